@@ -0,0 +1,88 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	airplaneWidth       = 120
+	airplaneHeight      = 40
+	airplaneSpawnMargin = 400
+)
+
+var (
+	airplaneColor       = color.RGBA{0xd8, 0xdc, 0xe0, 0xff}
+	airplaneWindowColor = color.RGBA{0x40, 0x60, 0x80, 0xff}
+
+	airplaneImg *ebiten.Image
+)
+
+// Airplane crosses the high band much faster than the birds.
+type Airplane struct {
+	x, y float64
+}
+
+func newAirplaneImage() *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, airplaneWidth, airplaneHeight))
+	for y := 0; y < airplaneHeight; y++ {
+		for x := 0; x < airplaneWidth; x++ {
+			dy := y - airplaneHeight/2
+			fuselage := x >= 6 && dy >= -6 && dy < 6
+			nose := x < 6 && dy*dy < (x+1)*6
+			// Wing sweeping back from the middle, and the tail fin
+			wing := x >= 50 && x < 70 && dy >= 0 && dy < (x-50)+2
+			tail := x >= 104 && dy < 0 && -dy < (x-100)*2
+			switch {
+			case fuselage && x >= 12 && x < 96 && dy == -2 && x%6 < 3:
+				img.Set(x, y, airplaneWindowColor)
+			case fuselage || nose || wing || tail:
+				img.Set(x, y, airplaneColor)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+func (g *Game) spawnAirplane(y float64) {
+	g.airplanes = append(g.airplanes, Airplane{x: g.birdman.x + screenWidth + airplaneSpawnMargin, y: y})
+	// From the side it comes in at
+	g.sfx.PlayPanned(whooshAudioData, g.direction())
+}
+
+func (g *Game) moveAirplanes() {
+	n := 0
+	for i := range g.airplanes {
+		a := &g.airplanes[i]
+		a.x -= g.config.AirplaneSpeed * simulationStep
+		if a.x+airplaneWidth/2 > g.camera.ViewX() {
+			g.airplanes[n] = *a
+			n++
+		}
+	}
+	g.airplanes = g.airplanes[:n]
+}
+
+func (g *Game) collidesAirplane() bool {
+	birdman := g.birdman
+	for i := range g.airplanes {
+		if Collides(birdman.hitbox(), birdman.x, birdman.y, airplaneHitbox, g.airplanes[i].x, g.airplanes[i].y) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Airplane) Draw(screen *ebiten.Image, game *Game) {
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(a.x-airplaneWidth/2-game.camera.ViewX(), a.y-airplaneHeight/2-game.camera.ViewY())
+	screen.DrawImage(airplaneImg, opt)
+}
+
+func (g *Game) drawAirplanes(screen *ebiten.Image) {
+	for i := range g.airplanes {
+		g.airplanes[i].Draw(screen, g)
+	}
+}
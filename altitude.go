@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	altitudeZoneStreaks      = 12
+	altitudeWarningInterval  = 0.4
+	altitudePressureRecovery = 2
+)
+
+var (
+	altitudeZoneColor   = color.RGBA{0xff, 0xff, 0xff, 0x30}
+	altitudeStreakColor = color.RGBA{0xff, 0xff, 0xff, 0x90}
+	altitudeDangerColor = color.RGBA{0xff, 0x40, 0x40, 0x60}
+)
+
+// updateAltitudePressure buffets the birdman downward while he is in the
+// high-altitude zone, in proportion to how deep he is in it, and reports
+// whether he has stayed there long enough to get damaged. Time spent in the
+// zone drains away again below it.
+func (g *Game) updateAltitudePressure() bool {
+	birdman := g.birdman
+	zone := g.config.AltitudeZoneHeight
+
+	if birdman.y < 0 {
+		birdman.y = 0
+		birdman.vy = math.Max(birdman.vy, 0)
+	}
+
+	// Enough boosters carry him through the zone, and space has no zone
+	if birdman.y >= zone || g.inSpace() || g.spaceCharged() {
+		birdman.pressureTime = math.Max(0, birdman.pressureTime-altitudePressureRecovery*simulationStep)
+		return false
+	}
+
+	depth := 1 - birdman.y/zone
+	birdman.vy += g.config.AltitudePush * depth * simulationStep
+
+	prev := birdman.pressureTime
+	birdman.pressureTime += simulationStep
+	if math.Floor(prev/altitudeWarningInterval) != math.Floor(birdman.pressureTime/altitudeWarningInterval) || prev == 0 {
+		g.sfx.PlaySE(warningAudioData)
+	}
+
+	if birdman.pressureTime >= g.config.AltitudeDamageTime {
+		birdman.pressureTime = 0
+		return true
+	}
+	return false
+}
+
+func (g *Game) isInAltitudeZone() bool {
+	return g.birdman.state == StateFlying && g.birdman.y < g.config.AltitudeZoneHeight && !g.inSpace() && !g.spaceCharged()
+}
+
+// altitudeDanger is how close the birdman is to being damaged by the
+// altitude pressure, from 0 to 1.
+func (g *Game) altitudeDanger() float64 {
+	return math.Min(1, g.birdman.pressureTime/g.config.AltitudeDamageTime)
+}
+
+// drawAltitudeZone shows the high-altitude zone as a band with wind
+// streaks blowing through it, reddening as the damage nears.
+func (g *Game) drawAltitudeZone(screen *ebiten.Image) {
+	zone := g.config.AltitudeZoneHeight
+	top := -g.camera.ViewY() - cameraMaxRise
+	bottom := zone - g.camera.ViewY()
+	ebitenutil.DrawRect(screen, 0, top, screenWidth, bottom-top, altitudeZoneColor)
+
+	if d := g.altitudeDanger(); d > 0 {
+		clr := altitudeDangerColor
+		clr.A = uint8(float64(clr.A) * d)
+		ebitenutil.DrawRect(screen, 0, top, screenWidth, bottom-top, clr)
+	}
+
+	// Streaks blow left faster than the scenery scrolls
+	const streakLength = 40
+	span := float64(screenWidth + streakLength)
+	for i := 0; i < altitudeZoneStreaks; i++ {
+		x := math.Mod(float64(i)*span/altitudeZoneStreaks-g.camera.ViewX()*2, span)
+		if x < 0 {
+			x += span
+		}
+		x -= streakLength
+		y := top + (bottom-top)*(float64(i*7%altitudeZoneStreaks)+0.5)/altitudeZoneStreaks
+		ebitenutil.DrawLine(screen, x, y, x+streakLength, y+4, altitudeStreakColor)
+	}
+}
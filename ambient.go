@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+const (
+	ambientLoopSeconds = 6
+	ambientFadeRate    = 0.00002
+)
+
+// Ambience loops synthesized sea and wind noise whose balance follows the
+// birdman's altitude: waves get louder near the sea and wind near the
+// ceiling.
+type Ambience struct {
+	mu         sync.Mutex
+	sea, wind  []float32
+	seaGain    float64
+	windGain   float64
+	seaTarget  float64
+	windTarget float64
+	pos        int
+}
+
+func NewAmbience(sampleRate int) *Ambience {
+	frames := sampleRate * ambientLoopSeconds
+	r := rand.New(rand.NewSource(2))
+	return &Ambience{
+		sea: newNoiseLoop(r, sampleRate, frames, 0.02, func(t float64) float64 {
+			// a few swells per loop
+			return 0.5 + 0.5*math.Sin(2*math.Pi*t*3)
+		}),
+		wind: newNoiseLoop(r, sampleRate, frames, 0.15, func(t float64) float64 {
+			return 0.6 + 0.25*math.Sin(2*math.Pi*t*2) + 0.15*math.Sin(2*math.Pi*t*5)
+		}),
+	}
+}
+
+// newNoiseLoop low-pass filters white noise and shapes it with a periodic
+// envelope. The tail is cross-faded into the head so the loop is seamless.
+func newNoiseLoop(r *rand.Rand, sampleRate, frames int, cutoff float64, envelope func(t float64) float64) []float32 {
+	fade := sampleRate / 4
+	buf := make([]float64, frames+fade)
+	var lp float64
+	for i := range buf {
+		lp += (r.Float64()*2 - 1 - lp) * cutoff
+		buf[i] = lp
+	}
+	loop := make([]float32, frames)
+	for i := 0; i < frames; i++ {
+		v := buf[i]
+		if i < fade {
+			t := float64(i) / float64(fade)
+			v = v*t + buf[frames+i]*(1-t)
+		}
+		loop[i] = float32(v * envelope(float64(i)/float64(frames)) * 0.5)
+	}
+	return loop
+}
+
+// SetAltitude sets the mix from y, where 0 is the ceiling and screenHeight
+// is the sea.
+func (a *Ambience) SetAltitude(y float64) {
+	h := math.Max(0, math.Min(1, y/screenHeight))
+	a.SetGains(h*h, (1-h)*(1-h))
+}
+
+func (a *Ambience) SetGains(sea, wind float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seaTarget, a.windTarget = sea, wind
+}
+
+func approach(v, target, step float64) float64 {
+	if v < target {
+		return math.Min(target, v+step)
+	}
+	return math.Max(target, v-step)
+}
+
+func (a *Ambience) Read(b []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	frames := len(a.sea)
+	if frames == 0 {
+		return 0, io.EOF
+	}
+
+	n := len(b) / bytesPerFrame
+	for i := 0; i < n; i++ {
+		a.seaGain = approach(a.seaGain, a.seaTarget, ambientFadeRate)
+		a.windGain = approach(a.windGain, a.windTarget, ambientFadeRate)
+		v := float64(a.sea[a.pos])*a.seaGain + float64(a.wind[a.pos])*a.windGain
+		s := uint16(int16(math.Max(-1, math.Min(1, v)) * math.MaxInt16))
+		binary.LittleEndian.PutUint16(b[i*bytesPerFrame:], s)
+		binary.LittleEndian.PutUint16(b[i*bytesPerFrame+2:], s)
+		a.pos = (a.pos + 1) % frames
+	}
+	return n * bytesPerFrame, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Animation is a sequence of frames of a horizontal sprite sheet.
+type Animation struct {
+	frames        []int
+	frameDuration int
+	loop          bool
+}
+
+var (
+	birdmanRunningAnimation = &Animation{frames: []int{0}, frameDuration: 1, loop: true}
+	birdmanFlyingAnimation  = &Animation{frames: []int{0, 1}, frameDuration: 10, loop: true}
+	birdmanDamagedAnimation = &Animation{frames: []int{1}, frameDuration: 1, loop: false}
+	birdFlyingAnimation     = &Animation{frames: []int{0, 1}, frameDuration: 10, loop: true}
+)
+
+// AnimationPlayer is the per-entity playback state of an Animation.
+type AnimationPlayer struct {
+	animation *Animation
+	ticks     int
+}
+
+// Play switches to the animation, restarting only if it differs from the
+// current one.
+func (p *AnimationPlayer) Play(a *Animation) {
+	if p.animation == a {
+		return
+	}
+	p.animation = a
+	p.ticks = 0
+}
+
+func (p *AnimationPlayer) Update() {
+	if p.animation == nil || p.Finished() {
+		return
+	}
+	p.ticks++
+}
+
+func (p *AnimationPlayer) length() int {
+	return len(p.animation.frames) * p.animation.frameDuration
+}
+
+func (p *AnimationPlayer) Finished() bool {
+	return p.animation != nil && !p.animation.loop && p.ticks >= p.length()-1
+}
+
+func (p *AnimationPlayer) Frame() int {
+	if p.animation == nil {
+		return 0
+	}
+	t := p.ticks
+	if p.animation.loop {
+		t %= p.length()
+	} else if t >= p.length() {
+		t = p.length() - 1
+	}
+	return p.animation.frames[t/p.animation.frameDuration]
+}
+
+// spriteFrames slices a horizontal sprite sheet into its frames once, as
+// SubImage allocates on every call.
+func spriteFrames(img *ebiten.Image, width, height int) []*ebiten.Image {
+	w, _ := img.Size()
+	frames := make([]*ebiten.Image, w/width)
+	for i := range frames {
+		frames[i] = img.SubImage(image.Rect(width*i, 0, width*(i+1), height)).(*ebiten.Image)
+	}
+	return frames
+}
+
+// drawOpt is reused by the draw calls of every frame to avoid allocating
+// options for each of them.
+var drawOpt ebiten.DrawImageOptions
+
+// scratchDrawOptions returns the shared options reset to identity. They are
+// valid until the next call.
+func scratchDrawOptions() *ebiten.DrawImageOptions {
+	drawOpt = ebiten.DrawImageOptions{}
+	return &drawOpt
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// AssetManager loads images, fonts and sounds from a file system, which is
+// the embedded resources by default but can be any fs.FS (e.g. os.DirFS for
+// development). Assets are cached by name and loaded by the first Get* of
+// them, which the loading scene does up front (see newAssetLoader).
+type AssetManager struct {
+	mu           sync.Mutex
+	source       fs.FS
+	audioContext *audio.Context
+	images       map[string]*ebiten.Image
+	fonts        map[string]*opentype.Font
+	faces        map[fontFaceKey]font.Face
+	audio        map[string][]byte
+}
+
+type fontFaceKey struct {
+	name string
+	size float64
+}
+
+// overlayFS serves files from upper when they exist there and from lower
+// otherwise, so a directory can replace individual embedded resources.
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+func NewOverlayFS(upper, lower fs.FS) fs.FS {
+	return &overlayFS{upper: upper, lower: lower}
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return o.lower.Open(name)
+}
+
+func NewAssetManager(source fs.FS, audioContext *audio.Context) *AssetManager {
+	return &AssetManager{
+		source:       source,
+		audioContext: audioContext,
+		images:       make(map[string]*ebiten.Image),
+		fonts:        make(map[string]*opentype.Font),
+		faces:        make(map[fontFaceKey]font.Face),
+		audio:        make(map[string][]byte),
+	}
+}
+
+// Invalidate drops the cached asset so the next Get* reads it again.
+func (m *AssetManager) Invalidate(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.images, name)
+	delete(m.fonts, name)
+	delete(m.audio, name)
+	for key := range m.faces {
+		if key.name == name {
+			delete(m.faces, key)
+		}
+	}
+}
+
+type assetKindType int
+
+const (
+	assetKindUnknown assetKindType = iota
+	assetKindImage
+	assetKindFont
+	assetKindAudio
+)
+
+func assetKind(name string) assetKindType {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png":
+		return assetKindImage
+	case ".ttf", ".otf":
+		return assetKindFont
+	case ".mp3", ".ogg", ".wav":
+		return assetKindAudio
+	default:
+		return assetKindUnknown
+	}
+}
+
+func (m *AssetManager) readFile(name string) ([]byte, error) {
+	data, _, err := m.readPacked(name)
+	return data, err
+}
+
+// readPacked reads the named file, or the packed one standing for it (see
+// cmd/packassets): the gzipped file with ".gz" appended, and for sounds the
+// one converted to OGG. It returns the name of the file read without ".gz",
+// which tells the format.
+func (m *AssetManager) readPacked(name string) ([]byte, string, error) {
+	candidates := []string{name}
+	if ext := path.Ext(name); assetKind(name) == assetKindAudio && strings.ToLower(ext) != ".ogg" {
+		candidates = append(candidates, strings.TrimSuffix(name, ext)+".ogg")
+	}
+	for _, c := range candidates {
+		data, err := fs.ReadFile(m.source, c)
+		if err == nil {
+			return data, c, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		data, err = fs.ReadFile(m.source, c+".gz")
+		if err == nil {
+			data, err = gunzip(data)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to decompress %s: %w", c+".gz", err)
+			}
+			return data, c, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+	return nil, "", fmt.Errorf("failed to read %s: %w", name, fs.ErrNotExist)
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readAllYielding(r)
+}
+
+func (m *AssetManager) GetImage(name string) (*ebiten.Image, error) {
+	m.mu.Lock()
+	img, ok := m.images[name]
+	m.mu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	data, err := m.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", name, err)
+	}
+	img = ebiten.NewImageFromImage(decoded)
+
+	m.mu.Lock()
+	m.images[name] = img
+	m.mu.Unlock()
+	return img, nil
+}
+
+func (m *AssetManager) getFont(name string) (*opentype.Font, error) {
+	m.mu.Lock()
+	f, ok := m.fonts[name]
+	m.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+
+	data, err := m.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err = opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	m.fonts[name] = f
+	m.mu.Unlock()
+	return f, nil
+}
+
+func (m *AssetManager) GetFontFace(name string, size float64) (font.Face, error) {
+	key := fontFaceKey{name: name, size: size}
+	m.mu.Lock()
+	face, ok := m.faces[key]
+	m.mu.Unlock()
+	if ok {
+		return face, nil
+	}
+
+	f, err := m.getFont(name)
+	if err != nil {
+		return nil, err
+	}
+	const dpi = 72
+	face, err = opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face %s (%v): %w", name, size, err)
+	}
+
+	m.mu.Lock()
+	m.faces[key] = face
+	m.mu.Unlock()
+	return face, nil
+}
+
+// GetAudio decodes a sound file into 16-bit stereo PCM at the context's
+// sample rate. The format is chosen by the file extension.
+func (m *AssetManager) GetAudio(name string) ([]byte, error) {
+	m.mu.Lock()
+	pcm, ok := m.audio[name]
+	m.mu.Unlock()
+	if ok {
+		return pcm, nil
+	}
+
+	data, packed, err := m.readPacked(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var stream io.Reader
+	switch ext := strings.ToLower(path.Ext(packed)); ext {
+	case ".mp3":
+		stream, err = mp3.Decode(m.audioContext, bytes.NewReader(data))
+	case ".ogg":
+		stream, err = vorbis.Decode(m.audioContext, bytes.NewReader(data))
+	case ".wav":
+		stream, err = wav.Decode(m.audioContext, bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported audio format: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio %s: %w", name, err)
+	}
+	pcm, err = readAllYielding(stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	m.audio[name] = pcm
+	m.mu.Unlock()
+	return pcm, nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type AudioSettings struct {
+	MasterVolume float64 `json:"master_volume"`
+	SFXVolume    float64 `json:"sfx_volume"`
+	BGMVolume    float64 `json:"bgm_volume"`
+	Muted        bool    `json:"muted"`
+	// whether to keep playing while the game is in the background
+	Background bool `json:"background"`
+}
+
+func DefaultAudioSettings() AudioSettings {
+	return AudioSettings{
+		MasterVolume: 1.0,
+		SFXVolume:    1.0,
+		BGMVolume:    0.8,
+		// Phones expect apps in the background to be quiet
+		Background: !mobilePlatform,
+	}
+}
+
+// AudioSink plays the sound effects triggered by the game logic.
+type AudioSink interface {
+	PlaySE(data []byte)
+	PlaySound(s *Sound)
+	PlayPanned(data []byte, pan float64) *PannedSound
+}
+
+// silentAudio is an AudioSink which discards every sound.
+type silentAudio struct{}
+
+func (silentAudio) PlaySE(data []byte) {}
+
+func (silentAudio) PlaySound(s *Sound) {}
+
+func (silentAudio) PlayPanned(data []byte, pan float64) *PannedSound {
+	return &PannedSound{data: data, pan: pan}
+}
+
+type sfxPlayer struct {
+	player *audio.Player
+	volume float64
+}
+
+// AudioManager owns every audio player so that volume settings apply to
+// sounds already playing as well as new ones.
+type AudioManager struct {
+	context  *audio.Context
+	settings *AudioSettings
+	sfx      []sfxPlayer
+	bgm      []*audio.Player
+	ambient  []*audio.Player
+}
+
+func NewAudioManager(context *audio.Context, settings *AudioSettings) *AudioManager {
+	return &AudioManager{
+		context:  context,
+		settings: settings,
+	}
+}
+
+func (m *AudioManager) sfxVolume() float64 {
+	if m.settings.Muted {
+		return 0
+	}
+	return m.settings.MasterVolume * m.settings.SFXVolume
+}
+
+func (m *AudioManager) bgmVolume() float64 {
+	if m.settings.Muted {
+		return 0
+	}
+	return m.settings.MasterVolume * m.settings.BGMVolume
+}
+
+func (m *AudioManager) PlaySE(data []byte) {
+	p := audio.NewPlayerFromBytes(m.context, data)
+	p.SetVolume(m.sfxVolume())
+	p.Play()
+	m.sfx = append(m.sfx, sfxPlayer{player: p, volume: 1.0})
+}
+
+// PlaySound plays a random variant of the sound at a slightly randomized
+// volume so that repeated effects don't sound mechanical.
+func (m *AudioManager) PlaySound(s *Sound) {
+	data := s.variants[rand.Intn(len(s.variants))]
+	volume := 1.0 - s.volumeJitter*rand.Float64()
+	p := audio.NewPlayerFromBytes(m.context, data)
+	p.SetVolume(volume * m.sfxVolume())
+	p.Play()
+	m.sfx = append(m.sfx, sfxPlayer{player: p, volume: volume})
+}
+
+// PlayPanned starts a sound effect whose stereo position can be moved while
+// it plays.
+func (m *AudioManager) PlayPanned(data []byte, pan float64) *PannedSound {
+	s := &PannedSound{data: data, pan: pan}
+	p, err := audio.NewPlayer(m.context, s)
+	if err != nil {
+		return s
+	}
+	p.SetVolume(m.sfxVolume())
+	p.Play()
+	m.sfx = append(m.sfx, sfxPlayer{player: p, volume: 1.0})
+	return s
+}
+
+// PlayBGM starts an endless music stream which follows the BGM volume.
+func (m *AudioManager) PlayBGM(src io.Reader) {
+	p, err := audio.NewPlayer(m.context, src)
+	if err != nil {
+		return
+	}
+	p.SetVolume(m.bgmVolume())
+	p.Play()
+	m.bgm = append(m.bgm, p)
+}
+
+// PlayAmbient starts an endless ambient stream which follows the SFX volume.
+func (m *AudioManager) PlayAmbient(src io.Reader) {
+	p, err := audio.NewPlayer(m.context, src)
+	if err != nil {
+		return
+	}
+	p.SetVolume(m.sfxVolume())
+	p.Play()
+	m.ambient = append(m.ambient, p)
+}
+
+// Suspend stops every sound effect and pauses the endless streams until
+// Resume.
+func (m *AudioManager) Suspend() {
+	for i, s := range m.sfx {
+		s.player.Close()
+		m.sfx[i] = sfxPlayer{}
+	}
+	m.sfx = m.sfx[:0]
+	for _, p := range m.bgm {
+		p.Pause()
+	}
+	for _, p := range m.ambient {
+		p.Pause()
+	}
+}
+
+func (m *AudioManager) Resume() {
+	for _, p := range m.bgm {
+		p.Play()
+	}
+	for _, p := range m.ambient {
+		p.Play()
+	}
+}
+
+func (m *AudioManager) ToggleMute() {
+	m.settings.Muted = !m.settings.Muted
+	m.applyVolumes()
+}
+
+func (m *AudioManager) applyVolumes() {
+	for _, s := range m.sfx {
+		s.player.SetVolume(s.volume * m.sfxVolume())
+	}
+	for _, p := range m.bgm {
+		p.SetVolume(m.bgmVolume())
+	}
+	for _, p := range m.ambient {
+		p.SetVolume(m.sfxVolume())
+	}
+}
+
+// Update releases finished sound effects and handles the mute hotkey, unless
+// the keys are typed for something else.
+func (m *AudioManager) Update(typing bool) {
+	if !typing && inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		m.ToggleMute()
+	}
+
+	n := 0
+	for _, s := range m.sfx {
+		if s.player.IsPlaying() {
+			m.sfx[n] = s
+			n++
+		} else {
+			s.player.Close()
+		}
+	}
+	for i := n; i < len(m.sfx); i++ {
+		m.sfx[i] = sfxPlayer{}
+	}
+	m.sfx = m.sfx[:n]
+}
+
+func volumeBar(v float64) string {
+	const steps = 10
+	n := int(v*steps + 0.5)
+	bar := "["
+	for i := 0; i < steps; i++ {
+		if i < n {
+			bar += "#"
+		} else {
+			bar += "-"
+		}
+	}
+	return bar + "]"
+}
+
+func adjustVolume(v *float64, delta int) {
+	*v += float64(delta) * 0.1
+	if *v < 0 {
+		*v = 0
+	} else if *v > 1 {
+		*v = 1
+	}
+}
+
+// Sound holds pre-rendered pitch variants of a decoded sound effect.
+type Sound struct {
+	variants     [][]byte
+	volumeJitter float64
+}
+
+// NewSound resamples the 16-bit stereo PCM data once per pitch factor at load
+// time, which is cheaper than resampling on every playback.
+func NewSound(data []byte, volumeJitter float64, pitches ...float64) *Sound {
+	s := &Sound{volumeJitter: volumeJitter}
+	if len(pitches) == 0 {
+		s.variants = [][]byte{data}
+		return s
+	}
+	for _, p := range pitches {
+		s.variants = append(s.variants, resample(data, p))
+	}
+	return s
+}
+
+const bytesPerFrame = 4
+
+func resample(data []byte, pitch float64) []byte {
+	if pitch == 1 {
+		return data
+	}
+
+	frames := len(data) / bytesPerFrame
+	outFrames := int(float64(frames) / pitch)
+	out := make([]byte, outFrames*bytesPerFrame)
+	sample := func(frame, ch int) float64 {
+		return float64(int16(binary.LittleEndian.Uint16(data[frame*bytesPerFrame+ch*2:])))
+	}
+	for i := 0; i < outFrames; i++ {
+		pos := float64(i) * pitch
+		f0 := int(pos)
+		f1 := f0 + 1
+		if f1 >= frames {
+			f1 = frames - 1
+		}
+		t := pos - float64(f0)
+		for ch := 0; ch < 2; ch++ {
+			v := sample(f0, ch)*(1-t) + sample(f1, ch)*t
+			binary.LittleEndian.PutUint16(out[i*bytesPerFrame+ch*2:], uint16(int16(v)))
+		}
+	}
+	return out
+}
+
+// PannedSound streams 16-bit stereo PCM data applying an equal-power pan.
+type PannedSound struct {
+	mu   sync.Mutex
+	data []byte
+	pos  int
+	pan  float64
+}
+
+// SetPan moves the sound between -1 (left) and 1 (right).
+func (s *PannedSound) SetPan(pan float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pan = math.Max(-1, math.Min(1, pan))
+}
+
+func (s *PannedSound) Read(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pos >= len(s.data) {
+		return 0, io.EOF
+	}
+	n := len(b) / bytesPerFrame * bytesPerFrame
+	if rest := len(s.data) - s.pos; n > rest {
+		n = rest
+	}
+
+	angle := (s.pan + 1) * math.Pi / 4
+	gains := [2]float64{math.Cos(angle) * math.Sqrt2, math.Sin(angle) * math.Sqrt2}
+	for i := 0; i < n; i += bytesPerFrame {
+		for ch := 0; ch < 2; ch++ {
+			v := float64(int16(binary.LittleEndian.Uint16(s.data[s.pos+i+ch*2:]))) * gains[ch]
+			v = math.Max(math.MinInt16, math.Min(math.MaxInt16, v))
+			binary.LittleEndian.PutUint16(b[i+ch*2:], uint16(int16(v)))
+		}
+	}
+	s.pos += n
+	return n, nil
+}
+
+// newWhooshData synthesizes a short wind noise burst used for bird fly-bys.
+func newWhooshData(sampleRate int, duration float64) []byte {
+	r := rand.New(rand.NewSource(1))
+	frames := int(float64(sampleRate) * duration)
+	data := make([]byte, frames*bytesPerFrame)
+	var lp float64
+	for i := 0; i < frames; i++ {
+		t := float64(i) / float64(frames)
+		env := math.Sin(math.Pi*t) * math.Sin(math.Pi*t)
+		lp += (r.Float64()*2 - 1 - lp) * 0.08
+		v := int16(lp * env * 0.6 * math.MaxInt16)
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame:], uint16(v))
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame+2:], uint16(v))
+	}
+	return data
+}
+
+// newBeepData synthesizes a short square wave tone used for warnings.
+func newBeepData(sampleRate int, freq, duration float64) []byte {
+	frames := int(float64(sampleRate) * duration)
+	data := make([]byte, frames*bytesPerFrame)
+	for i := 0; i < frames; i++ {
+		t := float64(i) / float64(sampleRate)
+		v := 0.25
+		if math.Mod(t*freq, 1) >= 0.5 {
+			v = -v
+		}
+		v *= 1 - float64(i)/float64(frames)
+		s := uint16(int16(v * math.MaxInt16))
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame:], s)
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame+2:], s)
+	}
+	return data
+}
+
+// newCheerData synthesizes a crowd cheering: a swell of band-limited noise
+// with claps scattered over it.
+func newCheerData(sampleRate int, duration float64) []byte {
+	r := rand.New(rand.NewSource(2))
+	frames := int(float64(sampleRate) * duration)
+	data := make([]byte, frames*bytesPerFrame)
+	var lo, hi float64
+	clap := 0
+	for i := 0; i < frames; i++ {
+		t := float64(i) / float64(frames)
+		env := math.Min(1, t*6) * (1 - t)
+		n := r.Float64()*2 - 1
+		lo += (n - lo) * 0.3
+		hi += (n - hi) * 0.03
+		v := (lo - hi) * env * 0.5
+		if clap == 0 && r.Float64() < 12/float64(sampleRate) {
+			clap = sampleRate / 100
+		}
+		if clap > 0 {
+			v += n * 0.3 * env * float64(clap) / float64(sampleRate/100)
+			clap--
+		}
+		s := uint16(int16(math.Max(-1, math.Min(1, v)) * math.MaxInt16))
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame:], s)
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame+2:], s)
+	}
+	return data
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Backdrop holds the sky, the sea and the cliff pre-rendered at their
+// on-screen size, so that each of them is drawn with a single call. The sky
+// and sea strips are one tile wider than the screen to allow scrolling.
+type Backdrop struct {
+	sky       *ebiten.Image
+	skyTop    *ebiten.Image
+	skyTileW  int
+	sea       *ebiten.Image
+	seaTileW  int
+	seaHeight int
+	cliff     *ebiten.Image
+}
+
+func NewBackdrop() *Backdrop {
+	seaW, seaH := seaImg.Size()
+	d := &Backdrop{seaTileW: seaW, seaHeight: seaH}
+
+	d.SetSky(backgroundImg)
+	d.sea = newTiledStrip(seaImg, 1, 1)
+
+	cliffW, cliffH := cliffImg.Size()
+	d.cliff = ebiten.NewImage(cliffWidth, cliffH)
+	opt := &ebiten.DrawImageOptions{}
+	opt.GeoM.Scale(cliffWidth/float64(cliffW), 1.0)
+	d.cliff.DrawImage(cliffImg, opt)
+
+	return d
+}
+
+// SetSky replaces the sky with a tile of another scene, stretched down to
+// the sea like the original.
+func (d *Backdrop) SetSky(tile *ebiten.Image) {
+	skyW, skyH := tile.Size()
+	d.skyTileW = skyW
+	d.sky = newTiledStrip(tile, 1, float64(screenHeight-d.seaHeight)/float64(skyH))
+	d.skyTop = d.sky.SubImage(image.Rect(0, 0, screenWidth, 1)).(*ebiten.Image)
+}
+
+func newTiledStrip(tile *ebiten.Image, scaleX, scaleY float64) *ebiten.Image {
+	w, h := tile.Size()
+	tileW := int(float64(w) * scaleX)
+	n := (screenWidth+tileW-1)/tileW + 1
+	strip := ebiten.NewImage(n*tileW, int(float64(h)*scaleY))
+	for i := 0; i < n; i++ {
+		opt := &ebiten.DrawImageOptions{}
+		opt.GeoM.Scale(scaleX, scaleY)
+		opt.GeoM.Translate(float64(i*tileW), 0)
+		strip.DrawImage(tile, opt)
+	}
+	return strip
+}
+
+// scrollOffset returns how far a strip of tileW wide tiles is shifted left
+// for the camera position.
+func scrollOffset(cameraX float64, tileW int) float64 {
+	o := int(cameraX) % tileW
+	if o < 0 {
+		o += tileW
+	}
+	return float64(o)
+}
+
+func (d *Backdrop) Draw(screen *ebiten.Image, cameraX, cameraY float64) {
+	// Stretch the top row of the sky over what the camera shows above it
+	if cameraY < 0 {
+		opt := scratchDrawOptions()
+		opt.GeoM.Scale(1, -cameraY+1)
+		screen.DrawImage(d.skyTop, opt)
+	}
+
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(-scrollOffset(cameraX, d.skyTileW), -cameraY)
+	screen.DrawImage(d.sky, opt)
+
+	opt = scratchDrawOptions()
+	opt.GeoM.Translate(-scrollOffset(cameraX, d.seaTileW), float64(screenHeight-d.seaHeight)-cameraY)
+	screen.DrawImage(d.sea, opt)
+}
+
+// DrawCliff draws the cliff the runs take off from when they start on it.
+func (d *Backdrop) DrawCliff(screen *ebiten.Image, cameraX, cameraY float64) {
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(-cliffWidth-cameraX, initialBirdmanPosY+birdmanHeight/3-cameraY)
+	screen.DrawImage(d.cliff, opt)
+}
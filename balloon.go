@@ -0,0 +1,156 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	balloonRadius      = 40
+	balloonBasketY     = 62
+	balloonBobHeight   = 8
+	balloonBobPeriod   = 4.0
+	balloonSpawnMargin = 80
+	// height of the birdman's feet below his center
+	birdmanFeetY = 16
+)
+
+var (
+	balloonColors = [2]color.RGBA{{0xe8, 0x50, 0x40, 0xff}, {0xf8, 0xd0, 0x40, 0xff}}
+	basketColor   = color.RGBA{0x90, 0x60, 0x30, 0xff}
+	ropeColor     = color.RGBA{0x50, 0x40, 0x30, 0xff}
+
+	balloonImg *ebiten.Image
+)
+
+// Balloon drifts slowly against the birdman. He can land on its top to
+// rest, which pops it after a while, but its basket is as hard as a bird.
+type Balloon struct {
+	x, y     float64
+	baseY    float64
+	time     float64
+	rideTime float64
+	rested   bool
+	carrying bool
+}
+
+func newBalloonImage() *ebiten.Image {
+	const w, h = 2 * balloonRadius, balloonBasketY + 18 + balloonRadius
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x-balloonRadius)+0.5, float64(y-balloonRadius)+0.5
+			switch {
+			case dx*dx+dy*dy < balloonRadius*balloonRadius:
+				// Vertical stripes
+				img.Set(x, y, balloonColors[int(math.Abs(dx)/10)%2])
+			case y >= balloonRadius+balloonBasketY-8 && math.Abs(dx) < 12:
+				img.Set(x, y, basketColor)
+			case y >= balloonRadius && math.Abs(math.Abs(dx)-(12+float64(balloonRadius+balloonBasketY-8-y)/3)) < 1:
+				img.Set(x, y, ropeColor)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+func (b *Balloon) top() float64 {
+	return b.y - balloonRadius
+}
+
+func (g *Game) spawnBalloon() {
+	y := 150 + g.rand.Float64()*(screenHeight-300)
+	g.balloons = append(g.balloons, Balloon{
+		x:     g.birdman.x + screenWidth + balloonSpawnMargin,
+		y:     y,
+		baseY: y,
+	})
+}
+
+// updateBalloons drifts the balloons, lands the birdman on one he falls
+// onto from above and carries him while he rests on it. It returns whether
+// he has hit a basket.
+func (g *Game) updateBalloons(prevY float64) (hit bool) {
+	birdman := g.birdman
+	if birdman.state == StateFlying && !g.inSpace() && birdman.x >= g.config.BalloonStartX && birdman.x >= g.nextBalloonX {
+		g.nextBalloonX = birdman.x + g.config.BalloonInterval
+		g.spawnBalloon()
+	}
+
+	birdman.riding = false
+	n := 0
+	for i := range g.balloons {
+		b := &g.balloons[i]
+		b.time += simulationStep
+		b.x -= g.config.BalloonSpeed * simulationStep
+		b.y = b.baseY + balloonBobHeight*math.Sin(2*math.Pi*b.time/balloonBobPeriod)
+
+		carrying := b.carrying
+		b.carrying = false
+		if birdman.state == StateFlying && birdman.vy >= 0 && math.Abs(birdman.x-b.x) < balloonRadius*0.8 {
+			// One-way: only landing from above counts, he passes through
+			// the envelope otherwise
+			feet, prevFeet := birdman.y+birdmanFeetY, prevY+birdmanFeetY
+			if carrying || (prevFeet <= b.top()+1 && feet >= b.top()) {
+				g.rideBalloon(b)
+			}
+		}
+
+		if birdman.state == StateFlying && Collides(birdman.hitbox(), birdman.x, birdman.y, basketHitbox, b.x, b.y) {
+			hit = true
+		}
+
+		if b.rideTime >= g.config.BalloonRideTime {
+			// Popped
+			if b.carrying {
+				birdman.riding = false
+			}
+			g.sfx.PlaySE(popAudioData)
+			continue
+		}
+		if b.x+balloonRadius > g.camera.ViewX() {
+			g.balloons[n] = *b
+			n++
+		}
+	}
+	g.balloons = g.balloons[:n]
+	return hit
+}
+
+// rideBalloon keeps the birdman standing on b, and gives back some of his
+// flap power lost to damage once he has rested on it for long enough.
+func (g *Game) rideBalloon(b *Balloon) {
+	birdman := g.birdman
+	birdman.riding = true
+	b.carrying = true
+	birdman.x = b.x
+	birdman.y = b.top() - birdmanFeetY
+	birdman.vy = 0
+	b.rideTime += simulationStep
+	if !b.rested && b.rideTime >= g.config.BalloonRestTime {
+		b.rested = true
+		if birdman.damagedCount > 0 {
+			birdman.damagedCount--
+		}
+	}
+}
+
+func (b *Balloon) Draw(screen *ebiten.Image, game *Game) {
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(b.x-balloonRadius-game.camera.ViewX(), b.y-balloonRadius-game.camera.ViewY())
+	if b.rideTime > 0 {
+		// Shrivel as it is about to pop
+		t := b.rideTime / game.config.BalloonRideTime
+		opt.ColorM.Scale(1, 1-0.4*t, 1-0.4*t, 1)
+	}
+	screen.DrawImage(balloonImg, opt)
+}
+
+func (g *Game) drawBalloons(screen *ebiten.Image) {
+	for i := range g.balloons {
+		g.balloons[i].Draw(screen, g)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	hazardBird     = "bird"
+	hazardAirplane = "airplane"
+
+	bandSpawnMargin = 50
+	bandSprayDrops  = 24
+	bandWindStreaks = 10
+)
+
+var (
+	bandSprayColor = color.RGBA{0xe8, 0xf8, 0xff, 0x30}
+	bandDropColor  = color.RGBA{0xf0, 0xfc, 0xff, 0xa0}
+	bandWindColor  = color.RGBA{0xff, 0xff, 0xff, 0x50}
+)
+
+// spawnHazard picks an entry from the spawn tables of all the bands by its
+// weight in the biome, and spawns the hazard somewhere in its band. A bird
+// is placed where it leaves a way through the others, see passable.
+func (g *Game) spawnHazard() {
+	total := 0.0
+	for _, b := range g.config.Bands {
+		for _, h := range b.Hazards {
+			total += g.hazardWeight(h)
+		}
+	}
+
+	r := g.rand.Float64() * total
+	for i, b := range g.config.Bands {
+		for _, h := range b.Hazards {
+			if r -= g.hazardWeight(h); r >= 0 {
+				continue
+			}
+			top := math.Max(g.config.BandTop(i), bandSpawnMargin)
+			bottom := float64(screenHeight - bandSpawnMargin)
+			if b.Bottom != 0 {
+				bottom = math.Min(b.Bottom, bottom)
+			}
+
+			switch h.Kind {
+			case hazardAirplane:
+				g.spawnAirplane(top + g.rand.Float64()*math.Max(0, bottom-top))
+			default:
+				x := g.birdman.x + screenWidth
+				y, ok := g.placeFairly(x, top, bottom, g.birdScale)
+				if !ok {
+					// Better no bird than a wall with no way through
+					return
+				}
+				bird := Bird{
+					frames: birdFrames,
+					x:      x,
+					y:      y,
+					scale:  g.birdScale,
+				}
+				bird.animation.Play(birdFlyingAnimation)
+				g.birds = append(g.birds, bird)
+			}
+			return
+		}
+	}
+}
+
+// bandDrag is how much the band the birdman is in slows him down.
+func (g *Game) bandDrag() float64 {
+	b := g.config.BandAt(g.birdman.y)
+	return b.Headwind + b.Spray
+}
+
+// drawBands gives the bands with headwind blowing streaks and the ones with
+// spray a mist with drops thrown up from the sea.
+func (g *Game) drawBands(screen *ebiten.Image) {
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	for i, b := range g.config.Bands {
+		top := g.config.BandTop(i) - viewY
+		bottom := float64(screenHeight) - viewY
+		if b.Bottom != 0 {
+			bottom = b.Bottom - viewY
+		}
+
+		if b.Spray > 0 {
+			ebitenutil.DrawRect(screen, 0, top, screenWidth, bottom-top, bandSprayColor)
+			for j := 0; j < bandSprayDrops; j++ {
+				// Each drop rises from the bottom and starts over, at its own
+				// pace
+				t := math.Mod(g.tricks.time*(0.5+float64(j%5)*0.1)+float64(j)*0.37, 1)
+				x := math.Mod(float64(j)*97-viewX*1.2, screenWidth)
+				if x < 0 {
+					x += screenWidth
+				}
+				y := bottom - (bottom-top)*t
+				ebitenutil.DrawRect(screen, x, y, 2, 2, bandDropColor)
+			}
+		}
+
+		if b.Headwind > 0 {
+			const streakLength = 60
+			span := float64(screenWidth + streakLength)
+			for j := 0; j < bandWindStreaks; j++ {
+				x := math.Mod(float64(j)*span/bandWindStreaks-g.tricks.time*b.Headwind*8-viewX, span)
+				if x < 0 {
+					x += span
+				}
+				x -= streakLength
+				y := top + (bottom-top)*(float64(j*3%bandWindStreaks)+0.5)/bandWindStreaks
+				ebitenutil.DrawLine(screen, x, y, x+streakLength, y, bandWindColor)
+			}
+		}
+	}
+}
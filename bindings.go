@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type Action int
+
+const (
+	ActionFlap Action = iota
+	ActionDive
+	ActionPause
+	ActionRoll
+	ActionThrow
+	ActionFocus
+)
+
+var actions = []Action{ActionFlap, ActionDive, ActionPause, ActionRoll, ActionThrow, ActionFocus}
+
+func (a Action) String() string {
+	switch a {
+	case ActionFlap:
+		return "FLAP"
+	case ActionDive:
+		return "DIVE"
+	case ActionPause:
+		return "PAUSE"
+	case ActionRoll:
+		return "ROLL"
+	case ActionThrow:
+		return "THROW"
+	case ActionFocus:
+		return "FOCUS"
+	default:
+		return "?"
+	}
+}
+
+const (
+	noKey           ebiten.Key           = -1
+	noMouseButton   ebiten.MouseButton   = -1
+	noGamepadButton ebiten.GamepadButton = -1
+)
+
+type ActionBinding struct {
+	Key           ebiten.Key           `json:"key"`
+	MouseButton   ebiten.MouseButton   `json:"mouse_button"`
+	GamepadButton ebiten.GamepadButton `json:"gamepad_button"`
+}
+
+// isJustPressed and isPressed leave the mouse button out unless mouse is
+// true, for the touches some browsers report as clicks too.
+func (b *ActionBinding) isJustPressed(mouse bool) bool {
+	if b.Key != noKey && inpututil.IsKeyJustPressed(b.Key) {
+		return true
+	}
+	if mouse && b.MouseButton != noMouseButton && inpututil.IsMouseButtonJustPressed(b.MouseButton) {
+		return true
+	}
+	if b.GamepadButton != noGamepadButton {
+		for _, id := range ebiten.GamepadIDs() {
+			if inpututil.IsGamepadButtonJustPressed(id, b.GamepadButton) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *ActionBinding) isPressed(mouse bool) bool {
+	if b.Key != noKey && ebiten.IsKeyPressed(b.Key) {
+		return true
+	}
+	if mouse && b.MouseButton != noMouseButton && ebiten.IsMouseButtonPressed(b.MouseButton) {
+		return true
+	}
+	if b.GamepadButton != noGamepadButton {
+		for _, id := range ebiten.GamepadIDs() {
+			if ebiten.IsGamepadButtonPressed(id, b.GamepadButton) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// capture rebinds the slot for whichever device produced a new press on this
+// frame. Escape is reserved for cancelling and is never captured.
+func (b *ActionBinding) capture() bool {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if k != ebiten.KeyEscape && inpututil.IsKeyJustPressed(k) {
+			b.Key = k
+			return true
+		}
+	}
+	for _, mb := range []ebiten.MouseButton{ebiten.MouseButtonLeft, ebiten.MouseButtonRight, ebiten.MouseButtonMiddle} {
+		if inpututil.IsMouseButtonJustPressed(mb) {
+			b.MouseButton = mb
+			return true
+		}
+	}
+	for _, id := range ebiten.GamepadIDs() {
+		for gb := ebiten.GamepadButton(0); gb <= ebiten.GamepadButtonMax; gb++ {
+			if inpututil.IsGamepadButtonJustPressed(id, gb) {
+				b.GamepadButton = gb
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *ActionBinding) String() string {
+	var names []string
+	if b.Key != noKey {
+		names = append(names, strings.ToUpper(b.Key.String()))
+	}
+	switch b.MouseButton {
+	case ebiten.MouseButtonLeft:
+		names = append(names, "MOUSE L")
+	case ebiten.MouseButtonRight:
+		names = append(names, "MOUSE R")
+	case ebiten.MouseButtonMiddle:
+		names = append(names, "MOUSE M")
+	}
+	if b.GamepadButton != noGamepadButton {
+		names = append(names, fmt.Sprintf("PAD %d", b.GamepadButton))
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, " / ")
+}
+
+type Bindings struct {
+	Flap  ActionBinding `json:"flap"`
+	Dive  ActionBinding `json:"dive"`
+	Pause ActionBinding `json:"pause"`
+	Roll  ActionBinding `json:"roll"`
+	Throw ActionBinding `json:"throw"`
+	Focus ActionBinding `json:"focus"`
+}
+
+func DefaultBindings() Bindings {
+	return Bindings{
+		Flap:  ActionBinding{Key: ebiten.KeySpace, MouseButton: ebiten.MouseButtonLeft, GamepadButton: ebiten.GamepadButton0},
+		Dive:  ActionBinding{Key: ebiten.KeyDown, MouseButton: ebiten.MouseButtonRight, GamepadButton: ebiten.GamepadButton1},
+		Pause: ActionBinding{Key: ebiten.KeyP, MouseButton: noMouseButton, GamepadButton: ebiten.GamepadButton7},
+		Roll:  ActionBinding{Key: ebiten.KeyR, MouseButton: noMouseButton, GamepadButton: ebiten.GamepadButton2},
+		Throw: ActionBinding{Key: ebiten.KeyE, MouseButton: noMouseButton, GamepadButton: ebiten.GamepadButton3},
+		Focus: ActionBinding{Key: ebiten.KeyF, MouseButton: noMouseButton, GamepadButton: ebiten.GamepadButton5},
+	}
+}
+
+func (b *Bindings) Get(a Action) *ActionBinding {
+	switch a {
+	case ActionFlap:
+		return &b.Flap
+	case ActionDive:
+		return &b.Dive
+	case ActionPause:
+		return &b.Pause
+	case ActionRoll:
+		return &b.Roll
+	case ActionThrow:
+		return &b.Throw
+	case ActionFocus:
+		return &b.Focus
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// pixels over which a biome fades into the next
+	biomeFadeLength   = 1500
+	biomeArtWidth     = 320
+	biomeArtHeight    = 120
+	biomeArtParallax  = 0.4
+	biomeBannerTime   = 2.5
+	biomeRainDrops    = 40
+	biomeRainSpeed    = 900
+	biomeRainSlant    = 0.3
+	biomeRainLength   = 14
+	biomeBannerY      = 90
+	biomeCloudsHeight = 90
+)
+
+var biomeRainColor = color.RGBA{0xc0, 0xd0, 0xe0, 0x80}
+
+// biomeArts are what the biomes show of themselves in the background, by
+// the art name of the config.
+var biomeArts map[string]*ebiten.Image
+
+func newBiomeArts() map[string]*ebiten.Image {
+	return map[string]*ebiten.Image{
+		"rocks":   ebiten.NewImageFromImage(newRocksImage(biomeArtWidth, biomeArtHeight)),
+		"islands": ebiten.NewImageFromImage(newIslandsImage(biomeArtWidth, biomeArtHeight)),
+		"clouds":  ebiten.NewImageFromImage(newStormCloudsImage(biomeArtWidth, biomeCloudsHeight)),
+	}
+}
+
+// BiomeAt returns the biome of the course at x, the one after it and how far
+// into the fade to the next one x is, from 0 to 1. The biomes repeat in
+// order. Both are nil without biomes.
+func (c *GameConfig) BiomeAt(x float64) (cur, next *Biome, fade float64) {
+	if len(c.Biomes) == 0 {
+		return nil, nil, 0
+	}
+	n := int(math.Floor(math.Max(0, x) / c.BiomeLength))
+	cur = &c.Biomes[n%len(c.Biomes)]
+	next = &c.Biomes[(n+1)%len(c.Biomes)]
+	into := math.Max(0, x) - float64(n)*c.BiomeLength
+	fade = math.Max(0, (into-(c.BiomeLength-biomeFadeLength))/biomeFadeLength)
+	return cur, next, fade
+}
+
+func (g *Game) biome() *Biome {
+	b, _, _ := g.config.BiomeAt(g.birdman.x)
+	return b
+}
+
+// hazardWeight is the weight of a hazard of the spawn table in the biome
+// the birdman is in.
+func (g *Game) hazardWeight(h BandHazard) float64 {
+	if b := g.biome(); b != nil {
+		if s, ok := b.HazardScale[h.Kind]; ok {
+			return h.Weight * s
+		}
+	}
+	return h.Weight
+}
+
+func (g *Game) fishSpawnRate() float64 {
+	if b := g.biome(); b != nil {
+		return g.config.FishSpawnRate * b.FishScale
+	}
+	return g.config.FishSpawnRate
+}
+
+// updateBiome shows the name of a biome when the birdman enters it.
+func (g *Game) updateBiome() {
+	b := g.biome()
+	if b == nil || b == g.currentBiome {
+		return
+	}
+	if g.currentBiome != nil {
+		g.biomeBannerTime = biomeBannerTime
+	}
+	g.currentBiome = b
+}
+
+func biomePaletteColor(b *Biome, alpha float64) color.RGBA {
+	p := b.Palette
+	return color.RGBA{
+		uint8(float64(p[0]) * alpha),
+		uint8(float64(p[1]) * alpha),
+		uint8(float64(p[2]) * alpha),
+		uint8(float64(p[3]) * alpha),
+	}
+}
+
+// drawBiome tints the sky and the sea with the palette of the biome the
+// camera is in, and draws its art, fading into the next biome's.
+func (g *Game) drawBiome(screen *ebiten.Image) {
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	cur, next, fade := g.config.BiomeAt(viewX + cameraOffsetX)
+	if cur == nil || g.inSpace() {
+		return
+	}
+	for _, layer := range []struct {
+		biome *Biome
+		alpha float64
+	}{{cur, 1 - fade}, {next, fade}} {
+		b := layer.biome
+		if layer.alpha <= 0 {
+			continue
+		}
+		// The palette is premultiplied like ebiten's colors
+		ebitenutil.DrawRect(screen, 0, 0, screenWidth, screenHeight, biomePaletteColor(b, layer.alpha))
+		if img, ok := biomeArts[b.Art]; ok {
+			_, h := img.Size()
+			y := -viewY
+			if b.Art != "clouds" && g.backdrop != nil {
+				y = float64(screenHeight-g.backdrop.seaHeight-h) - viewY
+			}
+			x := -scrollOffset(viewX*biomeArtParallax, biomeArtWidth)
+			for ; x < screenWidth; x += biomeArtWidth {
+				opt := scratchDrawOptions()
+				opt.GeoM.Translate(x, y)
+				opt.ColorM.Scale(1, 1, 1, layer.alpha)
+				screen.DrawImage(img, opt)
+			}
+		}
+		if b.Rain {
+			g.drawRain(screen, layer.alpha)
+		}
+	}
+}
+
+func (g *Game) drawRain(screen *ebiten.Image, alpha float64) {
+	clr := biomeRainColor
+	clr.A = uint8(float64(clr.A) * alpha)
+	for i := 0; i < biomeRainDrops; i++ {
+		t := math.Mod(g.tricks.time*(1+float64(i%3)*0.2)+float64(i)*0.61, 1)
+		x := math.Mod(float64(i)*53-g.camera.ViewX(), screenWidth)
+		if x < 0 {
+			x += screenWidth
+		}
+		y := t * (screenHeight + biomeRainLength)
+		ebitenutil.DrawLine(screen, x-y*biomeRainSlant, y-biomeRainLength, x-y*biomeRainSlant-biomeRainLength*biomeRainSlant, y, clr)
+	}
+}
+
+func (g *Game) drawBiomeBanner(screen *ebiten.Image) {
+	if g.biomeBannerTime <= 0 || g.currentBiome == nil {
+		return
+	}
+	alpha := math.Min(1, g.biomeBannerTime)
+	clr := color.RGBA{0xff, 0xff, 0xff, uint8(0xff * alpha)}
+	name := g.currentBiome.Name
+	text.Draw(screen, name, regularFont, screenWidth/2-len(name)*regularFontSize/2, biomeBannerY, clr)
+}
+
+// newRocksImage draws jagged rocks rising from the sea along the coast.
+func newRocksImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	far := color.RGBA{0x78, 0x70, 0x68, 0xff}
+	near := color.RGBA{0x50, 0x48, 0x44, 0xff}
+	fillSilhouette(img, far, func(x int) int {
+		return int(math.Max(0, float64(h)*0.35+wave(x, w, 10, 0, 16, 0, 0, 8)))
+	})
+	fillSilhouette(img, near, func(x int) int {
+		// Stacks with sharp tops
+		v := math.Abs(math.Sin(3 * math.Pi * float64(x) / float64(w)))
+		return int(float64(h) * 0.8 * math.Pow(v, 6))
+	})
+	return img
+}
+
+// newIslandsImage draws green islands scattered over the horizon.
+func newIslandsImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	land := color.RGBA{0x3c, 0x78, 0x48, 0xff}
+	sand := color.RGBA{0xe0, 0xd0, 0x98, 0xff}
+	fillSilhouette(img, sand, func(x int) int {
+		return int(math.Max(0, wave(x, w, 0, 0, 18, 0, 8)+4))
+	})
+	fillSilhouette(img, land, func(x int) int {
+		return int(math.Max(0, wave(x, w, 0, 0, 18, 0, 8)*2-4))
+	})
+	return img
+}
+
+// newStormCloudsImage draws a dark cloud cover hanging from the top of the
+// sky.
+func newStormCloudsImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	cloud := color.RGBA{0x38, 0x3c, 0x48, 0xe0}
+	for x := 0; x < w; x++ {
+		bottom := float64(h)*0.6 + wave(x, w, 0, 6, 0, 8, 0, 0, 4)
+		for y := 0; y < int(bottom) && y < h; y++ {
+			img.SetRGBA(x, y, cloud)
+		}
+	}
+	return img
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	// of the birds knocked down by the birdman: the share of his speed they
+	// are thrown forward with, the hop up, the fall and the spin per second
+	birdTumbleShare   = 0.4
+	birdTumbleHop     = 120
+	birdTumbleGravity = 900
+	birdTumbleSpin    = 12
+	featherPuffCount  = 10
+	featherPuffTime   = 0.8
+)
+
+var featherPuffColors = []color.RGBA{
+	{0xff, 0xff, 0xff, 0xff},
+	{0xc8, 0xc8, 0xd0, 0xff},
+}
+
+// BirdState is where a bird is in being fed. Wild birds are the hazards;
+// fed ones catch the crumb, escort the birdman for a while and then leave.
+// Knocked ones tumble down into the sea.
+type BirdState int
+
+const (
+	BirdWild BirdState = iota
+	BirdFeeding
+	BirdEscorting
+	BirdLeaving
+	BirdTumbling
+)
+
+// FeatherPuff is the feathers knocked out of a bird, drifting apart.
+type FeatherPuff struct {
+	x, y float64
+	time float64
+}
+
+type Bird struct {
+	frames []*ebiten.Image
+	x, y   float64
+	sound  *PannedSound
+	passed bool
+	// set once the bird has hit the birdman, so that it does not hit him
+	// again while he recovers right in front of it
+	struck bool
+	// set once the companion has had its chance to block the bird
+	companionChecked bool
+	// id of the flock the bird flies in, or 0
+	flock            int
+	offsetX, offsetY float64
+	animation        AnimationPlayer
+	state            BirdState
+	// seconds in the state
+	stateTime float64
+	// of the tumble down
+	vx, vy float64
+	// the size by the modifiers, 0 for the normal one
+	scale float64
+}
+
+// isHazard reports whether the bird can still hit the birdman.
+func (b *Bird) isHazard() bool {
+	return !b.struck && b.state == BirdWild
+}
+
+// updateSound plays a whoosh once the bird enters the view and pans it
+// relative to the birdman on the screen, as a cue for incoming threats.
+func (b *Bird) updateSound(game *Game) {
+	pan := game.direction() * (b.x - game.birdman.x) / (screenWidth / 2)
+	if b.sound == nil {
+		if b.x-birdWidth/2 < game.camera.x+screenWidth {
+			b.sound = game.sfx.PlayPanned(whooshAudioData, pan)
+		}
+		return
+	}
+	b.sound.SetPan(pan)
+}
+
+func (b *Bird) hitbox() Hitbox {
+	h := birdHitboxes.Frame(b.animation.Frame())
+	if b.scale != 0 {
+		return h.Scaled(b.scale)
+	}
+	return h
+}
+
+func (b *Bird) Draw(screen *ebiten.Image, game *Game) {
+	img := b.frames[b.animation.Frame()]
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(-float64(birdWidth)/2, -float64(birdHeight)/2)
+	// Fed birds turn around to fly along
+	if b.state == BirdFeeding || b.state == BirdEscorting {
+		opt.GeoM.Scale(-1, 1)
+	}
+	if b.scale != 0 {
+		opt.GeoM.Scale(b.scale, b.scale)
+	}
+	if b.state == BirdTumbling {
+		opt.GeoM.Rotate(b.stateTime * birdTumbleSpin)
+	}
+	opt.GeoM.Translate(b.x-game.camera.ViewX(), b.y-game.camera.ViewY())
+	screen.DrawImage(img, opt)
+}
+
+// knockDown sends the bird tumbling down from the birdman moving at vx.
+func (b *Bird) knockDown(vx float64) {
+	b.state = BirdTumbling
+	b.stateTime = 0
+	b.vx = vx * birdTumbleShare
+	b.vy = -birdTumbleHop
+}
+
+// fallen reports whether the bird has tumbled down out of the view.
+func (b *Bird) fallen() bool {
+	return b.state == BirdTumbling && b.y > screenHeight+birdHeight
+}
+
+// birdSpeed is how fast the birds fly in the section of the difficulty curve
+// the birdman is in.
+func (g *Game) birdSpeed() float64 {
+	return g.config.BirdSpeed * g.config.DifficultyAt(g.birdman.x).BirdSpeedScale
+}
+
+func (g *Game) updateFeatherPuffs() {
+	n := 0
+	for i := range g.featherPuffs {
+		g.featherPuffs[i].time += simulationStep
+		if g.featherPuffs[i].time < featherPuffTime {
+			g.featherPuffs[n] = g.featherPuffs[i]
+			n++
+		}
+	}
+	g.featherPuffs = g.featherPuffs[:n]
+}
+
+func (g *Game) drawFeatherPuffs(screen *ebiten.Image) {
+	for _, p := range g.featherPuffs {
+		t := p.time / featherPuffTime
+		for i := 0; i < featherPuffCount; i++ {
+			// Feathers burst out all around and drift down as they fade
+			a := 2 * math.Pi * (float64(i) + 0.5) / featherPuffCount
+			r := 30 * math.Sqrt(t)
+			x := p.x + math.Cos(a)*r - g.camera.ViewX()
+			y := p.y + math.Sin(a)*r + 20*t - g.camera.ViewY()
+			clr := featherPuffColors[i%len(featherPuffColors)]
+			clr.A = uint8(0xff * (1 - t))
+			ebitenutil.DrawRect(screen, x-3, y-1, 6, 3, clr)
+		}
+	}
+}
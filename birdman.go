@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type BirdmanState int
+
+const (
+	StateRunning BirdmanState = iota
+	StateFlying
+	StateDamaged
+)
+
+func (s BirdmanState) String() string {
+	switch s {
+	case StateRunning:
+		return "running"
+	case StateFlying:
+		return "flying"
+	case StateDamaged:
+		return "damaged"
+	default:
+		return "?"
+	}
+}
+
+type Birdman struct {
+	frames              []*ebiten.Image
+	state               BirdmanState
+	x, y                float64
+	vx, vy              float64
+	knockbackVx         float64
+	damagedCount        int
+	damagedTicks        int
+	damagedSkippedTicks int
+	pressureTime        float64
+	draftTime           float64
+	boostTime           float64
+	skimTime            float64
+	// seconds since the last flap
+	glideTime float64
+	// the fraction of the flap power lost with the distance which is restored
+	flapRecovery float64
+	riding       bool
+	rolling      bool
+	angle        float64
+	animation    AnimationPlayer
+	// seconds the birdman is safe for after losing a heart or continuing
+	heartLossTime float64
+	// played on the run-up, which differs by the launch
+	runAnimation *Animation
+	// the size by the modifiers, 0 for the normal one
+	scale float64
+}
+
+func (b *Birdman) updateAnimation() {
+	switch b.state {
+	case StateRunning:
+		if b.runAnimation != nil {
+			b.animation.Play(b.runAnimation)
+		} else {
+			b.animation.Play(birdmanRunningAnimation)
+		}
+	case StateFlying:
+		b.animation.Play(birdmanFlyingAnimation)
+	case StateDamaged:
+		b.animation.Play(birdmanDamagedAnimation)
+	}
+	b.animation.Update()
+}
+
+func (b *Birdman) hitbox() Hitbox {
+	h := birdmanHitboxes.Frame(b.animation.Frame())
+	if b.scale != 0 {
+		return h.Scaled(b.scale)
+	}
+	return h
+}
+
+func (b *Birdman) Draw(screen *ebiten.Image, game *Game) {
+	// Blink while safe
+	if b.heartLossTime > 0 && int(b.heartLossTime*10)%2 == 1 {
+		return
+	}
+	img := b.frames[b.animation.Frame()]
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(-float64(birdmanWidth)/2, -float64(birdmanHeight)/2)
+	if b.scale != 0 {
+		opt.GeoM.Scale(b.scale, b.scale)
+	}
+	if b.state == StateDamaged {
+		opt.GeoM.Rotate(float64(b.damagedTicks) / 3)
+	} else if b.rolling {
+		// Loop backwards, nose up first
+		opt.GeoM.Rotate(-b.angle)
+	}
+	opt.GeoM.Translate(b.x-game.camera.ViewX(), b.y-game.camera.ViewY())
+	screen.DrawImage(img, opt)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	botTargetY       = screenHeight * 0.45
+	botCeilingMargin = 70
+	botLookahead     = 0.25
+	botDodgeRange    = 480
+	botDodgeGap      = 150
+	botFlockDepth    = 160
+	botFlapSlack     = 50
+)
+
+// Bot is a Controller playing the game by itself: it keeps the birdman around
+// a target altitude and moves that target into the openings between the
+// birds ahead.
+type Bot struct {
+	game   *Game
+	points [][2]float64
+}
+
+func NewBot(game *Game) *Bot {
+	return &Bot{game: game}
+}
+
+// targetY returns the altitude the bot wants to be at for now: the middle
+// of the widest opening between the birds just ahead, which for a single
+// bird means over or under it and for a flock its gap, if any.
+func (b *Bot) targetY() float64 {
+	birdman := b.game.birdman
+	obstacles := b.obstacles()
+
+	nearestX := math.Inf(1)
+	for _, o := range obstacles {
+		if dx := o[0] - birdman.x; dx >= -birdWidth/2 && dx <= botDodgeRange && o[0] < nearestX {
+			nearestX = o[0]
+		}
+	}
+	if math.IsInf(nearestX, 1) {
+		return botTargetY
+	}
+
+	var ys []float64
+	for _, o := range obstacles {
+		if o[0] >= nearestX && o[0] < nearestX+botFlockDepth {
+			ys = append(ys, o[1])
+		}
+	}
+	sort.Float64s(ys)
+
+	// Candidates are over the top bird, under the bottom one and the middle
+	// of each opening in between, each with its clearance from the birds
+	type candidate struct{ y, clearance float64 }
+	top := math.Max(botCeilingMargin, ys[0]-botDodgeGap)
+	// Fish leap at those skimming the sea, so stay above that
+	bottom := math.Min(b.game.config.FishSkimY, ys[len(ys)-1]+botDodgeGap)
+	candidates := []candidate{
+		{top, ys[0] - top},
+		{bottom, bottom - ys[len(ys)-1]},
+	}
+	for i := 1; i < len(ys); i++ {
+		candidates = append(candidates, candidate{(ys[i] + ys[i-1]) / 2, (ys[i] - ys[i-1]) / 2})
+	}
+
+	// Prefer the roomy ones, then the ones closer to the birdman
+	best, bestScore := botTargetY, math.Inf(-1)
+	for _, c := range candidates {
+		score := math.Min(c.clearance, botDodgeGap) - math.Abs(birdman.y-c.y)/4
+		if score > bestScore {
+			best, bestScore = c.y, score
+		}
+	}
+	return best
+}
+
+// obstacles returns the positions of everything the birdman must not touch:
+// the birds, the airplanes, the balloon baskets and the fish.
+func (b *Bot) obstacles() [][2]float64 {
+	b.points = b.points[:0]
+	for i := range b.game.birds {
+		b.points = append(b.points, [2]float64{b.game.birds[i].x, b.game.birds[i].y})
+	}
+	for i := range b.game.airplanes {
+		b.points = append(b.points, [2]float64{b.game.airplanes[i].x, b.game.airplanes[i].y})
+	}
+	for i := range b.game.balloons {
+		balloon := &b.game.balloons[i]
+		b.points = append(b.points, [2]float64{balloon.x, balloon.y + balloonBasketY})
+	}
+	for i := range b.game.fish {
+		// A rising fish is in the way up to the top of its leap
+		f := &b.game.fish[i]
+		y := f.y
+		if f.vy < 0 {
+			y -= f.vy * f.vy / (2 * b.game.config.FishGravity)
+		}
+		b.points = append(b.points, [2]float64{f.x, y})
+	}
+	return b.points
+}
+
+// predictedY is roughly where the birdman will be shortly if nothing is
+// done.
+func (b *Bot) predictedY() float64 {
+	birdman := b.game.birdman
+	vy := math.Min(birdman.vy+b.game.config.Gravity*botLookahead, b.game.config.MaxFallSpeed)
+	return birdman.y + (birdman.vy+vy)/2*botLookahead
+}
+
+// ConsumeFlap flaps when the birdman is about to fall below the target, so
+// that he goes up and down around it, unless the flap hits the ceiling.
+func (b *Bot) ConsumeFlap() (ok, strong bool) {
+	birdman := b.game.birdman
+	if birdman.vy < 0 {
+		return false, false
+	}
+	power := b.game.config.FlapPower(birdman.x) / float64(birdman.damagedCount+1)
+	lift := power * power / (2 * b.game.config.Gravity)
+	if birdman.y-lift < botCeilingMargin {
+		return false, false
+	}
+	return b.predictedY() > b.targetY()+math.Max(lift/2, botFlapSlack), false
+}
+
+func (b *Bot) IsDivePressed() bool {
+	birdman := b.game.birdman
+	return birdman.vy >= 0 && b.predictedY() < b.targetY()-botDodgeGap
+}
+
+func (b *Bot) ConsumeRoll() bool {
+	return false
+}
+
+func (b *Bot) ConsumeThrow() bool {
+	return false
+}
+
+func (b *Bot) ConsumeFocus() bool {
+	return false
+}
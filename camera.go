@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// screen x the followed target is kept at
+	cameraOffsetX = 100
+	// how fast the camera catches up with its target, per second
+	cameraSmoothing = 4
+	// seconds of forward movement shown ahead of the target
+	cameraLookahead = 0.5
+	// the camera starts rising when the target is closer to the ceiling
+	cameraCeilingMargin = 80
+	cameraMaxRise       = 60
+)
+
+// Camera is the top-left corner of the view in world coordinates. It eases
+// towards its target rather than being locked to it and may shake.
+type Camera struct {
+	x, y           float64
+	shakeStrength  float64
+	shakeDuration  float64
+	shakeRemaining float64
+	shakeX, shakeY float64
+}
+
+func (c *Camera) Reset(x, y float64) {
+	*c = Camera{x: x, y: y}
+}
+
+// Follow moves the camera towards the target (e.g. the birdman) moving
+// forward at vx.
+func (c *Camera) Follow(targetX, targetY, vx, dt float64) {
+	t := 1 - math.Exp(-cameraSmoothing*dt)
+
+	desiredX := targetX - cameraOffsetX + vx*cameraLookahead
+	c.x += (desiredX - c.x) * t
+
+	// Rise a little near the ceiling so that the target stays in view
+	desiredY := 0.0
+	if targetY < cameraCeilingMargin {
+		desiredY = -math.Min((cameraCeilingMargin-targetY)/2, cameraMaxRise)
+	}
+	c.y += (desiredY - c.y) * t
+}
+
+// Shake starts shaking the view by up to strength pixels, fading out over
+// duration seconds. A stronger shake replaces a weaker one.
+func (c *Camera) Shake(strength, duration float64) {
+	if c.shakeRemaining > 0 && c.currentShake() > strength {
+		return
+	}
+	c.shakeStrength = strength
+	c.shakeDuration = duration
+	c.shakeRemaining = duration
+}
+
+func (c *Camera) currentShake() float64 {
+	if c.shakeDuration <= 0 {
+		return 0
+	}
+	return c.shakeStrength * c.shakeRemaining / c.shakeDuration
+}
+
+func (c *Camera) Update(dt float64) {
+	if c.shakeRemaining <= 0 {
+		c.shakeX, c.shakeY = 0, 0
+		return
+	}
+	c.shakeRemaining = math.Max(0, c.shakeRemaining-dt)
+	// The shake is cosmetic, so it doesn't use the game's random source
+	s := c.currentShake()
+	c.shakeX = (rand.Float64()*2 - 1) * s
+	c.shakeY = (rand.Float64()*2 - 1) * s
+}
+
+// ViewX and ViewY are the position to draw the world from, shake included.
+func (c *Camera) ViewX() float64 {
+	return c.x + c.shakeX
+}
+
+func (c *Camera) ViewY() float64 {
+	return c.y + c.shakeY
+}
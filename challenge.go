@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// 10 bytes in 5 bits a letter
+	challengeCodeLength = 16
+	challengeDataLength = 10
+	challengeSeedMask   = 1<<24 - 1
+	challengeInfoY      = titleMenuY + smallFontSize*10
+)
+
+// The letters are the same as the sync codes', which are easy to type in
+var challengeEncoding = base32.NewEncoding(syncCodeChars).WithPadding(base32.NoPadding)
+
+var errBadChallengeCode = errors.New("not a challenge code")
+
+type ChallengeKind int
+
+const (
+	ChallengeDistance ChallengeKind = iota
+	ChallengeRace
+	ChallengeSpeedrun
+)
+
+// Challenge is a run to play again on another device: the same hazards from
+// the same seed and launch, in the same mode with the same modifiers. It
+// carries the result of the one who played it first to compare with.
+type Challenge struct {
+	Seed uint32
+	Kind ChallengeKind
+	// in meters, of the race or the speedrun
+	Target    int
+	Launch    int
+	Modifiers []string
+	// in meters for the distance, or in hundredths of a second of the time,
+	// 0 if it didn't finish
+	Result int
+}
+
+// Encode packs the challenge into a short code. The last byte is a check
+// against typos.
+func (c *Challenge) Encode() string {
+	b := make([]byte, challengeDataLength)
+	b[0], b[1], b[2] = byte(c.Seed>>16), byte(c.Seed>>8), byte(c.Seed)
+	b[3] = byte(c.Kind)<<6 | byte(c.Target/checkpointDistance)
+	b[4] = byte(c.Launch)
+	for i := range modifiers {
+		for _, id := range c.Modifiers {
+			if id == modifiers[i].ID {
+				b[5] |= 1 << i
+			}
+		}
+	}
+	b[6], b[7], b[8] = byte(c.Result>>16), byte(c.Result>>8), byte(c.Result)
+	b[9] = byte(crc32.ChecksumIEEE(b[:9]))
+	return challengeEncoding.EncodeToString(b)
+}
+
+// DecodeChallenge reads a code typed in or opened, in any case and with or
+// without the dashes.
+func DecodeChallenge(code string) (*Challenge, error) {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != challengeCodeLength {
+		return nil, errBadChallengeCode
+	}
+	b, err := challengeEncoding.DecodeString(code)
+	if err != nil || len(b) != challengeDataLength || b[9] != byte(crc32.ChecksumIEEE(b[:9])) {
+		return nil, errBadChallengeCode
+	}
+	c := &Challenge{
+		Seed:   uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]),
+		Kind:   ChallengeKind(b[3] >> 6),
+		Target: int(b[3]&0x3f) * checkpointDistance,
+		Launch: int(b[4]),
+		Result: int(b[6])<<16 | int(b[7])<<8 | int(b[8]),
+	}
+	for i := range modifiers {
+		if b[5]&(1<<i) != 0 {
+			c.Modifiers = append(c.Modifiers, modifiers[i].ID)
+		}
+	}
+	if c.Kind > ChallengeSpeedrun || c.Launch >= len(launches) || (c.Kind != ChallengeDistance && c.Target == 0) {
+		return nil, errBadChallengeCode
+	}
+	return c, nil
+}
+
+// formatChallengeCode splits the code in fours for reading it out.
+func formatChallengeCode(code string) string {
+	var parts []string
+	for ; len(code) > 4; code = code[4:] {
+		parts = append(parts, code[:4])
+	}
+	return strings.Join(append(parts, code), "-")
+}
+
+func formatChallengeTime(result int) string {
+	return formatRunTime(float64(result) / 100)
+}
+
+// describe tells what the challenge is about in a line.
+func (c *Challenge) describe() string {
+	var s string
+	switch {
+	case c.Kind == ChallengeDistance:
+		s = fmt.Sprintf("FLY PAST %sm", formatIntComma(c.Result))
+	case c.Result == 0:
+		s = fmt.Sprintf("FINISH %sm", formatIntComma(c.Target))
+	default:
+		s = fmt.Sprintf("%sm IN %s", formatIntComma(c.Target), formatChallengeTime(c.Result))
+	}
+	if n := len(c.Modifiers); n > 0 {
+		s += fmt.Sprintf(" WITH %d MODIFIERS", n)
+	}
+	return s
+}
+
+func launchIndex(l *Launch) int {
+	for i := range launches {
+		if &launches[i] == l {
+			return i
+		}
+	}
+	return 0
+}
+
+// runChallenge returns the run just over as a challenge, or nil if it can't
+// be played again the same: a practice or a run with cheats warps, the
+// party mode has turns of its own and the new game+ is harder than a code
+// tells. Nor can the gaps of the adaptive difficulty, the upgrades or the
+// rhythm flaps, which are of the player's own and not in the code.
+func (g *Game) runChallenge() *Challenge {
+	if g.stats.practice || g.cheats.Active() || g.party.active || g.loop > 0 {
+		return nil
+	}
+	if g.stats.gapScale != 1 || g.stats.upgraded || g.stats.rhythm {
+		return nil
+	}
+	c := &Challenge{
+		Seed:      g.runSeed,
+		Launch:    launchIndex(g.launch),
+		Modifiers: g.activeModifiers(),
+		Result:    int(g.birdman.x) / 10,
+	}
+	if g.speedrunTarget > 0 {
+		c.Kind, c.Target = ChallengeSpeedrun, g.speedrunTarget
+		if g.racing {
+			c.Kind = ChallengeRace
+		}
+		c.Result = 0
+		if g.stats.finished {
+			c.Result = int(math.Round(g.stats.finishTime * 100))
+		}
+	}
+	return c
+}
+
+// challengeComparison compares the run just over with the result of the
+// challenge.
+func (g *Game) challengeComparison() string {
+	c := g.challenge
+	if c.Kind == ChallengeDistance {
+		d := int(g.birdman.x)/10 - c.Result
+		switch {
+		case d > 0:
+			return fmt.Sprintf("CHALLENGE BEATEN BY %sm!", formatIntComma(d))
+		case d == 0:
+			return "CHALLENGE TIED!"
+		default:
+			return fmt.Sprintf("%sm SHORT OF THE CHALLENGE", formatIntComma(-d))
+		}
+	}
+	if !g.stats.finished {
+		return "CHALLENGE NOT FINISHED"
+	}
+	d := int(math.Round(g.stats.finishTime*100)) - c.Result
+	switch {
+	case c.Result == 0 || d < 0:
+		return "CHALLENGE BEATEN!"
+	case d == 0:
+		return "CHALLENGE TIED!"
+	default:
+		return fmt.Sprintf("CHALLENGE LOST BY %.2fs", float64(d)/100)
+	}
+}
+
+// nextRunSeed seeds the random source for a run, from the challenge if one
+// is played, so that the run can be made a challenge of.
+func (g *Game) nextRunSeed() {
+	g.runSeed = uint32(g.rand.Int63()) & challengeSeedMask
+	if g.challenge != nil {
+		g.runSeed = g.challenge.Seed
+	}
+	g.rand.Seed(int64(g.runSeed))
+}
+
+// startChallenge plays the challenge in its mode with its modifiers, which
+// are kept on for the runs after.
+func (g *Game) startChallenge(c *Challenge) {
+	g.challenge = c
+	g.daily = ""
+	g.practice = false
+	g.party.active = false
+	g.loop = 0
+	g.racing = c.Kind == ChallengeRace
+	g.speedrunTarget = c.Target
+	if c.Kind == ChallengeDistance {
+		g.speedrunTarget = 0
+	}
+	g.modifiers = map[string]bool{}
+	for _, id := range c.Modifiers {
+		g.modifiers[id] = true
+	}
+	g.initialize()
+	g.startGame()
+}
+
+// enterChallenge takes a code typed in or opened, and reports whether it
+// was one.
+func (g *Game) enterChallenge(code string) bool {
+	c, err := DecodeChallenge(code)
+	if err != nil {
+		g.challengeStatus = "NOT A CHALLENGE CODE"
+		return false
+	}
+	g.enteredChallenge = c
+	g.challengeStatus = c.describe()
+	return true
+}
+
+func (g *Game) newChallengeMenu() *Menu {
+	return &Menu{
+		title: "CHALLENGE",
+		y:     titleMenuY,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label: func() string { return "PLAY " + formatChallengeCode(g.enteredChallenge.Encode()) },
+				action: func() {
+					g.startChallenge(g.enteredChallenge)
+				},
+				visible: func() bool { return g.enteredChallenge != nil },
+			},
+			{
+				label: func() string { return "ENTER A CODE" },
+				action: func() {
+					g.challengeEntry.Start("", challengeCodeLength+3)
+				},
+			},
+			{
+				label:  func() string { return "BACK" },
+				action: func() { g.mode = ModeTitle },
+			},
+		},
+	}
+}
+
+func (g *Game) updateChallengeScreen() {
+	e := &g.challengeEntry
+	if !e.active {
+		g.challengeMenu.Update(g.input)
+		return
+	}
+	if e.Update() && g.enterChallenge(e.text) {
+		e.active = false
+	}
+}
+
+// drawChallengeScreen draws the menu with the challenge entered, and the
+// code of the last run to share.
+func (g *Game) drawChallengeScreen(screen *ebiten.Image) {
+	drawLine := func(s string, y int) {
+		text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, y, color.White)
+	}
+	if g.challengeEntry.active {
+		g.challengeEntry.Draw(screen, "CODE: ", titleMenuY)
+	} else {
+		g.challengeMenu.Draw(screen)
+	}
+	y := challengeInfoY
+	if g.challengeStatus != "" {
+		drawLine(g.challengeStatus, y)
+	}
+	if g.lastChallenge == nil {
+		return
+	}
+	code := g.lastChallenge.Encode()
+	drawLine("YOUR LAST RUN: "+g.lastChallenge.describe(), y+smallFontSize*4)
+	drawLine(formatChallengeCode(code), y+smallFontSize*6)
+	if url := challengeURL(code); url != "" && len(url)*smallFontSize <= screenWidth {
+		drawLine(url, y+smallFontSize*8)
+	}
+}
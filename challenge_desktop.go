@@ -0,0 +1,15 @@
+//go:build !js
+// +build !js
+
+package main
+
+// challengeFromURL returns nothing on desktop, which takes the code with the
+// -challenge flag instead.
+func challengeFromURL() string {
+	return ""
+}
+
+// challengeURL returns nothing on desktop, where there is no page to link.
+func challengeURL(code string) string {
+	return ""
+}
@@ -0,0 +1,35 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"net/url"
+	"strings"
+	"syscall/js"
+)
+
+const challengeURLParam = "challenge"
+
+// challengeFromURL returns the challenge code the page was opened with.
+func challengeFromURL() string {
+	search := js.Global().Get("location").Get("search")
+	if search.Type() != js.TypeString {
+		return ""
+	}
+	q, err := url.ParseQuery(strings.TrimPrefix(search.String(), "?"))
+	if err != nil {
+		return ""
+	}
+	return q.Get(challengeURLParam)
+}
+
+// challengeURL returns the link to open the page with the challenge.
+func challengeURL(code string) string {
+	loc := js.Global().Get("location")
+	origin, path := loc.Get("origin"), loc.Get("pathname")
+	if origin.Type() != js.TypeString || path.Type() != js.TypeString {
+		return ""
+	}
+	return origin.String() + path.String() + "?" + challengeURLParam + "=" + code
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// in meters
+	cheatStartDistanceStep = 500
+	cheatMaxStartDistance  = 9500
+	cheatSpawnRateStep     = 0.25
+	cheatMinSpawnRate      = 0.25
+	cheatMaxSpawnRate      = 4
+	// pixels per second the free camera moves
+	freeCameraSpeed = 600
+)
+
+// konamiCode is typed on the title screen to unlock the cheat menu.
+var konamiCode = []ebiten.Key{
+	ebiten.KeyUp, ebiten.KeyUp, ebiten.KeyDown, ebiten.KeyDown,
+	ebiten.KeyLeft, ebiten.KeyRight, ebiten.KeyLeft, ebiten.KeyRight,
+	ebiten.KeyB, ebiten.KeyA,
+}
+
+// KeySequence tells when the keys of a sequence were just pressed in order,
+// with no other key in between.
+type KeySequence struct {
+	keys []ebiten.Key
+	// the latest keys pressed, as many as the sequence has
+	recent []ebiten.Key
+}
+
+// Update takes the keys just pressed this frame and reports whether they
+// completed the sequence.
+func (s *KeySequence) Update(pressed []ebiten.Key) bool {
+	for _, k := range pressed {
+		s.recent = append(s.recent, k)
+		if n := len(s.recent) - len(s.keys); n > 0 {
+			s.recent = append(s.recent[:0], s.recent[n:]...)
+		}
+		if s.matches() {
+			s.recent = s.recent[:0]
+			return true
+		}
+	}
+	return false
+}
+
+func (s *KeySequence) matches() bool {
+	if len(s.recent) != len(s.keys) {
+		return false
+	}
+	for i, k := range s.keys {
+		if s.recent[i] != k {
+			return false
+		}
+	}
+	return true
+}
+
+func justPressedKeys() []ebiten.Key {
+	var keys []ebiten.Key
+	for _, k := range inpututil.PressedKeys() {
+		if inpututil.IsKeyJustPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Cheats change the rules for testing. Runs with any of them but the free
+// camera aren't recorded.
+type Cheats struct {
+	Invincible bool
+	// in meters
+	StartDistance int
+	// multiplies how often single birds and other hazards appear
+	SpawnRate float64
+	// the camera stops following the birdman and moves with IJKL
+	FreeCamera bool
+}
+
+func DefaultCheats() Cheats {
+	return Cheats{SpawnRate: 1}
+}
+
+func (c *Cheats) Active() bool {
+	return c.Invincible || c.StartDistance > 0 || c.SpawnRate != 1
+}
+
+// updateCheatCode opens the cheat menu once the code is typed.
+func (g *Game) updateCheatCode() {
+	if g.cheatCode.keys == nil {
+		g.cheatCode.keys = konamiCode
+	}
+	if g.cheatCode.Update(justPressedKeys()) {
+		g.logEvent(CheatsUnlockedEvent{})
+		g.cheatsUnlocked = true
+		g.mode = ModeCheats
+	}
+}
+
+func (g *Game) newCheatMenu() *Menu {
+	c := &g.cheats
+	adjustStart := func(delta int) {
+		c.StartDistance += delta * cheatStartDistanceStep
+		if c.StartDistance < 0 {
+			c.StartDistance = cheatMaxStartDistance
+		} else if c.StartDistance > cheatMaxStartDistance {
+			c.StartDistance = 0
+		}
+	}
+	adjustSpawnRate := func(delta int) {
+		c.SpawnRate += float64(delta) * cheatSpawnRateStep
+		if c.SpawnRate < cheatMinSpawnRate {
+			c.SpawnRate = cheatMaxSpawnRate
+		} else if c.SpawnRate > cheatMaxSpawnRate {
+			c.SpawnRate = cheatMinSpawnRate
+		}
+	}
+	return &Menu{
+		title: "CHEATS",
+		y:     160,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label:  func() string { return "INVINCIBLE: " + onOff(c.Invincible) },
+				action: func() { c.Invincible = !c.Invincible },
+			},
+			{
+				label:  func() string { return fmt.Sprintf("START AT: %sm", formatIntComma(c.StartDistance)) },
+				action: func() { adjustStart(1) },
+				adjust: adjustStart,
+			},
+			{
+				label:  func() string { return fmt.Sprintf("SPAWN RATE: %.2fX", c.SpawnRate) },
+				action: func() { adjustSpawnRate(1) },
+				adjust: adjustSpawnRate,
+			},
+			{
+				label:  func() string { return "FREE CAMERA: " + onOff(c.FreeCamera) },
+				action: func() { c.FreeCamera = !c.FreeCamera },
+			},
+			{
+				label:  func() string { return "RESET" },
+				action: func() { *c = DefaultCheats() },
+			},
+			{
+				label:  func() string { return "BACK" },
+				action: func() { g.mode = ModeTitle },
+			},
+		},
+	}
+}
+
+// birdSpawnInterval is the distance between single birds, shortened by the
+// spawn rate of the difficulty curve and the cheat and scaled by the adaptive
+// difficulty.
+func (g *Game) birdSpawnInterval() float64 {
+	interval := g.config.BirdSpawnInterval * g.gapScale() / g.config.DifficultyAt(g.birdman.x).SpawnRate
+	if g.cheats.SpawnRate > 0 {
+		interval /= g.cheats.SpawnRate
+	}
+	return interval
+}
+
+// updateFreeCamera moves the camera with IJKL, and keeps doing so while the
+// game is paused, to look around.
+func (g *Game) updateFreeCamera() {
+	if !g.cheats.FreeCamera || g.mode != ModeGame {
+		return
+	}
+	d := freeCameraSpeed / float64(ebiten.MaxTPS())
+	if ebiten.IsKeyPressed(ebiten.KeyJ) {
+		g.camera.x -= d
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyL) {
+		g.camera.x += d
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyI) {
+		g.camera.y -= d
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyK) {
+		g.camera.y += d
+	}
+	// Not too far below the sea
+	g.camera.y = math.Min(g.camera.y, screenHeight)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// in meters
+	checkpointDistance = 500
+	checkpointShowTime = 3
+	flagPoleHeight     = 70
+	flagWidth          = 30
+	flagHeight         = 18
+	flagWavePeriod     = 0.8
+	// the latest sectors listed on the game over screen, left of the texts
+	maxSectorRows = 6
+	sectorTableX  = 12
+	sectorTableY  = 150
+)
+
+var (
+	flagPoleColor   = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	flagAheadColor  = color.RGBA{0xe0, 0x40, 0x40, 0xff}
+	flagPassedColor = color.RGBA{0x40, 0xc0, 0x60, 0xff}
+)
+
+// Checkpoints time the sectors of the course between the checkpoint flags,
+// to compare each sector with the best time of it over all runs.
+type Checkpoints struct {
+	// the index of the first sector timed, after those skipped by warping
+	first int
+	// seconds the birdman took for every sector since the first
+	sectors []float64
+	// the run time at the latest checkpoint
+	start   float64
+	shownAt float64
+	// the best sector times as of the start of the run
+	best []float64
+}
+
+func (c *Checkpoints) Reset(best []float64) {
+	c.best = append(c.best[:0], best...)
+	c.first = 0
+	c.sectors = c.sectors[:0]
+	c.start = 0
+	c.shownAt = -checkpointShowTime
+}
+
+// next is the index of the sector the birdman is in.
+func (c *Checkpoints) next() int {
+	return c.first + len(c.sectors)
+}
+
+// updateCheckpoints takes the sector time whenever the birdman passes the
+// next checkpoint.
+func (g *Game) updateCheckpoints() {
+	c := &g.checkpoints
+	n := int(g.birdman.x) / 10 / checkpointDistance
+	if n <= c.next() {
+		return
+	}
+	if n > c.next()+1 {
+		// Warped past them, so the sector since is the first one to time
+		c.first = n
+		c.sectors = c.sectors[:0]
+		c.start = g.splits.time
+		return
+	}
+	c.sectors = append(c.sectors, g.splits.time-c.start)
+	c.start = g.splits.time
+	c.shownAt = g.splits.time
+}
+
+// updateBestSectors keeps the times of the run's sectors which beat the best
+// ones.
+func (g *Game) updateBestSectors() {
+	c := &g.checkpoints
+	best := g.records.BestSectors
+	for i, t := range c.sectors {
+		switch j := c.first + i; {
+		case j == len(best):
+			best = append(best, t)
+		case j < len(best):
+			best[j] = math.Min(best[j], t)
+		}
+	}
+	g.records.BestSectors = best
+}
+
+// sectorDiff returns how many seconds the ith sector timed was behind the
+// best time of it, negative if ahead. There is none for a new sector.
+func (g *Game) sectorDiff(i int) (float64, bool) {
+	c := &g.checkpoints
+	if j := c.first + i; j < len(c.best) && i < len(c.sectors) {
+		return c.sectors[i] - c.best[j], true
+	}
+	return 0, false
+}
+
+// sectorDiffText returns the comparison of the ith sector timed with the
+// best time of it, with its color.
+func (g *Game) sectorDiffText(i int) (string, color.Color) {
+	diff, ok := g.sectorDiff(i)
+	switch {
+	case !ok:
+		return "", color.White
+	case diff <= 0:
+		return fmt.Sprintf("-%.1f", -diff), splitAheadColor
+	default:
+		return fmt.Sprintf("+%.1f", diff), splitBehindColor
+	}
+}
+
+// drawSectorSplit shows the latest sector time for a while after its
+// checkpoint.
+func (g *Game) drawSectorSplit(screen *ebiten.Image, y int) {
+	c := &g.checkpoints
+	if len(c.sectors) == 0 || g.splits.time-c.shownAt >= checkpointShowTime {
+		return
+	}
+	i := len(c.sectors) - 1
+	diff, clr := g.sectorDiffText(i)
+	s := fmt.Sprintf("SECTOR %d %.1fs", c.first+i+1, c.sectors[i])
+	if diff != "" {
+		s += " " + diff + "s"
+	}
+	text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, y, clr)
+}
+
+// drawSectorTable lists the latest sectors of the run, narrow enough to fit
+// beside the texts of the game over screen.
+func (g *Game) drawSectorTable(screen *ebiten.Image) {
+	c := &g.checkpoints
+	if len(c.sectors) == 0 {
+		return
+	}
+	text.Draw(screen, "SECTORS", smallFont, sectorTableX, sectorTableY, color.White)
+	y := sectorTableY + smallFontSize*2
+	from := int(math.Max(0, float64(len(c.sectors)-maxSectorRows)))
+	for i := from; i < len(c.sectors); i++ {
+		diff, clr := g.sectorDiffText(i)
+		s := fmt.Sprintf("%d %.1f %s", c.first+i+1, c.sectors[i], diff)
+		text.Draw(screen, s, smallFont, sectorTableX, y, clr)
+		y += smallFontSize * 2
+	}
+}
+
+// visibleCheckpoints calls f with the index, the x and the top of the pole
+// of every checkpoint flag in view.
+func (g *Game) visibleCheckpoints(f func(i int, x, top float64)) {
+	if g.inSpace() || g.backdrop == nil {
+		return
+	}
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	interval := float64(checkpointDistance * 10)
+	seaY := float64(screenHeight-g.backdrop.seaHeight) - viewY
+	for i := int(math.Max(1, math.Ceil((viewX-flagWidth)/interval))); float64(i)*interval < viewX+screenWidth; i++ {
+		f(i, float64(i)*interval-viewX, seaY-flagPoleHeight)
+	}
+}
+
+// drawCheckpointFlags draws the flags standing in the sea at every
+// checkpoint, which turn green once passed.
+func (g *Game) drawCheckpointFlags(screen *ebiten.Image) {
+	g.visibleCheckpoints(func(i int, x, top float64) {
+		ebitenutil.DrawRect(screen, x-1, top, 3, flagPoleHeight+8, flagPoleColor)
+		clr := flagAheadColor
+		if i <= g.checkpoints.next() {
+			clr = flagPassedColor
+		}
+		// Flutters in the wind
+		w := flagWidth * (0.85 + 0.15*math.Sin(2*math.Pi*g.tricks.time/flagWavePeriod+float64(i)))
+		ebitenutil.DrawRect(screen, x+2, top, w, flagHeight, clr)
+	})
+}
+
+// drawCheckpointLabels draws the distances over the flags, apart from them
+// so as to stay readable in the mirror mode.
+func (g *Game) drawCheckpointLabels(screen *ebiten.Image) {
+	g.visibleCheckpoints(func(i int, x, top float64) {
+		label := fmt.Sprintf("%sm", formatIntComma(i*checkpointDistance))
+		text.Draw(screen, label, smallFont, int(g.mirrorX(x))-len(label)*smallFontSize/2, int(top)-6, color.White)
+	})
+}
@@ -0,0 +1,144 @@
+// Command packassets packs the resources of the game into a smaller set for
+// the lite build:
+//
+//	go run ./cmd/packassets -src resources -dst resources_lite
+//
+// PNGs are re-encoded with the best compression, sounds are converted to mono
+// OGG with ffmpeg, which is required, and the files gzip makes smaller are
+// stored gzipped with ".gz" appended. The game reads either form of a file.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gzip has to save this much to be worth decompressing at load
+const minGzipSaving = 0.1
+
+// The files read as they are, so never packed
+var plainFiles = map[string]bool{
+	"config.json": true,
+	"secret":      true,
+}
+
+func main() {
+	src := flag.String("src", "resources", "directory of the resources")
+	dst := flag.String("dst", "resources_lite", "directory to write the packed resources to")
+	bitrate := flag.String("bitrate", "48k", "bitrate of the converted sounds")
+	flag.Parse()
+
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		log.Fatalf("ffmpeg is required to convert the sounds: %v", err)
+	}
+
+	if err := os.RemoveAll(*dst); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(*dst, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var before, after int64
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		name := f.Name()
+		data, err := ioutil.ReadFile(filepath.Join(*src, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		before += int64(len(data))
+
+		if !plainFiles[name] {
+			switch strings.ToLower(filepath.Ext(name)) {
+			case ".png":
+				data, err = recompressPNG(data)
+			case ".mp3", ".wav":
+				data, err = convertToOGG(ffmpeg, data, *bitrate)
+				name = strings.TrimSuffix(name, filepath.Ext(name)) + ".ogg"
+			}
+			if err != nil {
+				log.Fatalf("%s: %v", f.Name(), err)
+			}
+			if z, err := gzipped(data); err != nil {
+				log.Fatal(err)
+			} else if float64(len(z)) < float64(len(data))*(1-minGzipSaving) {
+				data = z
+				name += ".gz"
+			}
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(*dst, name), data, 0644); err != nil {
+			log.Fatal(err)
+		}
+		after += int64(len(data))
+		fmt.Printf("%-40s %8d -> %8d\n", name, f.Size(), len(data))
+	}
+	fmt.Printf("%-40s %8d -> %8d\n", "total", before, after)
+	if before > 0 {
+		fmt.Printf("saved %dKB (%.0f%%) of the resources\n", (before-after)/1000, float64(before-after)/float64(before)*100)
+	}
+}
+
+// recompressPNG keeps the original unless re-encoding makes it smaller.
+func recompressPNG(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	e := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := e.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	if b.Len() >= len(data) {
+		return data, nil
+	}
+	return b.Bytes(), nil
+}
+
+// convertToOGG converts a sound to mono OGG Vorbis. The game plays the sound
+// effects on both channels anyway.
+func convertToOGG(ffmpeg string, data []byte, bitrate string) ([]byte, error) {
+	cmd := exec.Command(ffmpeg, "-loglevel", "error", "-i", "pipe:0", "-ac", "1", "-c:a", "libvorbis", "-b:a", bitrate, "-f", "ogg", "pipe:1")
+	cmd.Stdin = bytes.NewReader(data)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %v: %s", err, stderr.String())
+	}
+	return out.Bytes(), nil
+}
+
+func gzipped(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
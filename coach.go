@@ -0,0 +1,138 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// the runs the hints are given for
+	coachHintRuns = 5
+	// seconds a bubble is shown for, and of quiet after it
+	coachBubbleTime = 2.5
+	coachQuietTime  = 3
+	coachX          = 24
+	coachY          = screenHeight - 110
+	coachBubbleX    = coachX + 30
+)
+
+var (
+	coachShirtColor = color.RGBA{0xe0, 0x50, 0x40, 0xff}
+	coachSkinColor  = color.RGBA{0xf0, 0xc0, 0x90, 0xff}
+	coachCapColor   = color.RGBA{0x30, 0x60, 0xb0, 0xff}
+	bubbleColor     = color.RGBA{0xff, 0xff, 0xff, 0xe0}
+)
+
+// CoachTrigger is a line of the coach and when it is said, at most once a
+// run.
+type CoachTrigger struct {
+	ID   string
+	Text string
+	// a hint for the first runs rather than a joke
+	hint bool
+	// in meters, said once past it, or by when if 0
+	distance int
+	when     func(g *Game) bool
+}
+
+var coachTriggers = []CoachTrigger{
+	{ID: "too_high", Text: "DON'T FLY TOO HIGH!", hint: true, when: (*Game).isInAltitudeZone},
+	{ID: "birds_ahead", Text: "BIRDS AHEAD!", hint: true, when: func(g *Game) bool {
+		return g.birdman.state == StateFlying && g.incomingThreats() > 0
+	}},
+	{ID: "too_low", Text: "FLAP OR YOU'LL GET WET!", hint: true, when: func(g *Game) bool {
+		return g.birdman.state == StateFlying && g.birdman.y > screenHeight*3/4
+	}},
+	{ID: "damaged", Text: "TAP TO SHAKE IT OFF!", hint: true, when: func(g *Game) bool {
+		return g.birdman.state == StateDamaged
+	}},
+	{ID: "weak_flaps", Text: "FEATHERS PUT THE FLAP BACK!", hint: true, when: func(g *Game) bool {
+		return g.birdman.state == StateFlying && g.flapPower() < g.config.FullFlapPower()*0.7
+	}},
+	{ID: "joke_1000", Text: "I'D HAVE TURNED BACK BY NOW", distance: 1000},
+	{ID: "joke_2000", Text: "MY KNEES HURT JUST WATCHING", distance: 2000},
+	{ID: "joke_3000", Text: "THE GULLS WANT AN AUTOGRAPH", distance: 3000},
+	{ID: "joke_5000", Text: "DID YOU PACK A LUNCH?", distance: 5000},
+	{ID: "joke_8000", Text: "I NEED BINOCULARS NOW!", distance: 8000},
+}
+
+// Coach calls out to the birdman from the corner of the screen, with hints
+// on the first runs and jokes at the milestones.
+type Coach struct {
+	said map[string]bool
+	text string
+	// of the bubble shown, then of the quiet after it
+	time  float64
+	quiet float64
+}
+
+func (c *Coach) Reset() {
+	c.said = map[string]bool{}
+	c.text = ""
+	c.time = 0
+	c.quiet = 0
+}
+
+func (c *Coach) Say(s string) {
+	c.text = s
+	c.time = coachBubbleTime
+}
+
+// updateCoach says the first line of the table due, unless the coach is
+// turned off in the settings.
+func (g *Game) updateCoach() {
+	c := &g.coach
+	if !g.settings.Hints {
+		c.text = ""
+		return
+	}
+	if c.text != "" {
+		c.time -= simulationStep
+		if c.time <= 0 {
+			c.text = ""
+			c.quiet = coachQuietTime
+		}
+		return
+	}
+	if c.quiet > 0 {
+		c.quiet -= simulationStep
+		return
+	}
+	d := int(g.birdman.x) / 10
+	hints := g.records.Runs < coachHintRuns
+	for i := range coachTriggers {
+		t := &coachTriggers[i]
+		if c.said[t.ID] || t.hint && !hints {
+			continue
+		}
+		due := d >= t.distance
+		if t.when != nil {
+			due = due && t.when(g)
+		}
+		if due {
+			c.said[t.ID] = true
+			c.Say(t.Text)
+			return
+		}
+	}
+}
+
+// drawCoach draws the coach in the corner with the bubble of the line said.
+func (g *Game) drawCoach(screen *ebiten.Image) {
+	c := &g.coach
+	if c.text == "" {
+		return
+	}
+	ebitenutil.DrawRect(screen, coachX, coachY, 14, 18, coachShirtColor)
+	ebitenutil.DrawRect(screen, coachX+2, coachY-10, 10, 10, coachSkinColor)
+	ebitenutil.DrawRect(screen, coachX, coachY-13, 16, 4, coachCapColor)
+
+	w := float64(len(c.text)*smallFontSize + smallFontSize)
+	ebitenutil.DrawRect(screen, coachBubbleX, coachY-smallFontSize*2, w, smallFontSize*2, bubbleColor)
+	// The tail points at the coach
+	ebitenutil.DrawRect(screen, coachBubbleX-6, coachY-smallFontSize, 6, 4, bubbleColor)
+	text.Draw(screen, c.text, smallFont, coachBubbleX+smallFontSize/2, coachY-smallFontSize/2, color.Black)
+}
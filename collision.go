@@ -0,0 +1,277 @@
+package main
+
+import (
+	"math"
+)
+
+type ShapeKind int
+
+const (
+	ShapeCircle ShapeKind = iota
+	ShapeAABB
+	ShapeCapsule
+)
+
+// Shape is a collision primitive positioned relative to its entity's center.
+//
+//   - Circle: center (X, Y), radius R
+//   - AABB: top-left (X, Y), size (W, H)
+//   - Capsule: segment (X, Y)-(X2, Y2) swept by radius R
+type Shape struct {
+	Kind   ShapeKind
+	X, Y   float64
+	X2, Y2 float64
+	W, H   float64
+	R      float64
+}
+
+func Circle(x, y, r float64) Shape {
+	return Shape{Kind: ShapeCircle, X: x, Y: y, R: r}
+}
+
+func AABB(x, y, w, h float64) Shape {
+	return Shape{Kind: ShapeAABB, X: x, Y: y, W: w, H: h}
+}
+
+func Capsule(x1, y1, x2, y2, r float64) Shape {
+	return Shape{Kind: ShapeCapsule, X: x1, Y: y1, X2: x2, Y2: y2, R: r}
+}
+
+// Hitbox is the union of the shapes of one sprite frame.
+type Hitbox []Shape
+
+// SpriteHitboxes holds a hitbox for every frame of a sprite sheet.
+type SpriteHitboxes []Hitbox
+
+func (s SpriteHitboxes) Frame(index int) Hitbox {
+	if index < 0 || index >= len(s) {
+		return nil
+	}
+	return s[index]
+}
+
+var (
+	birdmanHitboxes = SpriteHitboxes{
+		// wings folded
+		{Capsule(-18, -4, 18, 4, 12), Circle(22, -14, 9)},
+		// wings spread
+		{Capsule(-18, -4, 18, 4, 12), Circle(22, -14, 9), AABB(-40, -22, 80, 10)},
+	}
+	birdHitboxes = SpriteHitboxes{
+		// wings up
+		{Capsule(-22, 4, 18, 0, 11), AABB(-14, -28, 26, 24)},
+		// wings down
+		{Capsule(-22, 4, 18, 0, 11), AABB(-14, 8, 26, 22)},
+	}
+	fishHitbox   = Hitbox{Capsule(-12, 0, 12, 0, 8)}
+	basketHitbox = Hitbox{AABB(-12, balloonBasketY-8, 24, 18)}
+	pickupHitbox = Hitbox{Circle(0, 0, 16)}
+	// fuselage, wing and tail fin
+	airplaneHitbox = Hitbox{Capsule(-54, 0, 54, 0, 6), AABB(-10, 0, 20, 20), AABB(44, -20, 16, 20)}
+)
+
+func (s Shape) translated(x, y float64) Shape {
+	s.X += x
+	s.Y += y
+	s.X2 += x
+	s.Y2 += y
+	return s
+}
+
+func (s Shape) bounds() (minX, minY, maxX, maxY float64) {
+	switch s.Kind {
+	case ShapeCircle:
+		return s.X - s.R, s.Y - s.R, s.X + s.R, s.Y + s.R
+	case ShapeAABB:
+		return s.X, s.Y, s.X + s.W, s.Y + s.H
+	default:
+		return math.Min(s.X, s.X2) - s.R, math.Min(s.Y, s.Y2) - s.R, math.Max(s.X, s.X2) + s.R, math.Max(s.Y, s.Y2) + s.R
+	}
+}
+
+// Scaled returns the hitbox grown or shrunk about the entity's center.
+func (h Hitbox) Scaled(scale float64) Hitbox {
+	scaled := make(Hitbox, len(h))
+	for i, s := range h {
+		s.X, s.Y, s.X2, s.Y2 = s.X*scale, s.Y*scale, s.X2*scale, s.Y2*scale
+		s.W, s.H, s.R = s.W*scale, s.H*scale, s.R*scale
+		scaled[i] = s
+	}
+	return scaled
+}
+
+// Bounds returns the bounding box of the hitbox placed at (x, y).
+func (h Hitbox) Bounds(x, y float64) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, s := range h {
+		x0, y0, x1, y1 := s.translated(x, y).bounds()
+		minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+		maxX, maxY = math.Max(maxX, x1), math.Max(maxY, y1)
+	}
+	return
+}
+
+// Collides reports whether hitbox a at (ax, ay) overlaps hitbox b at (bx, by).
+func Collides(a Hitbox, ax, ay float64, b Hitbox, bx, by float64) bool {
+	for _, sa := range a {
+		for _, sb := range b {
+			if intersects(sa.translated(ax, ay), sb.translated(bx, by)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func intersects(a, b Shape) bool {
+	if a.Kind > b.Kind {
+		a, b = b, a
+	}
+	switch {
+	case a.Kind == ShapeCircle && b.Kind == ShapeCircle:
+		return distSq(a.X, a.Y, b.X, b.Y) < sq(a.R+b.R)
+	case a.Kind == ShapeCircle && b.Kind == ShapeAABB:
+		return pointAABBDistSq(a.X, a.Y, b) < sq(a.R)
+	case a.Kind == ShapeCircle && b.Kind == ShapeCapsule:
+		return pointSegmentDistSq(a.X, a.Y, b.X, b.Y, b.X2, b.Y2) < sq(a.R+b.R)
+	case a.Kind == ShapeAABB && b.Kind == ShapeAABB:
+		return a.X < b.X+b.W && b.X < a.X+a.W && a.Y < b.Y+b.H && b.Y < a.Y+a.H
+	case a.Kind == ShapeAABB && b.Kind == ShapeCapsule:
+		return segmentAABBDistSq(b.X, b.Y, b.X2, b.Y2, a) < sq(b.R)
+	default:
+		return segmentSegmentDistSq(a.X, a.Y, a.X2, a.Y2, b.X, b.Y, b.X2, b.Y2) < sq(a.R+b.R)
+	}
+}
+
+func sq(v float64) float64 {
+	return v * v
+}
+
+func distSq(x1, y1, x2, y2 float64) float64 {
+	return sq(x1-x2) + sq(y1-y2)
+}
+
+func pointAABBDistSq(x, y float64, b Shape) float64 {
+	cx := math.Max(b.X, math.Min(x, b.X+b.W))
+	cy := math.Max(b.Y, math.Min(y, b.Y+b.H))
+	return distSq(x, y, cx, cy)
+}
+
+func closestOnSegment(px, py, x1, y1, x2, y2 float64) (float64, float64) {
+	dx, dy := x2-x1, y2-y1
+	l := dx*dx + dy*dy
+	if l == 0 {
+		return x1, y1
+	}
+	t := math.Max(0, math.Min(1, ((px-x1)*dx+(py-y1)*dy)/l))
+	return x1 + t*dx, y1 + t*dy
+}
+
+func pointSegmentDistSq(px, py, x1, y1, x2, y2 float64) float64 {
+	cx, cy := closestOnSegment(px, py, x1, y1, x2, y2)
+	return distSq(px, py, cx, cy)
+}
+
+func segmentsCross(x1, y1, x2, y2, x3, y3, x4, y4 float64) bool {
+	cross := func(ax, ay, bx, by float64) float64 { return ax*by - ay*bx }
+	d1 := cross(x4-x3, y4-y3, x1-x3, y1-y3)
+	d2 := cross(x4-x3, y4-y3, x2-x3, y2-y3)
+	d3 := cross(x2-x1, y2-y1, x3-x1, y3-y1)
+	d4 := cross(x2-x1, y2-y1, x4-x1, y4-y1)
+	return ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0))
+}
+
+func segmentSegmentDistSq(x1, y1, x2, y2, x3, y3, x4, y4 float64) float64 {
+	if segmentsCross(x1, y1, x2, y2, x3, y3, x4, y4) {
+		return 0
+	}
+	return math.Min(
+		math.Min(pointSegmentDistSq(x1, y1, x3, y3, x4, y4), pointSegmentDistSq(x2, y2, x3, y3, x4, y4)),
+		math.Min(pointSegmentDistSq(x3, y3, x1, y1, x2, y2), pointSegmentDistSq(x4, y4, x1, y1, x2, y2)),
+	)
+}
+
+func segmentAABBDistSq(x1, y1, x2, y2 float64, b Shape) float64 {
+	if b.X <= x1 && x1 <= b.X+b.W && b.Y <= y1 && y1 <= b.Y+b.H {
+		return 0
+	}
+	edges := [4][4]float64{
+		{b.X, b.Y, b.X + b.W, b.Y},
+		{b.X + b.W, b.Y, b.X + b.W, b.Y + b.H},
+		{b.X + b.W, b.Y + b.H, b.X, b.Y + b.H},
+		{b.X, b.Y + b.H, b.X, b.Y},
+	}
+	d := math.Inf(1)
+	for _, e := range edges {
+		d = math.Min(d, segmentSegmentDistSq(x1, y1, x2, y2, e[0], e[1], e[2], e[3]))
+	}
+	return d
+}
+
+// SpatialGrid buckets entity bounds into fixed-size cells so that overlap
+// queries only look at nearby entities.
+type SpatialGrid struct {
+	cellSize float64
+	cells    map[[2]int][]int
+	visited  map[int]bool
+}
+
+func NewSpatialGrid(cellSize float64) *SpatialGrid {
+	return &SpatialGrid{
+		cellSize: cellSize,
+		cells:    make(map[[2]int][]int),
+		visited:  make(map[int]bool),
+	}
+}
+
+// spatialGridMaxCells bounds the number of remembered cells; the world
+// scrolls forever, so stale cells are dropped instead of being reused.
+const spatialGridMaxCells = 1024
+
+func (g *SpatialGrid) Clear() {
+	if len(g.cells) > spatialGridMaxCells {
+		g.cells = make(map[[2]int][]int)
+		return
+	}
+	for k, v := range g.cells {
+		g.cells[k] = v[:0]
+	}
+}
+
+func (g *SpatialGrid) cellRange(minX, minY, maxX, maxY float64) (int, int, int, int) {
+	return int(math.Floor(minX / g.cellSize)), int(math.Floor(minY / g.cellSize)),
+		int(math.Floor(maxX / g.cellSize)), int(math.Floor(maxY / g.cellSize))
+}
+
+func (g *SpatialGrid) Insert(id int, minX, minY, maxX, maxY float64) {
+	x0, y0, x1, y1 := g.cellRange(minX, minY, maxX, maxY)
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			k := [2]int{cx, cy}
+			g.cells[k] = append(g.cells[k], id)
+		}
+	}
+}
+
+// Query calls fn once for every id whose cells overlap the given bounds.
+// Iteration stops when fn returns false.
+func (g *SpatialGrid) Query(minX, minY, maxX, maxY float64, fn func(id int) bool) {
+	for k := range g.visited {
+		delete(g.visited, k)
+	}
+	x0, y0, x1, y1 := g.cellRange(minX, minY, maxX, maxY)
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			for _, id := range g.cells[[2]int{cx, cy}] {
+				if g.visited[id] {
+					continue
+				}
+				g.visited[id] = true
+				if !fn(id) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestIntersects(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{"circles overlapping", Circle(0, 0, 10), Circle(15, 0, 10), true},
+		{"circles apart", Circle(0, 0, 10), Circle(25, 0, 10), false},
+		{"circle in box", Circle(5, 5, 1), AABB(0, 0, 10, 10), true},
+		{"circle near box corner", Circle(-5, -5, 5), AABB(0, 0, 10, 10), false},
+		{"boxes overlapping", AABB(0, 0, 10, 10), AABB(5, 5, 10, 10), true},
+		{"boxes touching", AABB(0, 0, 10, 10), AABB(10, 0, 10, 10), false},
+		{"capsule and circle", Capsule(0, 0, 100, 0, 5), Circle(50, 8, 5), true},
+		{"capsule over box", Capsule(0, -20, 0, 20, 2), AABB(-10, -5, 20, 10), true},
+		{"capsules crossing", Capsule(-10, 0, 10, 0, 1), Capsule(0, -10, 0, 10, 1), true},
+		{"capsules parallel", Capsule(0, 0, 10, 0, 1), Capsule(0, 5, 10, 5, 1), false},
+	}
+	for _, tc := range cases {
+		if got := intersects(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: intersects = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := intersects(tc.b, tc.a); got != tc.want {
+			t.Errorf("%s (swapped): intersects = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCollidesBirdmanAndBird(t *testing.T) {
+	birdman := birdmanHitboxes.Frame(0)
+	bird := birdHitboxes.Frame(0)
+
+	if !Collides(birdman, 100, 100, bird, 110, 100) {
+		t.Error("overlapping birdman and bird don't collide")
+	}
+	if Collides(birdman, 100, 100, bird, 200, 100) {
+		t.Error("distant birdman and bird collide")
+	}
+}
+
+func TestSpatialGridQuery(t *testing.T) {
+	g := NewSpatialGrid(128)
+	g.Insert(0, 0, 0, 10, 10)
+	g.Insert(1, 300, 300, 310, 310)
+	g.Insert(2, 120, 0, 140, 10)
+
+	var found []int
+	seen := make(map[int]bool)
+	g.Query(0, 0, 130, 20, func(id int) bool {
+		if !seen[id] {
+			seen[id] = true
+			found = append(found, id)
+		}
+		return true
+	})
+	sort.Ints(found)
+	if len(found) != 2 || found[0] != 0 || found[1] != 2 {
+		t.Errorf("Query found %v, want [0 2]", found)
+	}
+
+	g.Clear()
+	g.Query(0, 0, 1000, 1000, func(id int) bool {
+		t.Errorf("Query after Clear found %d", id)
+		return true
+	})
+}
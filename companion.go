@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// in meters of the best distance
+	companionUnlockDistance = 2000
+	companionOffsetX        = -50
+	companionOffsetY        = -40
+	// how fast the companion catches up with its place, per second
+	companionStiffness = 5
+	companionBobPeriod = 1.2
+	companionBobHeight = 6
+	companionScale     = 0.6
+	// a bird closer than this ahead of the birdman may be blocked
+	companionBlockRange  = 140
+	companionBlockChance = 0.5
+	// pixels per second the companion flies off at after blocking
+	companionFlyAwaySpeed = 240
+)
+
+// Companion is a bird flying along with the birdman. It chirps when hazards
+// are about to come into view, and may throw itself at one incoming bird,
+// leaving for the rest of the run.
+type Companion struct {
+	x, y float64
+	gone bool
+	// the number of hazards that were about to come into view, to chirp at
+	// new ones
+	incoming  int
+	animation AnimationPlayer
+}
+
+func (g *Game) companionUnlocked() bool {
+	return g.records.BestDistance >= companionUnlockDistance
+}
+
+// resetCompanion puts the companion next to the birdman for a new run, when
+// it is unlocked and turned on.
+func (g *Game) resetCompanion() {
+	g.companion = nil
+	if !g.companionUnlocked() || !g.settings.Companion {
+		return
+	}
+	g.companion = &Companion{
+		x: g.birdman.x + companionOffsetX,
+		y: g.birdman.y + companionOffsetY,
+	}
+}
+
+func (g *Game) updateCompanion() {
+	c := g.companion
+	if c == nil {
+		return
+	}
+	c.animation.Play(birdFlyingAnimation)
+	c.animation.Update()
+	if c.gone {
+		c.x += companionFlyAwaySpeed * simulationStep
+		c.y -= companionFlyAwaySpeed * simulationStep
+		return
+	}
+
+	// Trail the birdman, easing towards the place behind him
+	b := g.birdman
+	bob := companionBobHeight * math.Sin(2*math.Pi*g.tricks.time/companionBobPeriod)
+	t := 1 - math.Exp(-companionStiffness*simulationStep)
+	c.x += (b.x + companionOffsetX - c.x) * t
+	c.y += (b.y + companionOffsetY + bob - c.y) * t
+
+	n := g.incomingThreats()
+	if n > c.incoming {
+		g.sfx.PlaySE(chirpAudioData)
+	}
+	c.incoming = n
+
+	if b.state != StateFlying {
+		return
+	}
+	for i := range g.birds {
+		bird := &g.birds[i]
+		if !bird.isHazard() || bird.companionChecked {
+			continue
+		}
+		dx, dy := bird.x-b.x, bird.y-b.y
+		if dx < 0 || dx > companionBlockRange || math.Abs(dy) > birdmanHeight {
+			continue
+		}
+		// Each bird gets one chance to be blocked
+		bird.companionChecked = true
+		if g.rand.Float64() < companionBlockChance {
+			g.strikeBird(i)
+			c.x, c.y = bird.x, bird.y
+			c.gone = true
+			g.popups.Spawn("BLOCKED!", bird.x, bird.y-birdHeight, popupPointsColor)
+			g.sfx.PlaySE(chirpAudioData)
+			return
+		}
+	}
+}
+
+func (g *Game) drawCompanion(screen *ebiten.Image) {
+	c := g.companion
+	if c == nil {
+		return
+	}
+	img := birdFrames[c.animation.Frame()]
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(-birdWidth/2, -birdHeight/2)
+	// The birds fly towards the birdman, the companion along with him
+	opt.GeoM.Scale(-companionScale, companionScale)
+	opt.GeoM.Translate(c.x-g.camera.ViewX(), c.y-g.camera.ViewY())
+	opt.ColorM.Scale(1, 0.85, 0.3, 1)
+	screen.DrawImage(img, opt)
+}
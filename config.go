@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"math"
+)
+
+const configName = "config.json"
+
+// Band is a horizontal slice of the sky with its own conditions and
+// hazards, from the top down.
+type Band struct {
+	Name string `json:"name"`
+	// Bottom is the y position down to which the band applies. 0 means no
+	// limit.
+	Bottom float64 `json:"bottom"`
+	// Headwind and Spray slow the birdman down while he flies in the band,
+	// in px/s².
+	Headwind float64      `json:"headwind"`
+	Spray    float64      `json:"spray"`
+	Hazards  []BandHazard `json:"hazards"`
+}
+
+// BandHazard is an entry of the spawn table: how likely Kind ("bird" or
+// "airplane") is to appear in the band compared to every other entry.
+type BandHazard struct {
+	Kind   string  `json:"kind"`
+	Weight float64 `json:"weight"`
+}
+
+// Biome is a segment of the course with its own look and hazards. The
+// course goes through the biomes in order, biome_length each, and back to
+// the first after the last.
+type Biome struct {
+	Name string `json:"name"`
+	// Art is the name of the background drawn for the biome, empty for none.
+	Art  string `json:"art"`
+	Rain bool   `json:"rain"`
+	// Palette is drawn over the sky and the sea, as a premultiplied RGBA.
+	Palette [4]uint8 `json:"palette"`
+	// HazardScale multiplies the weights of the spawn tables of the bands
+	// by the kind of the hazard, and FishScale the fish spawn rate.
+	HazardScale map[string]float64 `json:"hazard_scale"`
+	FishScale   float64            `json:"fish_scale"`
+}
+
+// DifficultyBreakpoint is a section of the difficulty curve, which the
+// course goes through in order. SpawnRate multiplies how often the single
+// birds and the flocks come, and BirdSpeedScale the bird speed.
+type DifficultyBreakpoint struct {
+	// Until is the x position up to which the section applies. 0 means no
+	// limit.
+	Until          float64 `json:"until"`
+	FlapPower      float64 `json:"flap_power"`
+	SpawnRate      float64 `json:"spawn_rate"`
+	BirdSpeedScale float64 `json:"bird_speed_scale"`
+}
+
+// GameConfig holds the balancing parameters of the game. Speeds are in
+// pixels per second and accelerations in pixels per second squared. Drag is
+// the fraction of the vertical velocity lost per second, and forward speed
+// recovery the fraction of the gap to the cruise speed (BirdmanSpeed) closed
+// per second. Knockback damping is the same for the knockback velocity,
+// which fades into a fall at the damaged fall speed, and damaged flap
+// recovery the seconds each flap cuts from the damaged duration.
+type GameConfig struct {
+	BirdmanSpeed          float64                `json:"birdman_speed"`
+	MinForwardSpeed       float64                `json:"min_forward_speed"`
+	MaxForwardSpeed       float64                `json:"max_forward_speed"`
+	ForwardSpeedRecovery  float64                `json:"forward_speed_recovery"`
+	DiveAcceleration      float64                `json:"dive_acceleration"`
+	StrongFlapBoost       float64                `json:"strong_flap_boost"`
+	DamageSpeedLoss       float64                `json:"damage_speed_loss"`
+	HeadwindStrength      float64                `json:"headwind_strength"`
+	HeadwindInterval      float64                `json:"headwind_interval"`
+	DamagedFallSpeed      float64                `json:"damaged_fall_speed"`
+	DamagedDuration       float64                `json:"damaged_duration"`
+	DamagedFlapMultiplier float64                `json:"damaged_flap_multiplier"`
+	DamagedFlapRecovery   float64                `json:"damaged_flap_recovery"`
+	KnockbackSpeed        float64                `json:"knockback_speed"`
+	KnockbackFallSpeed    float64                `json:"knockback_fall_speed"`
+	KnockbackDamping      float64                `json:"knockback_damping"`
+	Gravity               float64                `json:"gravity"`
+	Drag                  float64                `json:"drag"`
+	MaxFallSpeed          float64                `json:"max_fall_speed"`
+	DiveMaxFallSpeed      float64                `json:"dive_max_fall_speed"`
+	DifficultyCurve       []DifficultyBreakpoint `json:"difficulty_curve"`
+	StrongFlapMultiplier  float64                `json:"strong_flap_multiplier"`
+	// the fractions of the flap power lost with the distance a glide of
+	// glide_recovery_time seconds and a feather restore, and a flap uses up
+	GlideRecoveryTime  float64 `json:"glide_recovery_time"`
+	GlideRecovery      float64 `json:"glide_recovery"`
+	FeatherRecovery    float64 `json:"feather_recovery"`
+	FlapRecoveryCost   float64 `json:"flap_recovery_cost"`
+	FeatherStartX      float64 `json:"feather_start_x"`
+	FeatherInterval    float64 `json:"feather_interval"`
+	AltitudeZoneHeight float64 `json:"altitude_zone_height"`
+	AltitudePush       float64 `json:"altitude_push"`
+	AltitudeDamageTime float64 `json:"altitude_damage_time"`
+	SlipstreamLength   float64 `json:"slipstream_length"`
+	SlipstreamHeight   float64 `json:"slipstream_height"`
+	SlipstreamTime     float64 `json:"slipstream_time"`
+	SlipstreamBoost    float64 `json:"slipstream_boost"`
+	Bands              []Band  `json:"bands"`
+	AirplaneSpeed      float64 `json:"airplane_speed"`
+	BirdSpawnInterval  float64 `json:"bird_spawn_interval"`
+	BirdSpeed          float64 `json:"bird_speed"`
+	FlockStartX        float64 `json:"flock_start_x"`
+	FlockInterval      float64 `json:"flock_interval"`
+	FlockMinSize       int     `json:"flock_min_size"`
+	FlockMaxSize       int     `json:"flock_max_size"`
+	FishSkimY          float64 `json:"fish_skim_y"`
+	FishSpawnRate      float64 `json:"fish_spawn_rate"`
+	FishGravity        float64 `json:"fish_gravity"`
+	FishSpeed          float64 `json:"fish_speed"`
+	BalloonStartX      float64 `json:"balloon_start_x"`
+	BalloonInterval    float64 `json:"balloon_interval"`
+	BalloonSpeed       float64 `json:"balloon_speed"`
+	BalloonRideTime    float64 `json:"balloon_ride_time"`
+	BalloonRestTime    float64 `json:"balloon_rest_time"`
+	RingStartX         float64 `json:"ring_start_x"`
+	RingInterval       float64 `json:"ring_interval"`
+	RingBoost          float64 `json:"ring_boost"`
+	BoosterStartX      float64 `json:"booster_start_x"`
+	BoosterInterval    float64 `json:"booster_interval"`
+	SpaceBoosters      int     `json:"space_boosters"`
+	SpaceDuration      float64 `json:"space_duration"`
+	SpaceGravityScale  float64 `json:"space_gravity_scale"`
+	SpaceStarInterval  float64 `json:"space_star_interval"`
+	BreadcrumbStartX   float64 `json:"breadcrumb_start_x"`
+	BreadcrumbInterval float64 `json:"breadcrumb_interval"`
+	// seconds a fed bird escorts the birdman for
+	EscortTime  float64 `json:"escort_time"`
+	BiomeLength float64 `json:"biome_length"`
+	Biomes      []Biome `json:"biomes"`
+}
+
+// LoadConfig reads the config from the resources and, if overridePath is
+// not empty, applies the fields present in that file on top of it.
+func LoadConfig(resources fs.FS, overridePath string) (*GameConfig, error) {
+	c := &GameConfig{}
+
+	data, err := fs.ReadFile(resources, configName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", configName, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configName, err)
+	}
+
+	if overridePath != "" {
+		data, err := ioutil.ReadFile(overridePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", overridePath, err)
+		}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", overridePath, err)
+		}
+	}
+
+	if len(c.DifficultyCurve) == 0 {
+		return nil, fmt.Errorf("%s: difficulty_curve must not be empty", configName)
+	}
+	for i, p := range c.DifficultyCurve {
+		if p.FlapPower <= 0 || p.SpawnRate <= 0 || p.BirdSpeedScale <= 0 {
+			return nil, fmt.Errorf("%s: difficulty_curve[%d] must have a positive flap_power, spawn_rate and bird_speed_scale", configName, i)
+		}
+		last := i == len(c.DifficultyCurve)-1
+		if last != (p.Until == 0) || i > 0 && !last && p.Until <= c.DifficultyCurve[i-1].Until {
+			return nil, fmt.Errorf("%s: difficulty_curve[%d] must end after the one before, and only the last without limit", configName, i)
+		}
+	}
+	if c.MinForwardSpeed <= 0 || c.MinForwardSpeed > c.BirdmanSpeed || c.BirdmanSpeed > c.MaxForwardSpeed {
+		return nil, fmt.Errorf("%s: min_forward_speed <= birdman_speed <= max_forward_speed must hold", configName)
+	}
+	if c.AltitudeZoneHeight <= 0 || c.AltitudeDamageTime <= 0 {
+		return nil, fmt.Errorf("%s: altitude_zone_height and altitude_damage_time must be positive", configName)
+	}
+	if c.SlipstreamTime <= 0 {
+		return nil, fmt.Errorf("%s: slipstream_time must be positive", configName)
+	}
+	if len(c.Bands) == 0 {
+		return nil, fmt.Errorf("%s: bands must not be empty", configName)
+	}
+	total := 0.0
+	for _, b := range c.Bands {
+		for _, h := range b.Hazards {
+			if h.Kind != hazardBird && h.Kind != hazardAirplane || h.Weight < 0 {
+				return nil, fmt.Errorf("%s: band %q has an unknown hazard %q or a negative weight", configName, b.Name, h.Kind)
+			}
+			total += h.Weight
+		}
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("%s: bands must have some hazard to spawn", configName)
+	}
+	if c.BirdSpawnInterval <= 0 {
+		return nil, fmt.Errorf("%s: bird_spawn_interval must be positive", configName)
+	}
+	if c.FlockInterval <= 0 || c.FlockMinSize < 1 || c.FlockMaxSize < c.FlockMinSize {
+		return nil, fmt.Errorf("%s: flock_interval must be positive and 1 <= flock_min_size <= flock_max_size must hold", configName)
+	}
+	if c.FishGravity <= 0 {
+		return nil, fmt.Errorf("%s: fish_gravity must be positive", configName)
+	}
+	if c.BalloonInterval <= 0 || c.BalloonRideTime <= 0 {
+		return nil, fmt.Errorf("%s: balloon_interval and balloon_ride_time must be positive", configName)
+	}
+	if c.GlideRecoveryTime <= 0 || c.FeatherInterval <= 0 {
+		return nil, fmt.Errorf("%s: glide_recovery_time and feather_interval must be positive", configName)
+	}
+	if c.BreadcrumbInterval <= 0 || c.EscortTime <= 0 {
+		return nil, fmt.Errorf("%s: breadcrumb_interval and escort_time must be positive", configName)
+	}
+	if c.RingInterval <= 0 {
+		return nil, fmt.Errorf("%s: ring_interval must be positive", configName)
+	}
+	if len(c.Biomes) > 0 && c.BiomeLength <= biomeFadeLength {
+		return nil, fmt.Errorf("%s: biome_length must be longer than the fade of %dpx", configName, biomeFadeLength)
+	}
+	for _, b := range c.Biomes {
+		if b.FishScale < 0 {
+			return nil, fmt.Errorf("%s: biome %q has a negative fish_scale", configName, b.Name)
+		}
+		for kind, s := range b.HazardScale {
+			if kind != hazardBird && kind != hazardAirplane || s < 0 {
+				return nil, fmt.Errorf("%s: biome %q has an unknown hazard %q or a negative scale", configName, b.Name, kind)
+			}
+		}
+	}
+	if c.BoosterInterval <= 0 || c.SpaceBoosters < 1 || c.SpaceDuration <= 0 || c.SpaceStarInterval <= 0 {
+		return nil, fmt.Errorf("%s: booster_interval, space_boosters, space_duration and space_star_interval must be positive", configName)
+	}
+
+	return c, nil
+}
+
+// Headwind returns the deceleration by the wind at x. Gusts blow over the
+// first half of every headwind interval.
+func (c *GameConfig) Headwind(x float64) float64 {
+	if c.HeadwindInterval <= 0 || x < 0 {
+		return 0
+	}
+	phase := math.Mod(x, c.HeadwindInterval) / c.HeadwindInterval
+	if phase >= 0.5 {
+		return 0
+	}
+	return c.HeadwindStrength * math.Sin(2*math.Pi*phase)
+}
+
+func (c *GameConfig) BandIndex(y float64) int {
+	for i, b := range c.Bands {
+		if b.Bottom == 0 || y < b.Bottom {
+			return i
+		}
+	}
+	return len(c.Bands) - 1
+}
+
+func (c *GameConfig) BandAt(y float64) *Band {
+	return &c.Bands[c.BandIndex(y)]
+}
+
+// BandTop returns the y position where the band at index i begins.
+func (c *GameConfig) BandTop(i int) float64 {
+	if i == 0 {
+		return 0
+	}
+	return c.Bands[i-1].Bottom
+}
+
+func (c *GameConfig) DifficultyIndex(x float64) int {
+	for i, p := range c.DifficultyCurve {
+		if p.Until == 0 || x < p.Until {
+			return i
+		}
+	}
+	return len(c.DifficultyCurve) - 1
+}
+
+func (c *GameConfig) DifficultyAt(x float64) *DifficultyBreakpoint {
+	return &c.DifficultyCurve[c.DifficultyIndex(x)]
+}
+
+// DifficultyStart returns the x position where the section at index i
+// begins.
+func (c *GameConfig) DifficultyStart(i int) float64 {
+	if i == 0 {
+		return 0
+	}
+	return c.DifficultyCurve[i-1].Until
+}
+
+func (c *GameConfig) FlapPower(x float64) float64 {
+	return c.DifficultyAt(x).FlapPower
+}
+
+// FullFlapPower is the flap power at the start, which the recovery restores
+// to.
+func (c *GameConfig) FullFlapPower() float64 {
+	return c.DifficultyCurve[0].FlapPower
+}
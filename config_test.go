@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func loadTestConfig(t *testing.T) *GameConfig {
+	t.Helper()
+	src, err := fs.Sub(resources, "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(src, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFlapPowerDecay(t *testing.T) {
+	c := &GameConfig{
+		DifficultyCurve: []DifficultyBreakpoint{
+			{Until: 1000, FlapPower: 1200},
+			{Until: 2000, FlapPower: 900},
+			{Until: 0, FlapPower: 300},
+		},
+	}
+
+	cases := []struct {
+		x    float64
+		want float64
+	}{
+		{-60, 1200},
+		{0, 1200},
+		{999, 1200},
+		{1000, 900},
+		{1999, 900},
+		{2000, 300},
+		{100000, 300},
+	}
+	for _, tc := range cases {
+		if got := c.FlapPower(tc.x); got != tc.want {
+			t.Errorf("FlapPower(%v) = %v, want %v", tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultFlapPowerNeverIncreases(t *testing.T) {
+	c := loadTestConfig(t)
+
+	prev := c.FlapPower(0)
+	for x := 0.0; x < 10000; x += 10 {
+		p := c.FlapPower(x)
+		if p > prev {
+			t.Fatalf("flap power increases at x=%v: %v -> %v", x, prev, p)
+		}
+		prev = p
+	}
+}
+
+func TestDifficultyCurveValidation(t *testing.T) {
+	if err := validateDifficultyCurve(loadTestConfig(t)); err != nil {
+		t.Fatalf("default curve: %v", err)
+	}
+
+	weak := loadTestConfig(t)
+	weak.FeatherRecovery, weak.GlideRecovery = 0, 0
+	weak.DifficultyCurve[1].FlapPower = 100
+	if err := validateDifficultyCurve(weak); err == nil {
+		t.Error("a curve too weak to keep in the sky passed")
+	}
+
+	crowded := loadTestConfig(t)
+	crowded.DifficultyCurve[len(crowded.DifficultyCurve)-1].SpawnRate = 50
+	if err := validateDifficultyCurve(crowded); err == nil {
+		t.Error("a curve with birds too close together passed")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+// Controller is the source of the birdman's in-run actions. The player's
+// Input is one; headless runs plug in a scripted one instead.
+type Controller interface {
+	ConsumeFlap() (ok, strong bool)
+	IsDivePressed() bool
+	ConsumeRoll() bool
+	ConsumeThrow() bool
+	ConsumeFocus() bool
+}
+
+// ScriptedController flaps at a fixed interval of flying time and never
+// dives.
+type ScriptedController struct {
+	intervalSteps int
+	steps         int
+}
+
+func NewScriptedController(interval float64) *ScriptedController {
+	n := int(interval * simulationRate)
+	if n < 1 {
+		n = 1
+	}
+	return &ScriptedController{intervalSteps: n}
+}
+
+// ConsumeFlap is called once per flying step, so it doubles as the clock.
+func (c *ScriptedController) ConsumeFlap() (ok, strong bool) {
+	c.steps++
+	if c.steps >= c.intervalSteps {
+		c.steps = 0
+		return true, false
+	}
+	return false, false
+}
+
+func (c *ScriptedController) IsDivePressed() bool {
+	return false
+}
+
+func (c *ScriptedController) ConsumeRoll() bool {
+	return false
+}
+
+func (c *ScriptedController) ConsumeThrow() bool {
+	return false
+}
+
+func (c *ScriptedController) ConsumeFocus() bool {
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type Rebinding struct {
+	active bool
+	action Action
+}
+
+func (g *Game) newControlsMenu() *Menu {
+	m := &Menu{
+		title: "CONTROLS",
+		y:     150,
+		small: true,
+	}
+	for _, a := range actions {
+		a := a
+		m.items = append(m.items, MenuItem{
+			label: func() string {
+				if g.rebinding.active && g.rebinding.action == a {
+					return a.String() + ": PRESS INPUT (ESC TO CANCEL)"
+				}
+				return a.String() + ": " + g.settings.Bindings.Get(a).String()
+			},
+			action: func() {
+				g.rebinding = Rebinding{active: true, action: a}
+			},
+		})
+	}
+	m.items = append(m.items,
+		MenuItem{
+			label: func() string { return "RESET TO DEFAULTS" },
+			action: func() {
+				g.settings.Bindings = DefaultBindings()
+				g.saveSettings()
+			},
+		},
+		MenuItem{
+			label: func() string { return "BACK" },
+			action: func() {
+				g.mode = ModeSettings
+			},
+		},
+	)
+	return m
+}
+
+func (g *Game) updateControls() {
+	if !g.rebinding.active {
+		g.controlsMenu.Update(g.input)
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.rebinding.active = false
+		return
+	}
+	if g.settings.Bindings.Get(g.rebinding.action).capture() {
+		g.rebinding.active = false
+		g.saveSettings()
+	}
+}
+
+func (g *Game) saveSettings() {
+	if err := g.settings.Save(g.storage); err != nil {
+		log.Printf("Failed to save settings: %v", err)
+	}
+}
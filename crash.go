@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	crashReportFileName = "crash.txt"
+	inputHistorySize    = 32
+	// of the stack trace logged to the server
+	maxCrashEventStack = 4000
+)
+
+// InputHistory keeps the latest inputs for the crash report.
+type InputHistory struct {
+	frame   int
+	entries [inputHistorySize]string
+	next    int
+	count   int
+}
+
+func (h *InputHistory) add(s string) {
+	h.entries[h.next] = fmt.Sprintf("frame %d: %s", h.frame, s)
+	h.next = (h.next + 1) % inputHistorySize
+	if h.count < inputHistorySize {
+		h.count++
+	}
+}
+
+// Update records the keys pressed and the taps of this frame.
+func (h *InputHistory) Update(input *Input) {
+	h.frame++
+	for _, k := range inpututil.PressedKeys() {
+		if inpututil.IsKeyJustPressed(k) {
+			h.add(k.String())
+		}
+	}
+	if x, y, ok := input.JustTappedPosition(); ok {
+		h.add(fmt.Sprintf("tap %d,%d", x, y))
+	}
+}
+
+// Entries returns the recorded inputs, oldest first.
+func (h *InputHistory) Entries() []string {
+	entries := make([]string, 0, h.count)
+	for i := 0; i < h.count; i++ {
+		entries = append(entries, h.entries[(h.next-h.count+i+inputHistorySize)%inputHistorySize])
+	}
+	return entries
+}
+
+type CrashEvent struct {
+	Error string `json:"error"`
+	Stack string `json:"stack"`
+	State string `json:"state"`
+}
+
+func (CrashEvent) Action() string { return "crash" }
+
+// stateSummary describes the game at the moment, for the crash report.
+func (g *Game) stateSummary() string {
+	lines := []string{
+		fmt.Sprintf("mode %d, paused %v", g.mode, g.paused),
+		fmt.Sprintf("play %s, initialized %d times", g.playID, g.initializeCount),
+	}
+	if b := g.birdman; b != nil {
+		lines = append(lines, fmt.Sprintf("birdman state %d at %.1f,%.1f moving %.1f,%.1f, damaged %d times", b.state, b.x, b.y, b.vx, b.vy, b.damagedCount))
+	}
+	lines = append(lines, fmt.Sprintf("%d birds, %d airplanes, %d fish, %d balloons, %d rings", len(g.birds), len(g.airplanes), len(g.fish), len(g.balloons), len(g.rings)))
+	return strings.Join(lines, "\n")
+}
+
+// saveCrashReport logs the report of a panic and writes it to the storage,
+// and returns the lines for the error screen.
+func saveCrashReport(storage Storage, r interface{}, report string) []string {
+	log.Print(report)
+
+	lines := []string{fmt.Sprintf("%v", r), ""}
+	if storage == nil {
+		lines = append(lines, "The crash report is in the log.")
+	} else if err := storage.Save(crashReportFileName, []byte(report)); err != nil {
+		log.Printf("Failed to save the crash report: %v", err)
+		lines = append(lines, "The crash report is in the log.")
+	} else {
+		lines = append(lines, "A crash report was saved as "+crashReportFileName+" next to the save files.")
+	}
+	return lines
+}
+
+func crashReportHeader(r interface{}) []string {
+	return []string{
+		fmt.Sprintf("Birdman %s on %s crashed at %s", gameVersion, platformName(), time.Now().Format(time.RFC3339)),
+		fmt.Sprintf("panic: %v", r),
+	}
+}
+
+// reportStartupCrash reports a panic from before the game is made, such as
+// while loading the assets, which has no game state to tell.
+func reportStartupCrash(storage Storage, r interface{}, stack []byte) []string {
+	report := strings.Join(append(crashReportHeader(r), "", "while starting up", "", string(stack)), "\n")
+	return saveCrashReport(storage, r, report)
+}
+
+// reportCrash writes what is known about a panic to the storage and logs it,
+// and returns the lines for the error screen.
+func (g *Game) reportCrash(r interface{}, stack []byte) []string {
+	state := g.stateSummary()
+	report := strings.Join(append(crashReportHeader(r),
+		"",
+		state,
+		"",
+		"latest inputs:",
+		strings.Join(g.inputHistory.Entries(), "\n"),
+		"",
+		string(stack),
+	), "\n")
+	lines := saveCrashReport(g.storage, r, report)
+
+	if len(stack) > maxCrashEventStack {
+		stack = stack[:maxCrashEventStack]
+	}
+	// Only sent if the player agreed to logging; the queue keeps it for the
+	// next launch if the game dies before it is sent.
+	g.logEvent(CrashEvent{Error: fmt.Sprintf("%v", r), Stack: string(stack), State: state})
+	return lines
+}
+
+// recoveredPanic carries a panic recovered in another goroutine to the
+// CrashGuard, with the stack where it happened.
+type recoveredPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *recoveredPanic) String() string {
+	return fmt.Sprint(p.value)
+}
+
+// CrashGuard runs a game and, if it panics, shows an error screen in its
+// place rather than letting the game die without a word.
+type CrashGuard struct {
+	game ebiten.Game
+	// report records the panic and returns the lines to show
+	report  func(r interface{}, stack []byte) []string
+	crashed *ErrorScreen
+}
+
+func NewCrashGuard(g *Game) *CrashGuard {
+	return &CrashGuard{game: g, report: g.reportCrash}
+}
+
+// catch is deferred around every call into the game.
+func (c *CrashGuard) catch() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	if p, ok := r.(*recoveredPanic); ok {
+		r, stack = p.value, p.stack
+	}
+	lines := []string{fmt.Sprintf("%v", r)}
+	func() {
+		// A failing report must not take the error screen with it
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Failed to report the crash: %v", r)
+			}
+		}()
+		var reported []string
+		for _, line := range c.report(r, stack) {
+			reported = append(reported, wrapText(line, errorScreenLineWidth)...)
+		}
+		lines = reported
+	}()
+	c.crashed = &ErrorScreen{title: "Sorry, the game crashed", lines: lines}
+}
+
+func (c *CrashGuard) Update() error {
+	if c.crashed != nil {
+		return c.crashed.Update()
+	}
+	defer c.catch()
+	return c.game.Update()
+}
+
+func (c *CrashGuard) Draw(screen *ebiten.Image) {
+	if c.crashed != nil {
+		c.crashed.Draw(screen)
+		return
+	}
+	defer c.catch()
+	c.game.Draw(screen)
+}
+
+func (c *CrashGuard) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if c.crashed != nil {
+		return c.crashed.Layout(outsideWidth, outsideHeight)
+	}
+	return c.game.Layout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	letterboxHeight  = 40
+	cutsceneTextY    = screenHeight - letterboxHeight - smallFontSize*2
+	cutsceneBannerY  = screenHeight/2 - titleFontSize
+	cutsceneSkipText = "ANY KEY TO SKIP"
+	// frames of the actors per second
+	cutsceneFrameRate = 6
+	// the number counted down from before the run
+	introCountdown = 3
+)
+
+// The distances in meters whose first pass is told in a vignette after the
+// run, and its lines
+var milestoneVignettes = []struct {
+	distance int
+	text     string
+}{
+	{1000, "THE GULLS TAKE NOTICE OF THE BIRDMAN"},
+	{3000, "WORD OF THE BIRDMAN SPREADS ALONG THE COAST"},
+	{5000, "THE FLOCKS SALUTE A FELLOW FLIER"},
+}
+
+type CutsceneAction int
+
+const (
+	// moves the camera
+	CutsceneCamera CutsceneAction = iota
+	// moves a sprite through the world
+	CutsceneActor
+	// shows a line in the box at the bottom, or a banner
+	CutsceneText
+)
+
+// CutsceneStep is one timed thing done in a cutscene. The steps overlap
+// freely; the camera steps later in the script win over the earlier ones.
+type CutsceneStep struct {
+	Action CutsceneAction
+	// seconds from the start of the cutscene, and that the step lasts
+	At, Duration float64
+	// in world coordinates, of the top-left corner for the camera and of the
+	// center for the actors
+	FromX, FromY float64
+	ToX, ToY     float64
+	// the sprite of the actor, turned around if flipped and kept to the
+	// first frame if still
+	Frames []*ebiten.Image
+	Flip   bool
+	Still  bool
+	Text   string
+	// drawn big in the middle rather than in the box
+	Banner bool
+	// played at the start of the step, nil for none
+	Sound []byte
+}
+
+// progress returns how far the step is through at t, eased in and out.
+func (s *CutsceneStep) progress(t float64) float64 {
+	if s.Duration <= 0 {
+		return 1
+	}
+	p := math.Max(0, math.Min(1, (t-s.At)/s.Duration))
+	return p * p * (3 - 2*p)
+}
+
+func (s *CutsceneStep) position(t float64) (x, y float64) {
+	p := s.progress(t)
+	return s.FromX + (s.ToX-s.FromX)*p, s.FromY + (s.ToY-s.FromY)*p
+}
+
+func (s *CutsceneStep) active(t float64) bool {
+	return t >= s.At && t < s.At+s.Duration
+}
+
+// Cutscene is a short scripted sequence played over the world, skipped with
+// any input.
+type Cutscene struct {
+	steps []CutsceneStep
+	time  float64
+	// the birdman is played by an actor, so the real one isn't drawn
+	hidesBirdman bool
+	// called once it is over or skipped
+	then func()
+}
+
+func (c *Cutscene) length() float64 {
+	l := 0.0
+	for i := range c.steps {
+		l = math.Max(l, c.steps[i].At+c.steps[i].Duration)
+	}
+	return l
+}
+
+// playCutscene plays the cutscene, or does what comes after it straight away
+// if the cutscenes are turned off in the settings.
+func (g *Game) playCutscene(c *Cutscene) {
+	if !g.settings.Cutscenes {
+		c.then()
+		return
+	}
+	g.cutscene = c
+	g.mode = ModeCutscene
+	g.advanceCutscene(0)
+}
+
+func (g *Game) endCutscene() {
+	c := g.cutscene
+	g.cutscene = nil
+	c.then()
+}
+
+func (g *Game) updateCutscene() {
+	if g.input.IsAnyJustPressed() {
+		// The key skipping it isn't a flap of the run after
+		g.input.ClearFlapBuffer()
+		g.endCutscene()
+		return
+	}
+	g.advanceCutscene(1 / float64(ebiten.MaxTPS()))
+}
+
+// advanceCutscene moves the cutscene on by dt seconds, starting the sounds of
+// the steps reached and ending it after the last one.
+func (g *Game) advanceCutscene(dt float64) {
+	c := g.cutscene
+	prev := c.time
+	c.time += dt
+	for i := range c.steps {
+		s := &c.steps[i]
+		// The steps at the very start are reached by the first call, of no time
+		if s.Sound != nil && s.At <= c.time && (s.At > prev || dt == 0) {
+			g.sfx.PlaySE(s.Sound)
+		}
+		if s.Action == CutsceneCamera && c.time >= s.At {
+			g.camera.x, g.camera.y = s.position(c.time)
+		}
+	}
+	if c.time >= c.length() {
+		g.endCutscene()
+	}
+}
+
+// hidesBirdman reports whether an actor plays the birdman for now.
+func (g *Game) hidesBirdman() bool {
+	return g.mode == ModeCutscene && g.cutscene.hidesBirdman
+}
+
+// drawCutsceneActors draws the actors moving through the world, under the
+// texts.
+func (g *Game) drawCutsceneActors(screen *ebiten.Image) {
+	if g.mode != ModeCutscene {
+		return
+	}
+	c := g.cutscene
+	for i := range c.steps {
+		s := &c.steps[i]
+		if s.Action != CutsceneActor || !s.active(c.time) {
+			continue
+		}
+		x, y := s.position(c.time)
+		frame := int(c.time*cutsceneFrameRate) % len(s.Frames)
+		if s.Still {
+			frame = 0
+		}
+		img := s.Frames[frame]
+		w, h := img.Size()
+		opt := scratchDrawOptions()
+		opt.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+		if s.Flip {
+			opt.GeoM.Scale(-1, 1)
+		}
+		opt.GeoM.Translate(x-g.camera.ViewX(), y-g.camera.ViewY())
+		screen.DrawImage(img, opt)
+	}
+}
+
+// drawCutscene draws the letterbox and the texts of the steps going on.
+func (g *Game) drawCutscene(screen *ebiten.Image) {
+	c := g.cutscene
+	ebitenutil.DrawRect(screen, 0, 0, screenWidth, letterboxHeight, color.Black)
+	ebitenutil.DrawRect(screen, 0, screenHeight-letterboxHeight, screenWidth, letterboxHeight, color.Black)
+	for i := range c.steps {
+		s := &c.steps[i]
+		if s.Action != CutsceneText || !s.active(c.time) {
+			continue
+		}
+		if s.Banner {
+			text.Draw(screen, s.Text, titleFont, screenWidth/2-len(s.Text)*titleFontSize/2, cutsceneBannerY, color.White)
+			continue
+		}
+		ebitenutil.DrawRect(screen, 0, cutsceneTextY-smallFontSize*3/2, screenWidth, smallFontSize*2, commentaryColor)
+		text.Draw(screen, s.Text, smallFont, screenWidth/2-len(s.Text)*smallFontSize/2, cutsceneTextY, color.White)
+	}
+	text.Draw(screen, cutsceneSkipText, smallFont, screenWidth-24-len(cutsceneSkipText)*smallFontSize, screenHeight-letterboxHeight/2+smallFontSize/2, color.White)
+}
+
+// introCutscene shows the birdman climbing up the launch and walking back to
+// the start of the run-up, while the crowd counts down to the take-off.
+func (g *Game) introCutscene() *Cutscene {
+	l := g.launch
+	runUpX := -l.RunUp
+	wideX := -float64(screenWidth) / 2
+	steps := []CutsceneStep{
+		{Action: CutsceneCamera, FromX: wideX, ToX: wideX},
+		{Action: CutsceneText, Duration: 2, Text: "THE CROWD GATHERS BY THE SEA"},
+		{Action: CutsceneActor, Duration: 2.5, FromY: screenHeight, ToY: l.Y, Frames: birdmanFrames},
+		{Action: CutsceneText, At: 2, Duration: 2, Text: "THE BIRDMAN CLIMBS THE " + l.Name},
+		{Action: CutsceneActor, At: 2.5, Duration: 1, ToX: runUpX, FromY: l.Y, ToY: l.Y, Frames: birdmanFrames, Flip: true, Still: true},
+		{Action: CutsceneCamera, At: 2.5, Duration: 1, FromX: wideX, ToX: -cameraOffsetX},
+	}
+	at := 3.5
+	const goDuration = 0.6
+	for n := introCountdown; n > 0; n-- {
+		steps = append(steps, CutsceneStep{Action: CutsceneText, At: at, Duration: 0.8, Text: fmt.Sprint(n), Banner: true, Sound: warningAudioData})
+		at += 0.8
+	}
+	steps = append(steps,
+		CutsceneStep{Action: CutsceneActor, At: 3.5, Duration: at + goDuration - 3.5, FromX: runUpX, ToX: runUpX, FromY: l.Y, ToY: l.Y, Frames: birdmanFrames, Still: true},
+		CutsceneStep{Action: CutsceneText, At: at, Duration: goDuration, Text: "GO!", Banner: true, Sound: cheerAudioData},
+	)
+	return &Cutscene{
+		steps:        steps,
+		hidesBirdman: true,
+		then:         g.startGame,
+	}
+}
+
+// startIntro plays the intro before the first run of the session from the
+// title.
+func (g *Game) startIntro() {
+	if g.introPlayed {
+		g.startGame()
+		return
+	}
+	g.introPlayed = true
+	g.playCutscene(g.introCutscene())
+}
+
+// milestoneVignette returns the vignette of the furthest milestone first
+// passed by the new best, or nil if none was.
+func (g *Game) milestoneVignette() *Cutscene {
+	line := ""
+	distance := 0
+	for _, v := range milestoneVignettes {
+		if g.stats.previousBest < v.distance && v.distance <= g.records.BestDistance {
+			line, distance = v.text, v.distance
+		}
+	}
+	if line == "" {
+		return nil
+	}
+	x, y := g.camera.x, g.camera.y
+	birdY := []float64{screenHeight / 4, screenHeight / 3, screenHeight / 5}
+	steps := []CutsceneStep{
+		{Action: CutsceneCamera, Duration: 4, FromX: x, FromY: y, ToX: x + 160, ToY: y},
+		{Action: CutsceneText, Duration: 1.8, Text: fmt.Sprintf("%sm!", formatIntComma(distance)), Banner: true, Sound: cheerAudioData},
+		{Action: CutsceneText, At: 1.8, Duration: 2.2, Text: line},
+	}
+	// Birds fly by the other way in a loose line
+	for i, by := range birdY {
+		at := 0.3 * float64(i)
+		steps = append(steps, CutsceneStep{
+			Action:   CutsceneActor,
+			At:       at,
+			Duration: 3.5,
+			FromX:    x + screenWidth + birdWidth + float64(i*40),
+			FromY:    by,
+			ToX:      x - birdWidth,
+			ToY:      by - 40,
+			Frames:   birdFrames,
+		})
+	}
+	mode := g.mode
+	return &Cutscene{
+		steps: steps,
+		then: func() {
+			g.camera.x, g.camera.y = x, y
+			g.mode = mode
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"hash/crc32"
+	"time"
+)
+
+// dailyDate is the day of the daily run. It is taken in UTC so that it is
+// the same run everywhere at the same time.
+func dailyDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// dailySeed is the seed of the hazards of the daily run of the date.
+func dailySeed(date string) uint32 {
+	return crc32.ChecksumIEEE([]byte(gameName+"-daily-"+date)) & challengeSeedMask
+}
+
+// dailyChallenge is the run of the day, played as a challenge against the
+// best distance of the day, so that it gets the same hazards everywhere and
+// none of the local difficulty and upgrades.
+func (g *Game) dailyChallenge(t time.Time) *Challenge {
+	date := dailyDate(t)
+	c := &Challenge{Seed: dailySeed(date), Kind: ChallengeDistance}
+	if g.records.DailyDate == date {
+		c.Result = g.records.DailyBest
+	}
+	return c
+}
+
+func (g *Game) startDaily() {
+	now := time.Now()
+	g.startChallenge(g.dailyChallenge(now))
+	g.daily = dailyDate(now)
+}
+
+// updateDailyBest keeps the best distance of the daily run of the day.
+func (g *Game) updateDailyBest() {
+	d := int(g.birdman.x) / 10
+	if g.records.DailyDate != g.daily {
+		g.records.DailyDate, g.records.DailyBest = g.daily, 0
+	}
+	if d > g.records.DailyBest {
+		g.records.DailyBest = d
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+var (
+	debugHitboxColor  = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	debugVectorColor  = color.RGBA{0x40, 0xff, 0x40, 0xff}
+	debugTriggerColor = color.RGBA{0xff, 0xff, 0x40, 0xff}
+)
+
+func (g *Game) updateDebug() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF2) {
+		g.debugHitboxes = !g.debugHitboxes
+	}
+}
+
+func drawDebugCircle(screen *ebiten.Image, x, y, r float64, clr color.Color) {
+	const segments = 16
+	for i := 0; i < segments; i++ {
+		a0 := 2 * math.Pi * float64(i) / segments
+		a1 := 2 * math.Pi * float64(i+1) / segments
+		ebitenutil.DrawLine(screen, x+r*math.Cos(a0), y+r*math.Sin(a0), x+r*math.Cos(a1), y+r*math.Sin(a1), clr)
+	}
+}
+
+func drawDebugShape(screen *ebiten.Image, s Shape, clr color.Color) {
+	switch s.Kind {
+	case ShapeCircle:
+		drawDebugCircle(screen, s.X, s.Y, s.R, clr)
+	case ShapeAABB:
+		ebitenutil.DrawLine(screen, s.X, s.Y, s.X+s.W, s.Y, clr)
+		ebitenutil.DrawLine(screen, s.X+s.W, s.Y, s.X+s.W, s.Y+s.H, clr)
+		ebitenutil.DrawLine(screen, s.X+s.W, s.Y+s.H, s.X, s.Y+s.H, clr)
+		ebitenutil.DrawLine(screen, s.X, s.Y+s.H, s.X, s.Y, clr)
+	case ShapeCapsule:
+		drawDebugCircle(screen, s.X, s.Y, s.R, clr)
+		drawDebugCircle(screen, s.X2, s.Y2, s.R, clr)
+		dx, dy := s.X2-s.X, s.Y2-s.Y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return
+		}
+		nx, ny := -dy/l*s.R, dx/l*s.R
+		ebitenutil.DrawLine(screen, s.X+nx, s.Y+ny, s.X2+nx, s.Y2+ny, clr)
+		ebitenutil.DrawLine(screen, s.X-nx, s.Y-ny, s.X2-nx, s.Y2-ny, clr)
+	}
+}
+
+func drawDebugHitbox(screen *ebiten.Image, h Hitbox, x, y float64, clr color.Color) {
+	for _, s := range h {
+		drawDebugShape(screen, s.translated(x, y), clr)
+	}
+}
+
+// drawDebugHitboxes overlays collision shapes, the birdman's velocity and the
+// next bird spawn trigger in screen coordinates.
+func (g *Game) drawDebugHitboxes(screen *ebiten.Image) {
+	if !g.debugHitboxes {
+		return
+	}
+
+	birdman := g.birdman
+	bx, by := birdman.x-g.camera.ViewX(), birdman.y-g.camera.ViewY()
+	drawDebugHitbox(screen, birdman.hitbox(), bx, by, debugHitboxColor)
+	const vectorSeconds = 0.2
+	vx := birdman.vx
+	if birdman.state == StateDamaged {
+		vx = birdman.knockbackVx
+	}
+	ebitenutil.DrawLine(screen, bx, by, bx+vx*vectorSeconds, by+birdman.vy*vectorSeconds, debugVectorColor)
+
+	for i := range g.birds {
+		b := &g.birds[i]
+		drawDebugHitbox(screen, b.hitbox(), b.x-g.camera.ViewX(), b.y-g.camera.ViewY(), debugHitboxColor)
+	}
+	for i := range g.airplanes {
+		a := &g.airplanes[i]
+		drawDebugHitbox(screen, airplaneHitbox, a.x-g.camera.ViewX(), a.y-g.camera.ViewY(), debugHitboxColor)
+	}
+	for i := range g.fish {
+		f := &g.fish[i]
+		drawDebugHitbox(screen, fishHitbox, f.x-g.camera.ViewX(), f.y-g.camera.ViewY(), debugHitboxColor)
+	}
+
+	triggerX := g.nextBirdX - g.camera.ViewX()
+	ebitenutil.DrawLine(screen, triggerX, 0, triggerX, screenHeight, debugTriggerColor)
+}
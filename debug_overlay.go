@@ -0,0 +1,68 @@
+//go:build !nodebug
+// +build !nodebug
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const debugOverlayMemStatsInterval = 30
+
+// DebugOverlay shows runtime and game statistics. Build with the nodebug
+// tag to leave it out of release binaries.
+type DebugOverlay struct {
+	visible  bool
+	ticks    int
+	memStats runtime.MemStats
+}
+
+func (o *DebugOverlay) Update() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		o.visible = !o.visible
+	}
+	if !o.visible {
+		return
+	}
+	// ReadMemStats stops the world, so don't call it every frame
+	if o.ticks%debugOverlayMemStatsInterval == 0 {
+		runtime.ReadMemStats(&o.memStats)
+	}
+	o.ticks++
+}
+
+func (o *DebugOverlay) Draw(screen *ebiten.Image, g *Game) {
+	if !o.visible {
+		return
+	}
+
+	b := g.birdman
+	msg := fmt.Sprintf(
+		"FPS: %0.1f\nTPS: %0.1f\n%s\nCamera: (%0.1f, %0.1f)\nBirdman: %s (%0.1f, %0.1f) vx=%0.1f vy=%0.1f\nDifficulty: %d (flap %0.0f)\nHeap: %0.1f MB\nGC: %d",
+		ebiten.CurrentFPS(),
+		ebiten.CurrentTPS(),
+		debugEntityCounts(g),
+		g.camera.x, g.camera.y,
+		b.state, b.x, b.y, b.vx, b.vy,
+		g.config.DifficultyIndex(b.x), g.config.FlapPower(b.x),
+		float64(o.memStats.HeapAlloc)/1024/1024,
+		o.memStats.NumGC,
+	)
+	ebitenutil.DebugPrintAt(screen, msg, 4, 40)
+}
+
+// debugEntityCounts lists the sizes of the entity slices the simulation
+// updates, a few to a line.
+func debugEntityCounts(g *Game) string {
+	return fmt.Sprintf(
+		"Birds: %d (flocks %d) Airplanes: %d Fish: %d Splashes: %d\nBalloons: %d Rings: %d Feathers: %d Puffs: %d Boosters: %d\nBreadcrumbs: %d Crumbs: %d Stars: %d",
+		len(g.birds), len(g.flocks), len(g.airplanes), len(g.fish), len(g.splashes),
+		len(g.balloons), len(g.rings), len(g.feathers), len(g.featherPuffs), len(g.boosters),
+		len(g.breadcrumbs), len(g.crumbs), len(g.spaceStars),
+	)
+}
@@ -0,0 +1,14 @@
+//go:build nodebug
+// +build nodebug
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type DebugOverlay struct{}
+
+func (o *DebugOverlay) Update() {}
+
+func (o *DebugOverlay) Draw(screen *ebiten.Image, g *Game) {}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+const assetWatchIntervalTicks = 60
+
+// AssetWatcher polls a resources directory for modified files. Polling is
+// used rather than OS notifications so it works the same on every desktop
+// platform without extra dependencies.
+type AssetWatcher struct {
+	dir      string
+	modTimes map[string]time.Time
+	ticks    int
+}
+
+func NewAssetWatcher(dir string) *AssetWatcher {
+	w := &AssetWatcher{
+		dir:      dir,
+		modTimes: make(map[string]time.Time),
+	}
+	w.Poll()
+	return w
+}
+
+// Poll returns the names of the files changed since the last call.
+func (w *AssetWatcher) Poll() []string {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil
+	}
+
+	var changed []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		name := e.Name()
+		if t, ok := w.modTimes[name]; !ok || !t.Equal(info.ModTime()) {
+			if ok {
+				changed = append(changed, name)
+			}
+			w.modTimes[name] = info.ModTime()
+		}
+	}
+	return changed
+}
+
+func (g *Game) reloadChangedAssets() {
+	if g.assetWatcher == nil {
+		return
+	}
+	g.assetWatcher.ticks++
+	if g.assetWatcher.ticks%assetWatchIntervalTicks != 0 {
+		return
+	}
+
+	changed := g.assetWatcher.Poll()
+	if len(changed) == 0 {
+		return
+	}
+	for _, name := range changed {
+		log.Printf("Reloading %s", name)
+		g.assets.Invalidate(name)
+	}
+	if err := loadAssets(g.assets); err != nil {
+		log.Printf("Failed to reload assets: %v", err)
+		return
+	}
+
+	g.backdrop = NewBackdrop()
+	g.applyScenery()
+
+	// Entities keep their own frame references
+	g.birdman.frames = birdmanFrames
+	for i := range g.birds {
+		g.birds[i].frames = birdFrames
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	// in meters
+	earlyDeathDistance = 1000
+	breezeDistance     = 3000
+	// consecutive early deaths before the gaps start to widen
+	earlyDeathsToEase = 2
+	gapScaleStep      = 0.05
+	minGapScale       = 0.85
+	maxGapScale       = 1.3
+)
+
+// AdaptiveDifficulty is kept in the records so that it carries over between
+// sessions.
+type AdaptiveDifficulty struct {
+	// multiplies the gaps between single birds, 1 (or 0 when never adjusted)
+	// for none
+	GapScale    float64 `json:"gap_scale,omitempty"`
+	EarlyDeaths int     `json:"early_deaths,omitempty"`
+}
+
+func (d *AdaptiveDifficulty) scale() float64 {
+	if d.GapScale == 0 {
+		return 1
+	}
+	return d.GapScale
+}
+
+// adjust takes the distance of a run and returns the new gap scale. The gaps
+// widen a little after repeated early deaths and tighten for every run past
+// breezeDistance.
+func (d *AdaptiveDifficulty) adjust(distance int) float64 {
+	s := d.scale()
+	switch {
+	case distance < earlyDeathDistance:
+		d.EarlyDeaths++
+		if d.EarlyDeaths >= earlyDeathsToEase {
+			s += gapScaleStep
+		}
+	case distance >= breezeDistance:
+		d.EarlyDeaths = 0
+		s -= gapScaleStep
+	default:
+		d.EarlyDeaths = 0
+	}
+	// Round off the float error of the steps
+	s = math.Round(math.Max(minGapScale, math.Min(maxGapScale, s))*100) / 100
+	d.GapScale = s
+	return s
+}
+
+type DifficultyAdjustedEvent struct {
+	// in meters
+	Distance    int     `json:"distance"`
+	From        float64 `json:"from"`
+	To          float64 `json:"to"`
+	EarlyDeaths int     `json:"early_deaths"`
+}
+
+func (DifficultyAdjustedEvent) Action() string { return "difficulty_adjusted" }
+
+// gapScale is how much the adaptive difficulty widens the gaps between
+// hazards, 1 when it is off or a challenge is played, which must play the
+// same on every device.
+func (g *Game) gapScale() float64 {
+	if !g.settings.AdaptiveDifficulty || g.challenge != nil {
+		return 1
+	}
+	return g.records.Difficulty.scale()
+}
+
+// adjustDifficulty adapts the difficulty to the run just over, logging the
+// change so that its effect on the following runs can be evaluated.
+func (g *Game) adjustDifficulty() {
+	if !g.settings.AdaptiveDifficulty {
+		return
+	}
+	d := &g.records.Difficulty
+	distance := int(g.birdman.x) / 10
+	from := d.scale()
+	if to := d.adjust(distance); to != from {
+		g.logEvent(DifficultyAdjustedEvent{
+			Distance:    distance,
+			From:        from,
+			To:          to,
+			EarlyDeaths: d.EarlyDeaths,
+		})
+	}
+}
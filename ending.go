@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// in meters, where the island is reached
+	endingDistance = 10000
+	// in pixels, of the island past the ending distance
+	islandGap    = 300
+	islandWidth  = 480
+	islandTop    = screenHeight - 100
+	islandHillW  = 200
+	islandHillH  = 30
+	palmHeight   = 70
+	endingTitleY = 90
+	endingStatsY = 180
+	endingMenuY  = 400
+	// the new game+ loops are harder by these for every loop
+	loopSpawnScale = 0.85
+	loopSpeedScale = 1.1
+)
+
+var (
+	islandSandColor = color.RGBA{0xf0, 0xd8, 0x90, 0xff}
+	islandHillColor = color.RGBA{0x50, 0xa0, 0x48, 0xff}
+	palmTrunkColor  = color.RGBA{0x8a, 0x60, 0x38, 0xff}
+)
+
+func islandX() float64 {
+	return endingDistance*10 + islandGap
+}
+
+// endingReachable reports whether the run may fly to the island. The
+// speedruns and the races have finishes of their own, a practice warps and
+// the turns of a party go on to the standings.
+func (g *Game) endingReachable() bool {
+	return g.speedrunTarget == 0 && !g.stats.practice && !g.party.active
+}
+
+// hardenConfig makes the hazards of the new game+ come sooner and faster by
+// the loop.
+func hardenConfig(c *GameConfig, loop int) {
+	spawn := math.Pow(loopSpawnScale, float64(loop))
+	c.BirdSpawnInterval *= spawn
+	c.FlockInterval *= spawn
+	c.BirdSpeed *= math.Pow(loopSpeedScale, float64(loop))
+}
+
+// checkEnding ends the run on reaching the island with the landing on it and
+// the congratulations after.
+func (g *Game) checkEnding() {
+	if g.mode != ModeGame || !g.endingReachable() || int(g.birdman.x)/10 < endingDistance {
+		return
+	}
+	bx, by := g.birdman.x, g.birdman.y
+	cx, cy := g.camera.x, g.camera.y
+	g.stats.landed = true
+	g.gameOver()
+
+	landX, landY := islandX()+islandWidth/3, float64(islandTop-birdmanHeight/2)
+	viewX := islandX() + islandWidth/2 - screenWidth/2
+	g.playCutscene(&Cutscene{
+		steps: []CutsceneStep{
+			{Action: CutsceneCamera, Duration: 3, FromX: cx, FromY: cy, ToX: viewX},
+			{Action: CutsceneActor, Duration: 3, FromX: bx, FromY: by, ToX: landX, ToY: landY, Frames: birdmanFrames},
+			{Action: CutsceneActor, At: 3, Duration: 2.5, FromX: landX, FromY: landY, ToX: landX, ToY: landY, Frames: birdmanFrames, Still: true},
+			{Action: CutsceneText, Duration: 1.5, Text: fmt.Sprintf("%sm!", formatIntComma(endingDistance)), Banner: true, Sound: cheerAudioData},
+			{Action: CutsceneText, At: 1.5, Duration: 2, Text: "LAND HO! A DISTANT ISLAND"},
+			{Action: CutsceneText, At: 3.5, Duration: 2, Text: "THE BIRDMAN HAS CROSSED THE SEA"},
+		},
+		hidesBirdman: true,
+		then: func() {
+			g.mode = ModeEnding
+			g.fireworks.Reset()
+		},
+	})
+}
+
+// drawIsland draws the island at the end of the course, with a palm on a
+// hill.
+func (g *Game) drawIsland(screen *ebiten.Image) {
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	x := islandX() - viewX
+	if x < -islandWidth || x > screenWidth {
+		return
+	}
+	top := islandTop - viewY
+	ebitenutil.DrawRect(screen, x, top, islandWidth, screenHeight-top, islandSandColor)
+	hillX := x + islandWidth/2
+	ebitenutil.DrawRect(screen, hillX, top-islandHillH, islandHillW, islandHillH, islandHillColor)
+	palmX := hillX + islandHillW/2
+	ebitenutil.DrawRect(screen, palmX, top-islandHillH-palmHeight, 6, palmHeight, palmTrunkColor)
+	ebitenutil.DrawRect(screen, palmX-24, top-islandHillH-palmHeight-8, 54, 10, islandHillColor)
+}
+
+// startNewGamePlus starts the next loop, harder than the one just cleared.
+func (g *Game) startNewGamePlus() {
+	g.loop++
+	g.initialize()
+	g.startGame()
+}
+
+// newGamePlusLabel names the loop, or tells there is none.
+func newGamePlusLabel(loop int) string {
+	if loop == 0 {
+		return "OFF"
+	}
+	return fmt.Sprint(loop)
+}
+
+func (g *Game) newEndingMenu() *Menu {
+	return &Menu{
+		y:     endingMenuY,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label:  func() string { return fmt.Sprintf("NEW GAME+ %d", g.loop+1) },
+				action: g.startNewGamePlus,
+			},
+			{
+				label:  func() string { return "TITLE" },
+				action: g.initialize,
+			},
+		},
+	}
+}
+
+func (g *Game) updateEndingScreen() {
+	g.fireworks.Update(1 / float64(ebiten.MaxTPS()))
+	g.endingMenu.Update(g.input)
+}
+
+// endingTexts are the stats of the run which reached the island, and of all
+// the runs so far.
+func (g *Game) endingTexts() []string {
+	r := g.records
+	texts := []string{
+		"TIME        " + formatRunTime(g.splits.time),
+		fmt.Sprintf("FLAPS       %s", formatIntComma(g.stats.flaps)),
+		fmt.Sprintf("HITS TAKEN  %d", g.stats.damage),
+		fmt.Sprintf("STYLE       %s", formatIntComma(g.stylePoints)),
+		fmt.Sprintf("RUNS        %s", formatIntComma(r.Runs)),
+		fmt.Sprintf("TOTAL       %sm", formatIntComma(r.TotalDistance)),
+	}
+	if r.Loops > 0 {
+		texts = append(texts, fmt.Sprintf("LOOPS       %d CLEARED", r.Loops))
+	}
+	return texts
+}
+
+func (g *Game) drawEndingScreen(screen *ebiten.Image) {
+	g.fireworks.Draw(screen)
+	const title = "CONGRATULATIONS!"
+	text.Draw(screen, title, titleFont, screenWidth/2-len(title)*titleFontSize/2, endingTitleY, winnerColor)
+	s := "YOU LANDED ON THE ISLAND"
+	if g.loop > 0 {
+		s = fmt.Sprintf("NEW GAME+ %d CLEARED", g.loop)
+	}
+	text.Draw(screen, s, regularFont, screenWidth/2-len(s)*regularFontSize/2, endingTitleY+regularFontSize*2, color.White)
+	// Lined up on the left for the columns
+	texts := g.endingTexts()
+	width := 0
+	for _, s := range texts {
+		if len(s) > width {
+			width = len(s)
+		}
+	}
+	for i, s := range texts {
+		text.Draw(screen, s, smallFont, screenWidth/2-width*smallFontSize/2, endingStatsY+i*smallFontSize*2, color.White)
+	}
+	g.endingMenu.Draw(screen)
+}
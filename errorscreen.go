@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const errorScreenLineWidth = 100
+
+// AssetErrors lists every asset which failed to load.
+type AssetErrors []error
+
+func (e AssetErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var errQuitErrorScreen = errors.New("quit")
+
+// ErrorScreen is run instead of the game when it can't start. It uses the
+// debug font as the game's own font may be what failed to load.
+type ErrorScreen struct {
+	title string
+	lines []string
+}
+
+func NewErrorScreen(title string, err error) *ErrorScreen {
+	s := &ErrorScreen{title: title}
+	var errs AssetErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			s.lines = append(s.lines, wrapText("- "+e.Error(), errorScreenLineWidth)...)
+		}
+	} else {
+		s.lines = wrapText(err.Error(), errorScreenLineWidth)
+	}
+	return s
+}
+
+// wrapText splits s into lines of at most width characters.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for len(s) > width {
+		i := strings.LastIndex(s[:width], " ")
+		if i <= 0 {
+			i = width
+		}
+		lines = append(lines, s[:i])
+		s = strings.TrimLeft(s[i:], " ")
+	}
+	return append(lines, s)
+}
+
+func (s *ErrorScreen) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		return errQuitErrorScreen
+	}
+	return nil
+}
+
+func (s *ErrorScreen) Draw(screen *ebiten.Image) {
+	msg := s.title + "\n\n" + strings.Join(s.lines, "\n") + "\n\nPress ENTER to quit."
+	ebitenutil.DebugPrintAt(screen, msg, 16, 16)
+}
+
+func (s *ErrorScreen) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
+}
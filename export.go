@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the oldest runs are dropped from the history beyond this
+const maxRunHistory = 1000
+
+// RunRecord is one run of the history kept in the records.
+type RunRecord struct {
+	Date time.Time `json:"date"`
+	// in meters
+	Distance int `json:"distance"`
+	// in seconds
+	Duration    float64 `json:"duration"`
+	StylePoints int     `json:"style_points"`
+	Cause       string  `json:"cause"`
+}
+
+var runRecordCSVHeader = []string{"date", "distance_m", "duration_s", "style_points", "cause"}
+
+// exportRecords writes the run history and the lifetime stats as JSON, or
+// only the history as CSV, one run per row, which is what spreadsheets want.
+func exportRecords(w io.Writer, r *Records, format string) error {
+	switch format {
+	case "json":
+		history := r.History
+		if history == nil {
+			history = []RunRecord{}
+		}
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(struct {
+			BestDistance  int         `json:"best_distance"`
+			Runs          int         `json:"runs"`
+			TotalDistance int         `json:"total_distance"`
+			History       []RunRecord `json:"history"`
+		}{r.BestDistance, r.Runs, r.TotalDistance, history})
+	case "csv":
+		c := csv.NewWriter(w)
+		if err := c.Write(runRecordCSVHeader); err != nil {
+			return err
+		}
+		for _, run := range r.History {
+			if err := c.Write([]string{
+				run.Date.Format(time.RFC3339),
+				strconv.Itoa(run.Distance),
+				strconv.FormatFloat(run.Duration, 'f', 2, 64),
+				strconv.Itoa(run.StylePoints),
+				run.Cause,
+			}); err != nil {
+				return err
+			}
+		}
+		c.Flush()
+		return c.Error()
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func exportToFile(r *Records, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := exportRecords(f, r, exportFormat(path)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportFormat tells the format from the extension of a file name.
+func exportFormat(name string) string {
+	if strings.HasSuffix(strings.ToLower(name), ".json") {
+		return "json"
+	}
+	return "csv"
+}
+
+// exportFromMenu saves the records in the format under a dated name, see
+// saveExport of the platform.
+func (g *Game) exportFromMenu(format string) {
+	var b strings.Builder
+	if err := exportRecords(&b, g.records, format); err != nil {
+		log.Printf("Failed to export the records: %v", err)
+		g.exportStatus = "EXPORT FAILED"
+		return
+	}
+	name := fmt.Sprintf("%s-history-%s.%s", gameName, time.Now().Format("20060102"), format)
+	where, err := saveExport(name, []byte(b.String()))
+	if err != nil {
+		log.Printf("Failed to export the records: %v", err)
+		g.exportStatus = "EXPORT FAILED"
+		return
+	}
+	log.Printf("Exported the records to %s", where)
+	g.exportStatus = "SAVED " + strings.ToUpper(name)
+}
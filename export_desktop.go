@@ -0,0 +1,24 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// saveExport writes an exported file to the Downloads folder, or to the home
+// directory if there is none, and returns its path.
+func saveExport(name string, data []byte) (string, error) {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Join(dir, "Downloads")); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, "Downloads")
+	}
+	path := filepath.Join(dir, name)
+	return path, ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,39 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// saveExport has the browser download an exported file.
+func saveExport(name string, data []byte) (where string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to download %s: %v", name, r)
+		}
+	}()
+	doc := js.Global().Get("document")
+	if doc.IsUndefined() {
+		return "", fmt.Errorf("no document to download %s from", name)
+	}
+
+	a := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(a, data)
+	blob := js.Global().Get("Blob").New([]interface{}{a})
+	urls := js.Global().Get("URL")
+	url := urls.Call("createObjectURL", blob)
+	// Revoked later, as the download may not have started when click
+	// returns
+	defer js.Global().Call("setTimeout", urls.Get("revokeObjectURL").Call("bind", urls, url), 10000)
+
+	link := doc.Call("createElement", "a")
+	link.Set("href", url)
+	link.Set("download", name)
+	doc.Get("body").Call("appendChild", link)
+	link.Call("click")
+	link.Call("remove")
+	return "the downloads of the browser", nil
+}
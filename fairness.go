@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// seconds between the flaps of the quickest player thought of
+	reachFlapInterval = 0.15
+	// the birds this close to a new one across make a wall with it
+	wallDepth = birdWidth + birdmanWidth
+	// in pixels, of the positions the birdman may fly through a wall at on
+	// top of the rise of a flap
+	fairGapHeight = 20
+	// times a bird is placed again before it is left out
+	fairSpawnTries = 6
+)
+
+// spriteSpan returns the top and the bottom of the hitboxes of all the
+// frames, at the scale (0 for the normal one).
+func spriteSpan(s SpriteHitboxes, scale float64) (top, bottom float64) {
+	top, bottom = math.Inf(1), math.Inf(-1)
+	for _, h := range s {
+		if scale != 0 {
+			h = h.Scaled(scale)
+		}
+		_, minY, _, maxY := h.Bounds(0, 0)
+		top, bottom = math.Min(top, minY), math.Max(bottom, maxY)
+	}
+	return top, bottom
+}
+
+// verticalReach returns where the birdman is dt seconds from now when
+// flapping as fast as can be, or not at all.
+func (g *Game) verticalReach(dt float64, flapping bool) float64 {
+	b := g.birdman
+	y, vy := b.y, b.vy
+	power := g.flapPower() / float64(b.damagedCount+1)
+	next := 0.0
+	for t := 0.0; t < dt; t += simulationStep {
+		if flapping && t >= next {
+			vy -= power
+			next += reachFlapInterval
+		}
+		vy += (g.config.Gravity - g.config.Drag*vy) * simulationStep
+		vy = math.Min(vy, g.config.MaxFallSpeed)
+		y += vy * simulationStep
+	}
+	return y
+}
+
+// flapSwing returns how far a flap lifts the birdman falling as fast as he
+// does, which is the least he bobs up and down by keeping to a height. He
+// doesn't bob at all without gravity.
+func (g *Game) flapSwing() float64 {
+	if g.config.Gravity <= 0 {
+		return 0
+	}
+	vy := g.config.MaxFallSpeed - g.flapPower()/float64(g.birdman.damagedCount+1)
+	rise := 0.0
+	for vy < 0 {
+		rise -= vy * simulationStep
+		vy += (g.config.Gravity - g.config.Drag*vy) * simulationStep
+	}
+	return rise
+}
+
+// reachableEnvelope returns the span of the positions the birdman can be at
+// dt seconds from now with the flap power and the gravity of the moment,
+// kept out of the altitude zone and the sea.
+func (g *Game) reachableEnvelope(dt float64) (top, bottom float64) {
+	top = math.Max(g.verticalReach(dt, true), g.config.AltitudeZoneHeight)
+	bottom = math.Min(g.verticalReach(dt, false), screenHeight)
+	return top, math.Max(top, bottom)
+}
+
+// passable reports whether a bird at x, y leaves the birdman a gap within
+// his reach to fly through the birds it makes a wall with.
+func (g *Game) passable(x, y, scale float64) bool {
+	b := g.birdman
+	manTop, manBottom := spriteSpan(birdmanHitboxes, b.scale)
+	blocked := func(by, scale float64) [2]float64 {
+		top, bottom := spriteSpan(birdHitboxes, scale)
+		return [2]float64{by + top - manBottom, by + bottom - manTop}
+	}
+	spans := [][2]float64{blocked(y, scale)}
+	for i := range g.birds {
+		if o := &g.birds[i]; o.isHazard() && math.Abs(o.x-x) < wallDepth {
+			spans = append(spans, blocked(o.y, o.scale))
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	// The bird meets the birdman coming at him
+	top, bottom := g.reachableEnvelope((x - b.x) / (b.vx + g.birdSpeed()))
+	gap := fairGapHeight + g.flapSwing()
+	free := top
+	for _, s := range spans {
+		if s[0]-free >= gap {
+			return true
+		}
+		free = math.Max(free, s[1])
+		if free >= bottom {
+			return false
+		}
+	}
+	return bottom-free >= gap
+}
+
+// placeFairly returns a position between top and bottom for a bird at x
+// which leaves a gap in the wall, or false if none was found.
+func (g *Game) placeFairly(x, top, bottom, scale float64) (float64, bool) {
+	for i := 0; i < fairSpawnTries; i++ {
+		y := top + g.rand.Float64()*math.Max(0, bottom-top)
+		if g.passable(x, y, scale) {
+			return y, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	featherPoints      = 20
+	featherLength      = 20
+	featherSwayPeriod  = 1.5
+	featherSwayAngle   = 0.5
+	featherSpawnMargin = 40
+	// pixels per second feathers drift down
+	featherFallSpeed = 15
+)
+
+var featherColor = color.RGBA{0xff, 0xff, 0xff, 0xe0}
+
+// Feather drifts down the course and restores some of the flap power when
+// collected.
+type Feather struct {
+	x, y  float64
+	phase float64
+}
+
+// flapPower is the power of a flap at the birdman's distance, with the
+// recovered fraction of what was lost since the start added back, and what
+// the upgrade keeps.
+func (g *Game) flapPower() float64 {
+	base := g.config.FlapPower(g.birdman.x)
+	full := g.config.FullFlapPower()
+	return base + (full-base)*math.Min(1, g.birdman.flapRecovery+g.flapUpgradeRecovery())
+}
+
+func (g *Game) recoverFlapPower(fraction float64) {
+	b := g.birdman
+	b.flapRecovery = math.Min(1, b.flapRecovery+fraction)
+}
+
+// updateFlapRecovery restores flap power for every glide of the config's
+// length without a flap, which flapped is whether the birdman just flapped.
+// Flapping uses some of the recovered power up again.
+func (g *Game) updateFlapRecovery(flapped bool) {
+	b := g.birdman
+	if flapped {
+		b.glideTime = 0
+		b.flapRecovery = math.Max(0, b.flapRecovery-g.config.FlapRecoveryCost)
+		return
+	}
+	b.glideTime += simulationStep
+	if b.glideTime >= g.config.GlideRecoveryTime {
+		b.glideTime = 0
+		if b.flapRecovery < 1 && g.config.FlapPower(b.x) < g.config.FullFlapPower() {
+			g.recoverFlapPower(g.config.GlideRecovery)
+			g.popups.Spawn("GLIDE: FLAP UP", b.x, b.y-birdmanHeight/2, popupPointsColor)
+		}
+	}
+}
+
+func (g *Game) spawnFeather() {
+	y := g.config.AltitudeZoneHeight + g.rand.Float64()*(g.config.FishSkimY-g.config.AltitudeZoneHeight)
+	g.feathers = append(g.feathers, Feather{
+		x:     g.birdman.x + screenWidth + featherSpawnMargin,
+		y:     y,
+		phase: g.rand.Float64(),
+	})
+}
+
+func (g *Game) updateFeathers() {
+	birdman := g.birdman
+	if !g.inSpace() && birdman.x >= g.config.FeatherStartX && birdman.x >= g.nextFeatherX {
+		g.nextFeatherX = birdman.x + g.config.FeatherInterval
+		g.spawnFeather()
+	}
+
+	n := 0
+	for i := range g.feathers {
+		f := &g.feathers[i]
+		f.y += featherFallSpeed * simulationStep
+		if birdman.state == StateFlying && Collides(birdman.hitbox(), birdman.x, birdman.y, pickupHitbox, f.x, f.y) {
+			g.stats.items++
+			g.recoverFlapPower(g.config.FeatherRecovery)
+			g.award("FEATHER", featherPoints)
+			g.sfx.PlaySE(ringAudioData)
+			continue
+		}
+		if f.x+featherLength > g.camera.ViewX() && f.y < screenHeight {
+			g.feathers[n] = *f
+			n++
+		}
+	}
+	g.feathers = g.feathers[:n]
+}
+
+// drawFeathers draws the feathers as quills swaying as they fall.
+func (g *Game) drawFeathers(screen *ebiten.Image) {
+	for i := range g.feathers {
+		f := &g.feathers[i]
+		a := math.Pi/4 + featherSwayAngle*math.Sin(2*math.Pi*(g.tricks.time/featherSwayPeriod+f.phase))
+		dx, dy := math.Cos(a)*featherLength/2, math.Sin(a)*featherLength/2
+		cx, cy := f.x-g.camera.ViewX(), f.y-g.camera.ViewY()
+		ebitenutil.DrawLine(screen, cx-dx, cy-dy, cx+dx, cy+dy, featherColor)
+		// The vane on both sides of the quill
+		for j := -2; j <= 2; j++ {
+			t := float64(j) / 3
+			px, py := cx+dx*t, cy+dy*t
+			ebitenutil.DrawLine(screen, px, py, px-dy*0.4, py+dx*0.4, featherColor)
+			ebitenutil.DrawLine(screen, px, py, px+dy*0.4, py-dx*0.4, featherColor)
+		}
+	}
+}
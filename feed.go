@@ -0,0 +1,170 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	maxBreadcrumbs     = 3
+	breadcrumbSize     = 10
+	breadcrumbMargin   = 40
+	crumbSize          = 4
+	crumbThrowSpeed    = 240
+	crumbThrowVy       = -120
+	crumbGravity       = 400
+	birdFeedingTime    = 0.4
+	birdLeavingSpeed   = 150
+	birdEscortFollow   = 3.0
+	birdEscortLeadGap  = 60
+	breadcrumbBobSpeed = 3
+)
+
+var breadcrumbColor = color.RGBA{0xd8, 0xa8, 0x60, 0xff}
+
+// Breadcrumb is a pickup which adds a breadcrumb to throw.
+type Breadcrumb struct {
+	x, y float64
+}
+
+// Crumb is a thrown breadcrumb, falling in an arc until it hits a bird or
+// leaves the screen.
+type Crumb struct {
+	x, y, vx, vy float64
+}
+
+// birdEscortLead is how far ahead of the birdman an escorting bird flies,
+// with its wake trailing back over him.
+func birdEscortLead() float64 {
+	return birdWidth/2 + birdEscortLeadGap
+}
+
+func (g *Game) spawnBreadcrumb() {
+	y := g.config.AltitudeZoneHeight + g.rand.Float64()*(g.config.FishSkimY-g.config.AltitudeZoneHeight)
+	g.breadcrumbs = append(g.breadcrumbs, Breadcrumb{x: g.birdman.x + screenWidth + breadcrumbMargin, y: y})
+}
+
+// updateBreadcrumbs spawns and collects the breadcrumbs, throws one when
+// asked to, and moves the thrown ones, pacifying the birds they hit.
+func (g *Game) updateBreadcrumbs() {
+	birdman := g.birdman
+	if !g.inSpace() && birdman.x >= g.config.BreadcrumbStartX && birdman.x >= g.nextBreadcrumbX {
+		g.nextBreadcrumbX = birdman.x + g.config.BreadcrumbInterval
+		g.spawnBreadcrumb()
+	}
+
+	n := 0
+	for i := range g.breadcrumbs {
+		b := &g.breadcrumbs[i]
+		if g.carriedCrumbs < maxBreadcrumbs && Collides(birdman.hitbox(), birdman.x, birdman.y, pickupHitbox, b.x, b.y) {
+			g.carriedCrumbs++
+			g.stats.items++
+			g.popups.Spawn("BREADCRUMB", b.x, b.y-breadcrumbSize, popupPointsColor)
+			g.sfx.PlaySE(popAudioData)
+			continue
+		}
+		if b.x+breadcrumbSize > g.camera.ViewX() {
+			g.breadcrumbs[n] = *b
+			n++
+		}
+	}
+	g.breadcrumbs = g.breadcrumbs[:n]
+
+	if g.controller.ConsumeThrow() && g.carriedCrumbs > 0 {
+		g.carriedCrumbs--
+		g.crumbs = append(g.crumbs, Crumb{
+			x:  birdman.x + birdmanWidth/3,
+			y:  birdman.y,
+			vx: birdman.vx + crumbThrowSpeed,
+			vy: crumbThrowVy,
+		})
+		g.sfx.PlaySE(whooshAudioData)
+	}
+
+	n = 0
+	for i := range g.crumbs {
+		c := &g.crumbs[i]
+		c.vy += crumbGravity * simulationStep
+		c.x += c.vx * simulationStep
+		c.y += c.vy * simulationStep
+		if j := g.findFedBird(c); j >= 0 {
+			g.pacifyBird(j)
+			continue
+		}
+		if c.y < screenHeight && c.x < g.camera.ViewX()+screenWidth+breadcrumbMargin {
+			g.crumbs[n] = *c
+			n++
+		}
+	}
+	g.crumbs = g.crumbs[:n]
+}
+
+func (g *Game) findFedBird(c *Crumb) int {
+	for i := range g.birds {
+		b := &g.birds[i]
+		if b.isHazard() && Collides(pickupHitbox, c.x, c.y, b.hitbox(), b.x, b.y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pacifyBird turns a bird from a hazard into an escort. It leaves its flock
+// and the other birds carry on.
+func (g *Game) pacifyBird(i int) {
+	b := &g.birds[i]
+	b.state = BirdFeeding
+	b.stateTime = 0
+	b.flock = 0
+	// It's not passed by the birdman, so no near miss either
+	b.passed = true
+	g.stats.birdsFed++
+	g.popups.Spawn("FED!", b.x, b.y-birdHeight, popupPointsColor)
+	g.sfx.PlaySE(chirpAudioData)
+}
+
+// movePacifiedBird moves a bird through its states once fed: it catches the
+// crumb, flies ahead of the birdman for the escort time, then leaves.
+func (g *Game) movePacifiedBird(b *Bird) {
+	birdman := g.birdman
+	b.stateTime += simulationStep
+	switch b.state {
+	case BirdFeeding:
+		b.x += birdman.vx * 0.5 * simulationStep
+		if b.stateTime >= birdFeedingTime {
+			b.state = BirdEscorting
+			b.stateTime = 0
+		}
+	case BirdEscorting:
+		t := math.Min(1, birdEscortFollow*simulationStep)
+		b.x += (birdman.x + birdEscortLead() - b.x) * t
+		b.y += (birdman.y - b.y) * t
+		if b.stateTime >= g.config.EscortTime {
+			b.state = BirdLeaving
+			b.stateTime = 0
+		}
+	case BirdLeaving:
+		b.x -= g.birdSpeed() * simulationStep
+		b.y -= birdLeavingSpeed * simulationStep
+	case BirdTumbling:
+		b.vy += birdTumbleGravity * simulationStep
+		b.x += b.vx * simulationStep
+		b.y += b.vy * simulationStep
+	}
+}
+
+func (g *Game) drawBreadcrumbs(screen *ebiten.Image) {
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	for i := range g.breadcrumbs {
+		b := &g.breadcrumbs[i]
+		bob := 2 * math.Sin(g.tricks.time*breadcrumbBobSpeed+b.x)
+		ebitenutil.DrawRect(screen, b.x-breadcrumbSize/2-viewX, b.y-breadcrumbSize/2+bob-viewY, breadcrumbSize, breadcrumbSize*0.7, breadcrumbColor)
+	}
+	for i := range g.crumbs {
+		c := &g.crumbs[i]
+		ebitenutil.DrawRect(screen, c.x-crumbSize/2-viewX, c.y-crumbSize/2-viewY, crumbSize, crumbSize, breadcrumbColor)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	fishMaxCount      = 3
+	fishSplashDrops   = 8
+	fishSplashTime    = 0.6
+	fishSeaSurfaceGap = 24
+	fishAimSpread     = 120
+)
+
+var (
+	fishColor   = color.RGBA{0x70, 0x90, 0xb0, 0xff}
+	splashColor = color.RGBA{0xe0, 0xf4, 0xff, 0xff}
+
+	fishImg *ebiten.Image
+)
+
+// Fish leaps out of the sea in front of the birdman when he skims the water.
+type Fish struct {
+	x, y   float64
+	vx, vy float64
+	struck bool
+}
+
+// Splash is the spray thrown up where a fish leaves or enters the water.
+type Splash struct {
+	x    float64
+	time float64
+}
+
+func newFishImage() *ebiten.Image {
+	const w, h = 40, 16
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// An oval body with the tail fin on the right
+			dx, dy := (float64(x)-14)/14, (float64(y)-h/2)/(h/2-1)
+			tail := x >= 26 && math.Abs(float64(y)-h/2) < float64(x-24)*0.6
+			if dx*dx+dy*dy < 1 || tail {
+				img.Set(x, y, fishColor)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// seaSurfaceY is where the fish break the surface on screen.
+func seaSurfaceY() float64 {
+	return screenHeight - fishSeaSurfaceGap
+}
+
+// updateFish moves the fish and splashes, and, while the birdman flies low,
+// makes fish leap more and more often the longer he stays there.
+func (g *Game) updateFish() {
+	birdman := g.birdman
+	if birdman.state == StateFlying && birdman.y > g.config.FishSkimY && !g.inSpace() {
+		birdman.skimTime += simulationStep
+	} else {
+		birdman.skimTime = 0
+	}
+	if birdman.skimTime > 0 && len(g.fish) < fishMaxCount && g.rand.Float64() < g.fishSpawnRate()*birdman.skimTime*simulationStep {
+		g.spawnFish()
+	}
+
+	n := 0
+	for i := range g.fish {
+		f := &g.fish[i]
+		wasAbove := f.y < seaSurfaceY()
+		f.vy += g.config.FishGravity * simulationStep
+		f.x += f.vx * simulationStep
+		f.y += f.vy * simulationStep
+		if wasAbove && f.y >= seaSurfaceY() {
+			g.splashes = append(g.splashes, Splash{x: f.x})
+		}
+		if f.vy < 0 || f.y < screenHeight+fishSeaSurfaceGap {
+			g.fish[n] = *f
+			n++
+		}
+	}
+	g.fish = g.fish[:n]
+
+	n = 0
+	for i := range g.splashes {
+		g.splashes[i].time += simulationStep
+		if g.splashes[i].time < fishSplashTime {
+			g.splashes[n] = g.splashes[i]
+			n++
+		}
+	}
+	g.splashes = g.splashes[:n]
+}
+
+// spawnFish makes a fish leap somewhere ahead of the birdman so that it
+// tops out around his altitude roughly as he gets there.
+func (g *Game) spawnFish() {
+	birdman := g.birdman
+	height := screenHeight - birdman.y + g.rand.Float64()*60
+	vy := -math.Sqrt(2 * g.config.FishGravity * height)
+	rise := -vy / g.config.FishGravity
+	f := Fish{
+		x:  birdman.x + (birdman.vx+g.config.FishSpeed)*rise + (g.rand.Float64()-0.5)*fishAimSpread,
+		y:  seaSurfaceY(),
+		vx: -g.config.FishSpeed,
+		vy: vy,
+	}
+	g.fish = append(g.fish, f)
+	g.splashes = append(g.splashes, Splash{x: f.x})
+	g.sfx.PlaySE(whooshAudioData)
+}
+
+// collidesFish reports whether any fish hits the birdman. A fish hits only
+// once, so it does not hit him again as he recovers in its way.
+func (g *Game) collidesFish() bool {
+	birdman := g.birdman
+	for i := range g.fish {
+		f := &g.fish[i]
+		if !f.struck && Collides(birdman.hitbox(), birdman.x, birdman.y, fishHitbox, f.x, f.y) {
+			f.struck = true
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fish) Draw(screen *ebiten.Image, game *Game) {
+	w, h := fishImg.Size()
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+	// Nose first along the arc
+	opt.GeoM.Rotate(math.Atan2(f.vy, f.vx) - math.Pi)
+	opt.GeoM.Translate(f.x-game.camera.ViewX(), f.y-game.camera.ViewY())
+	screen.DrawImage(fishImg, opt)
+}
+
+func (g *Game) drawFish(screen *ebiten.Image) {
+	for i := range g.fish {
+		g.fish[i].Draw(screen, g)
+	}
+
+	for _, s := range g.splashes {
+		t := s.time / fishSplashTime
+		clr := splashColor
+		clr.A = uint8(0xff * (1 - t))
+		for i := 0; i < fishSplashDrops; i++ {
+			// Drops fan out and fall back in a parabola
+			a := math.Pi * (float64(i) + 0.5) / fishSplashDrops
+			dx := math.Cos(a) * 40 * t
+			dy := -math.Sin(a)*120*s.time + 200*s.time*s.time
+			x := s.x + dx - g.camera.ViewX()
+			y := seaSurfaceY() + dy - g.camera.ViewY()
+			ebitenutil.DrawRect(screen, x-2, y-2, 4, 4, clr)
+		}
+	}
+}
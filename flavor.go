@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// in meters
+	cheerDistance = 500
+	// pixels per second
+	commentarySpeed     = 160
+	commentaryY         = screenHeight - 64
+	commentaryQueueSize = 3
+	// in pixels
+	boatInterval    = 1800
+	boatJitter      = 500
+	boatWaveRange   = 200
+	boatWidth       = 70
+	boatSpectators  = 3
+	boatWavePeriod  = 0.4
+	boatBobPeriod   = 1.8
+	boatSurfaceDrop = 20
+)
+
+var (
+	commentaryColor     = color.RGBA{0x00, 0x00, 0x00, 0x80}
+	boatHullColor       = color.RGBA{0xf0, 0xf0, 0xe8, 0xff}
+	boatStripeColor     = color.RGBA{0x30, 0x60, 0xb0, 0xff}
+	boatSpectatorColors = []color.RGBA{
+		{0xe0, 0x50, 0x40, 0xff},
+		{0xf0, 0xc0, 0x30, 0xff},
+		{0x40, 0xa0, 0x60, 0xff},
+	}
+)
+
+var milestoneCommentary = []string{
+	"%sm AND STILL FLYING!",
+	"WHAT A FLIGHT, %sm!",
+	"THE CROWD IS ON ITS FEET AT %sm!",
+	"%sm! CAN ANYONE STOP HIM?",
+}
+
+var cheerAudioData []byte
+
+// Commentary scrolls lines across the bottom of the screen one after
+// another, like a ticker.
+type Commentary struct {
+	queue []string
+	text  string
+	x     float64
+}
+
+func (c *Commentary) Reset() {
+	c.queue = c.queue[:0]
+	c.text = ""
+}
+
+// Say queues a line, dropping it if too many are waiting.
+func (c *Commentary) Say(s string) {
+	if len(c.queue) < commentaryQueueSize {
+		c.queue = append(c.queue, s)
+	}
+}
+
+func (c *Commentary) Update(dt float64) {
+	if c.text == "" {
+		if len(c.queue) == 0 {
+			return
+		}
+		c.text = c.queue[0]
+		c.queue = append(c.queue[:0], c.queue[1:]...)
+		c.x = screenWidth
+	}
+	c.x -= commentarySpeed * dt
+	if c.x < -float64(len(c.text)*smallFontSize) {
+		c.text = ""
+	}
+}
+
+func (c *Commentary) Draw(screen *ebiten.Image) {
+	if c.text == "" {
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, commentaryY-smallFontSize*3/2, screenWidth, smallFontSize*2, commentaryColor)
+	text.Draw(screen, c.text, smallFont, int(c.x), commentaryY, color.White)
+}
+
+// updateFlavor cheers at the milestones and comments on the run, unless
+// turned off in the settings.
+func (g *Game) updateFlavor() {
+	if !g.settings.Flavor {
+		return
+	}
+	g.commentary.Update(simulationStep)
+
+	d := int(g.birdman.x) / 10
+	if d >= g.nextCheer {
+		// Skip those passed at once, e.g. by warping
+		n := d / cheerDistance
+		g.nextCheer = (n + 1) * cheerDistance
+		line := milestoneCommentary[(n-1)%len(milestoneCommentary)]
+		g.commentary.Say(fmt.Sprintf(line, formatIntComma(n*cheerDistance)))
+		g.sfx.PlaySE(cheerAudioData)
+	}
+	if !g.passedBest && g.stats.recorded() && g.records.BestDistance > 0 && d > g.records.BestDistance {
+		g.passedBest = true
+		g.commentary.Say("HE'S PAST THE OLD RECORD!")
+		g.sfx.PlaySE(cheerAudioData)
+	}
+}
+
+// drawBoats draws the spectator boats out in the sea, whose crowds wave
+// while the birdman passes over them. They are placed by the distance, so
+// there is nothing to keep of them.
+func (g *Game) drawBoats(screen *ebiten.Image) {
+	if !g.settings.Flavor || g.inSpace() || g.backdrop == nil {
+		return
+	}
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	// The first boat is off the launch
+	first := int(math.Max(1, math.Floor((viewX-boatJitter-boatWidth)/boatInterval)))
+	for i := first; float64(i*boatInterval) < viewX+screenWidth; i++ {
+		// Somewhat irregular, but the same every run
+		bx := float64(i*boatInterval) + float64(i*379%boatJitter)
+		x := bx - viewX
+		if x < -boatWidth || x > screenWidth {
+			continue
+		}
+		t := g.splits.time
+		y := float64(screenHeight-g.backdrop.seaHeight+boatSurfaceDrop) - viewY + 2*math.Sin(2*math.Pi*t/boatBobPeriod+float64(i))
+		waving := math.Abs(g.birdman.x-bx) < boatWaveRange
+
+		for j := 0; j < boatSpectators; j++ {
+			sx := x + 14 + float64(j)*18
+			clr := boatSpectatorColors[(i+j)%len(boatSpectatorColors)]
+			ebitenutil.DrawRect(screen, sx, y-14, 6, 10, clr)
+			ebitenutil.DrawRect(screen, sx+1, y-19, 4, 4, clr)
+			armY := y - 12.0
+			if waving && int((t+float64(j)*0.13)/(boatWavePeriod/2))%2 == 0 {
+				armY = y - 24
+			} else if waving {
+				armY = y - 20
+			}
+			ebitenutil.DrawLine(screen, sx+6, y-12, sx+10, armY, clr)
+		}
+		ebitenutil.DrawRect(screen, x, y-4, boatWidth, 12, boatHullColor)
+		ebitenutil.DrawRect(screen, x, y+2, boatWidth, 3, boatStripeColor)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+)
+
+type Formation int
+
+const (
+	FormationV Formation = iota
+	FormationLine
+)
+
+const (
+	flockSpawnMargin   = 100
+	flockVSpacingX     = 45
+	flockVSpacingY     = 40
+	flockLineSpacing   = 75
+	flockLineGap       = 170
+	flockSwayAmplitude = 20
+	flockSwayPeriod    = 3.0
+)
+
+// Flock moves a group of birds in formation as a unit. The members are
+// ordinary birds in Game.birds referring to the flock by id, so collisions
+// and the other bird interactions need no special casing.
+type Flock struct {
+	id        int
+	formation Formation
+	x, y      float64
+	baseY     float64
+	time      float64
+	members   int
+}
+
+// spawnFlock places a flock of 4 to 6 birds just off the right edge of the
+// screen. A V is led by its apex; a line is a column with a gap for the
+// birdman to fly through.
+func (g *Game) spawnFlock() {
+	g.nextFlockID++
+	size := g.config.FlockMinSize + g.rand.Intn(g.config.FlockMaxSize-g.config.FlockMinSize+1)
+	f := Flock{
+		id:        g.nextFlockID,
+		formation: Formation(g.rand.Intn(2)),
+		x:         g.birdman.x + screenWidth + flockSpawnMargin,
+	}
+
+	var offsets [][2]float64
+	switch f.formation {
+	case FormationV:
+		offsets = append(offsets, [2]float64{0, 0})
+		for k := 1; len(offsets) < size; k++ {
+			offsets = append(offsets, [2]float64{float64(k) * flockVSpacingX, -float64(k) * flockVSpacingY})
+			if len(offsets) < size {
+				offsets = append(offsets, [2]float64{float64(k) * flockVSpacingX, float64(k) * flockVSpacingY})
+			}
+		}
+		maxY := float64(size/2) * flockVSpacingY
+		f.baseY = 50 + maxY + g.rand.Float64()*(screenHeight-100-2*maxY)
+	case FormationLine:
+		// The flock's position is the middle of the gap, and the column may
+		// reach off the screen
+		above := 1 + g.rand.Intn(size-1)
+		for k := 0; k < size; k++ {
+			if k < above {
+				offsets = append(offsets, [2]float64{0, -flockLineGap/2 - float64(k)*flockLineSpacing})
+			} else {
+				offsets = append(offsets, [2]float64{0, flockLineGap/2 + float64(k-above)*flockLineSpacing})
+			}
+		}
+		f.baseY = flockLineGap + g.rand.Float64()*(screenHeight-2*flockLineGap)
+	}
+	f.y = f.baseY
+
+	for _, o := range offsets {
+		b := Bird{
+			frames:  birdFrames,
+			x:       f.x + o[0],
+			y:       f.y + o[1],
+			flock:   f.id,
+			offsetX: o[0],
+			offsetY: o[1],
+			scale:   g.birdScale,
+		}
+		b.animation.Play(birdFlyingAnimation)
+		g.birds = append(g.birds, b)
+		f.members++
+	}
+	g.flocks = append(g.flocks, f)
+}
+
+func (g *Game) findFlock(id int) *Flock {
+	for i := range g.flocks {
+		if g.flocks[i].id == id {
+			return &g.flocks[i]
+		}
+	}
+	return nil
+}
+
+// moveBirds advances the flocks and every bird, members following their
+// flock's position.
+func (g *Game) moveBirds() {
+	for i := range g.flocks {
+		f := &g.flocks[i]
+		f.time += simulationStep
+		f.x -= g.birdSpeed() * simulationStep
+		if f.formation == FormationV {
+			f.y = f.baseY + flockSwayAmplitude*math.Sin(2*math.Pi*f.time/flockSwayPeriod)
+		}
+	}
+
+	for i := range g.birds {
+		b := &g.birds[i]
+		if b.state != BirdWild {
+			g.movePacifiedBird(b)
+		} else if f := g.findFlock(b.flock); f != nil {
+			b.x, b.y = f.x+b.offsetX, f.y+b.offsetY
+		} else {
+			b.x -= g.birdSpeed() * simulationStep
+		}
+		b.updateSound(g)
+	}
+}
+
+// removeEmptyFlocks drops the flocks whose members have all left the screen.
+func (g *Game) removeEmptyFlocks() {
+	for i := range g.flocks {
+		g.flocks[i].members = 0
+	}
+	for i := range g.birds {
+		if f := g.findFlock(g.birds[i].flock); f != nil {
+			f.members++
+		}
+	}
+	n := 0
+	for i := range g.flocks {
+		if g.flocks[i].members > 0 {
+			g.flocks[n] = g.flocks[i]
+			n++
+		}
+	}
+	g.flocks = g.flocks[:n]
+}
+
+// strikeBird knocks the bird at index i down in a puff of feathers, and
+// marks the rest of its flock as having hit the birdman too, as they would
+// otherwise keep knocking him back flying on in formation.
+func (g *Game) strikeBird(i int) {
+	b := &g.birds[i]
+	b.struck = true
+	b.knockDown(g.birdman.vx)
+	g.featherPuffs = append(g.featherPuffs, FeatherPuff{x: b.x, y: b.y})
+	if g.birds[i].flock == 0 {
+		return
+	}
+	for j := range g.birds {
+		if g.birds[j].flock == g.birds[i].flock {
+			g.birds[j].struck = true
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	focusTimeScale = 0.5
+	// in seconds of real time, which runs faster than the simulation's
+	// while focused
+	focusDuration = 2
+	focusFadeTime = 0.25
+	// seconds for the meter to refill after focusing
+	focusCooldown = 12
+	// how much of the color is left while focused
+	focusColorLeft = 0.25
+)
+
+var (
+	focusFillColor  = color.RGBA{0x60, 0xc0, 0xf0, 0xff}
+	focusReadyColor = color.RGBA{0xa0, 0xf0, 0xff, 0xff}
+)
+
+// updateFocus slows the simulation down for a while when asked to with the
+// focus meter full, and refills the meter after that.
+func (g *Game) updateFocus() {
+	asked := g.controller.ConsumeFocus()
+	if g.focusTime > 0 {
+		g.focusTime = math.Max(0, g.focusTime-simulationStep/focusTimeScale)
+		g.focusMeter = g.focusTime / focusDuration
+		if g.focusTime == 0 {
+			g.timeScale = 1
+		}
+		return
+	}
+	g.focusMeter = math.Min(1, g.focusMeter+simulationStep/focusCooldown)
+	if asked && g.focusMeter >= 1 {
+		g.focusTime = focusDuration
+		g.timeScale = focusTimeScale
+		g.sfx.PlaySE(focusAudioData)
+	}
+}
+
+// focusSaturation is the saturation of the screen, draining while focused.
+func (g *Game) focusSaturation() float64 {
+	if g.focusTime <= 0 {
+		return 1
+	}
+	fade := math.Min(1, math.Min(g.focusTime, focusDuration-g.focusTime)/focusFadeTime)
+	return 1 - (1-focusColorLeft)*fade
+}
+
+func (g *Game) focusMeterColor() color.Color {
+	if g.focusTime <= 0 && g.focusMeter >= 1 {
+		return focusReadyColor
+	}
+	return focusFillColor
+}
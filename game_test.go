@@ -0,0 +1,2188 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type testController struct {
+	flap  bool
+	dive  bool
+	roll  bool
+	throw bool
+	focus bool
+}
+
+func (c *testController) ConsumeFlap() (ok, strong bool) {
+	ok = c.flap
+	c.flap = false
+	return ok, false
+}
+
+func (c *testController) IsDivePressed() bool {
+	return c.dive
+}
+
+func (c *testController) ConsumeRoll() bool {
+	ok := c.roll
+	c.roll = false
+	return ok
+}
+
+func (c *testController) ConsumeThrow() bool {
+	ok := c.throw
+	c.throw = false
+	return ok
+}
+
+func (c *testController) ConsumeFocus() bool {
+	ok := c.focus
+	c.focus = false
+	return ok
+}
+
+type testAudio struct {
+	played []interface{}
+}
+
+func (a *testAudio) PlaySE(data []byte) {
+	a.played = append(a.played, data)
+}
+
+func (a *testAudio) PlaySound(s *Sound) {
+	a.played = append(a.played, s)
+}
+
+func (a *testAudio) PlayPanned(data []byte, pan float64) *PannedSound {
+	return &PannedSound{data: data, pan: pan}
+}
+
+type testLogger struct {
+	actions  []string
+	payloads []map[string]interface{}
+}
+
+func (l *testLogger) LogAsync(payload map[string]interface{}) {
+	l.actions = append(l.actions, payload["action"].(string))
+	l.payloads = append(l.payloads, payload)
+}
+
+func (l *testLogger) Save() {}
+
+type testGame struct {
+	*Game
+	controller *testController
+	audio      *testAudio
+	logger     *testLogger
+}
+
+func newTestGame(t *testing.T) *testGame {
+	t.Helper()
+	tg := &testGame{
+		controller: &testController{},
+		audio:      &testAudio{},
+		logger:     &testLogger{},
+	}
+	tg.Game = NewGame(loadTestConfig(t), NewSettings(), rand.NewSource(1), tg.audio, tg.logger)
+	tg.Game.controller = tg.controller
+	// The tests play the cutscenes themselves
+	tg.settings.Cutscenes = false
+	tg.initialize()
+	tg.startGame()
+	return tg
+}
+
+// fly puts the birdman in the air at x in the middle of the screen.
+func (g *testGame) fly(x float64) {
+	g.birdman.state = StateFlying
+	g.birdman.x = x
+	g.birdman.y = screenHeight / 2
+	g.birdman.vx = g.config.BirdmanSpeed
+	g.birdman.vy = 0
+	g.camera.Reset(x-cameraOffsetX, 0)
+	g.nextBirdX = x
+}
+
+func TestRunningToFlying(t *testing.T) {
+	g := newTestGame(t)
+
+	steps := 0
+	for g.birdman.state == StateRunning {
+		g.simulate()
+		steps++
+		if steps > 10*simulationRate {
+			t.Fatal("birdman never left the cliff")
+		}
+	}
+	if g.birdman.state != StateFlying {
+		t.Errorf("state = %v, want flying", g.birdman.state)
+	}
+	if g.birdman.x < 0 {
+		t.Errorf("birdman took off at x=%v", g.birdman.x)
+	}
+}
+
+func TestFlapDecaysWithDamage(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+	g.config.Drag = 0
+
+	g.fly(0)
+	g.birds = nil
+	g.controller.flap = true
+	g.simulate()
+	undamaged := g.birdman.vy
+
+	if undamaged >= 0 {
+		t.Fatalf("flap didn't push the birdman up: vy=%v", undamaged)
+	}
+	if len(g.audio.played) == 0 {
+		t.Error("flap played no sound")
+	}
+
+	g.fly(0)
+	g.birds = nil
+	g.birdman.damagedCount = 1
+	g.controller.flap = true
+	g.simulate()
+	if want := undamaged / 2; g.birdman.vy != want {
+		t.Errorf("vy after flap with one damage = %v, want %v", g.birdman.vy, want)
+	}
+}
+
+func TestBirdSpawnCadence(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+
+	g.fly(0)
+	spawned := 0
+	for g.birdman.x < 5*g.config.BirdSpawnInterval {
+		g.simulate()
+		if len(g.birds)+len(g.airplanes) > 0 {
+			spawned++
+		}
+		for _, b := range g.birds {
+			// A line of a flock may reach off the screen
+			if b.flock == 0 && (b.y < 50 || b.y >= screenHeight-50) {
+				t.Errorf("bird spawned at y=%v", b.y)
+			}
+			if b.x < g.birdman.x+screenWidth-g.config.BirdmanSpeed {
+				t.Errorf("bird spawned on screen at x=%v", b.x)
+			}
+		}
+		// Clear the hazards so that they can't hit the birdman
+		g.birds = nil
+		g.airplanes = nil
+	}
+	if spawned != 5 {
+		t.Errorf("spawned %d hazards, want 5", spawned)
+	}
+}
+
+// wallAfterBounce sets a run up bouncing off the ceiling towards a wall of
+// birds with a gap, and spawns more birds into the wall. The wall is as many
+// seconds away as a screen is at the bird speed of the start, so that the
+// birds faster further on are checked for a way through in the same time.
+func wallAfterBounce(t *testing.T, seed int64) *testGame {
+	g := newTestGame(t)
+	g.config.FishSpawnRate = 0
+	g.fly(3000 * 10)
+	g.nextBirdX, g.nextFlockX, g.nextBalloonX = 1e9, 1e9, 1e9
+	g.rand.Seed(seed)
+	g.birdman.y = g.config.AltitudeZoneHeight / 2
+	g.birdman.vy = g.config.MaxFallSpeed
+	g.birdman.flapRecovery = 1
+
+	b := g.birdman
+	x := b.x + screenWidth*(b.vx+g.birdSpeed())/(b.vx+g.config.BirdSpeed)
+	gapY := 150 + g.rand.Float64()*200
+	for y := gapY - 80; y > 0; y -= 90 {
+		g.birds = append(g.birds, Bird{frames: birdFrames, x: x, y: y})
+	}
+	for y := gapY + 80; y < screenHeight; y += 90 {
+		g.birds = append(g.birds, Bird{frames: birdFrames, x: x, y: y})
+	}
+	for i := 0; i < 5; i++ {
+		g.spawnHazard()
+	}
+	g.airplanes = nil
+	return g
+}
+
+// flyThrough flaps to keep the birdman around targetY, and reports whether
+// he gets past all the birds unharmed.
+func flyThrough(g *testGame, targetY float64) bool {
+	lastFlap := math.Inf(-1)
+	for i := 0; i < simulationRate*10; i++ {
+		ahead := false
+		for _, b := range g.birds {
+			ahead = ahead || b.x+birdWidth > g.birdman.x
+		}
+		if !ahead {
+			return true
+		}
+		now := float64(i) * simulationStep
+		if g.birdman.y > targetY && g.birdman.vy > 0 && now-lastFlap >= reachFlapInterval {
+			g.controller.flap = true
+			lastFlap = now
+		}
+		g.simulate()
+		if g.birdman.state != StateFlying || g.mode != ModeGame {
+			return false
+		}
+	}
+	return false
+}
+
+func TestSpawnFairness(t *testing.T) {
+	for seed := int64(1); seed <= 8; seed++ {
+		// Keep to heights a bit under the middles between the birds, where
+		// the flaps lift him up to
+		var ys []float64
+		for _, b := range wallAfterBounce(t, seed).birds {
+			ys = append(ys, b.y)
+		}
+		sort.Float64s(ys)
+		passed := false
+		for i := 1; i < len(ys) && !passed; i++ {
+			for d := 0.0; d <= 90 && !passed; d += 15 {
+				passed = flyThrough(wallAfterBounce(t, seed), (ys[i-1]+ys[i])/2+d)
+			}
+		}
+		if !passed {
+			g := wallAfterBounce(t, seed)
+			var ys []float64
+			for _, b := range g.birds {
+				ys = append(ys, math.Round(b.y))
+			}
+			t.Errorf("seed %d: no way through the birds at %v", seed, ys)
+		}
+	}
+}
+
+func TestBandHazards(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(0)
+	for i := 0; i < 200; i++ {
+		g.spawnHazard()
+	}
+	for _, b := range g.birds {
+		if b.y < 50 || b.y > screenHeight-50 {
+			t.Errorf("bird spawned at y=%v", b.y)
+		}
+	}
+	if len(g.airplanes) == 0 {
+		t.Fatal("no airplanes spawned")
+	}
+	for _, a := range g.airplanes {
+		if band := g.config.BandAt(a.y); band.Name != "high" {
+			t.Errorf("airplane spawned at y=%v in the %s band", a.y, band.Name)
+		}
+	}
+
+	// Spray slows the birdman down
+	calm := newTestGame(t)
+	calm.fly(0)
+	calm.birdman.y = 250
+	g = newTestGame(t)
+	g.fly(0)
+	g.birdman.y = screenHeight - 60
+	if g.bandDrag() <= calm.bandDrag() {
+		t.Errorf("drag over the sea %v, want more than %v", g.bandDrag(), calm.bandDrag())
+	}
+}
+
+func TestBirdSpawnIsDeterministic(t *testing.T) {
+	spawnYs := func() []float64 {
+		g := newTestGame(t)
+		g.config.Gravity = 0
+		g.fly(0)
+		var ys []float64
+		for len(ys) < 10 {
+			g.simulate()
+			for _, b := range g.birds {
+				ys = append(ys, b.y)
+			}
+			g.birds = nil
+		}
+		return ys
+	}
+
+	a, b := spawnYs(), spawnYs()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("spawn %d differs with the same seed: %v != %v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestAltitudePressure(t *testing.T) {
+	g := newTestGame(t)
+
+	g.fly(0)
+	g.birds = nil
+	g.birdman.y = 0
+	g.birdman.vy = -1000
+	g.simulate()
+	if g.birdman.state != StateFlying {
+		t.Fatalf("touching the ceiling damaged instantly")
+	}
+	if g.birdman.y < 0 {
+		t.Errorf("birdman went through the ceiling: y=%v", g.birdman.y)
+	}
+
+	// Hold the birdman at the top of the zone
+	for i := 0; i < int(2*g.config.AltitudeDamageTime*simulationRate) && g.birdman.state == StateFlying; i++ {
+		g.birds = nil
+		g.birdman.y = 0
+		g.simulate()
+	}
+	if g.birdman.state != StateDamaged {
+		t.Errorf("state = %v, want damaged", g.birdman.state)
+	}
+	if g.birdman.damagedCount != 1 {
+		t.Errorf("damagedCount = %d, want 1", g.birdman.damagedCount)
+	}
+}
+
+func TestBirdCollisionDamage(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+
+	g.fly(1000)
+	g.nextBirdX = 1e9
+	g.birds = []Bird{{x: g.birdman.x + 10, y: g.birdman.y}}
+	g.simulate()
+
+	if g.birdman.state != StateDamaged {
+		t.Errorf("state = %v, want damaged", g.birdman.state)
+	}
+}
+
+func TestStruckBirdTumbles(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+	g.cheats.Invincible = true
+
+	g.fly(1000)
+	g.nextBirdX = 1e9
+	g.nextFlockX = 1e9
+	g.birds = []Bird{{x: g.birdman.x + 10, y: g.birdman.y}}
+	g.simulate()
+	b := &g.birds[0]
+	if b.state != BirdTumbling || b.isHazard() || len(g.featherPuffs) != 1 {
+		t.Fatalf("bird %v hazard %v with %d puffs after the hit, want tumbling", b.state, b.isHazard(), len(g.featherPuffs))
+	}
+	prevX := b.x
+	g.simulate()
+	if b.x <= prevX {
+		t.Errorf("bird moved from %v to %v, want knocked forward", prevX, b.x)
+	}
+
+	for i := 0; i < simulationRate*3 && len(g.birds) > 0; i++ {
+		g.simulate()
+	}
+	if len(g.birds) != 0 {
+		t.Errorf("bird at %v,%v still there, want fallen into the sea", g.birds[0].x, g.birds[0].y)
+	}
+	if len(g.featherPuffs) != 0 {
+		t.Errorf("%d feather puffs left", len(g.featherPuffs))
+	}
+}
+
+func TestDamagedRecovers(t *testing.T) {
+	g := newTestGame(t)
+
+	g.fly(0)
+	g.birdman.y = 10
+	g.damageBirdman(CauseBird)
+	for i := 0; i < int(g.config.DamagedDuration*simulationRate); i++ {
+		if g.birdman.state != StateDamaged {
+			t.Fatalf("recovered after %d steps", i)
+		}
+		g.simulate()
+	}
+	if g.birdman.state != StateFlying {
+		t.Errorf("state = %v, want flying", g.birdman.state)
+	}
+}
+
+func TestDamagedFlapsShortenRecovery(t *testing.T) {
+	g := newTestGame(t)
+
+	g.fly(0)
+	g.birdman.y = 10
+	g.damageBirdman(CauseBird)
+	if g.birdman.knockbackVx >= 0 || g.birdman.vy <= 0 {
+		t.Errorf("no knockback: vx=%v vy=%v", g.birdman.knockbackVx, g.birdman.vy)
+	}
+
+	steps := 0
+	for g.birdman.state == StateDamaged {
+		g.controller.flap = steps%10 == 0
+		g.simulate()
+		steps++
+	}
+	if float64(steps) >= g.config.DamagedDuration*simulationRate {
+		t.Errorf("recovered after %d steps despite flapping", steps)
+	}
+}
+
+func TestLoopTrick(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+
+	g.fly(0)
+	g.controller.roll = true
+	for i := 0; i < int(rollDuration*simulationRate)+1; i++ {
+		g.birds = nil
+		g.simulate()
+	}
+	if g.birdman.rolling {
+		t.Error("roll didn't finish")
+	}
+	if g.stylePoints != loopPoints {
+		t.Errorf("stylePoints = %d, want %d", g.stylePoints, loopPoints)
+	}
+}
+
+func TestThreadTrick(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+
+	g.fly(1000)
+	g.nextBirdX = 1e9
+	g.birds = []Bird{
+		{x: g.birdman.x + 5, y: g.birdman.y - 100},
+		{x: g.birdman.x + 6, y: g.birdman.y + 100},
+	}
+	for i := 0; i < simulationRate/4; i++ {
+		g.simulate()
+	}
+	if g.stylePoints != threadPoints {
+		t.Errorf("stylePoints = %d, want %d", g.stylePoints, threadPoints)
+	}
+}
+
+func TestSlipstreamBoost(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+	g.config.HeadwindStrength = 0
+
+	g.fly(1000)
+	g.nextBirdX = 1e9
+	g.birds = []Bird{{x: g.birdman.x - birdWidth, y: g.birdman.y + g.config.SlipstreamHeight - 5}}
+	for i := 0; i < int(g.config.SlipstreamTime*simulationRate)+1; i++ {
+		g.simulate()
+	}
+	if g.birdman.state != StateFlying {
+		t.Fatalf("state = %v, want flying", g.birdman.state)
+	}
+	if g.birdman.vx < g.config.BirdmanSpeed+g.config.SlipstreamBoost/2 {
+		t.Errorf("no boost: vx=%v", g.birdman.vx)
+	}
+}
+
+func TestFallIsGameOver(t *testing.T) {
+	g := newTestGame(t)
+
+	g.fly(0)
+	g.birds = nil
+	g.birdman.y = screenHeight - 1
+	g.birdman.vy = g.config.MaxFallSpeed
+	g.simulate()
+
+	if g.mode != ModeGameOver {
+		t.Fatalf("mode = %v, want game over", g.mode)
+	}
+	if last := strings.Join(g.logger.actions[len(g.logger.actions)-2:], ","); last != "game_over,run_summary" {
+		t.Errorf("last logged actions = %q, want game_over,run_summary", last)
+	}
+}
+
+func TestBotGetsFar(t *testing.T) {
+	// A single run hinges on a few unlucky spawns, so judge the median of
+	// several
+	const runs = 11
+	var records []int
+	for seed := int64(1); seed <= runs; seed++ {
+		g := newTestGame(t)
+		g.rand = rand.New(rand.NewSource(seed))
+		g.Game.controller = NewBot(g.Game)
+
+		for i := 0; i < 120*simulationRate && g.mode == ModeGame; i++ {
+			g.simulate()
+		}
+		records = append(records, int(g.birdman.x)/10)
+	}
+	sort.Ints(records)
+	if median := records[runs/2]; median < 300 {
+		t.Errorf("bot only got %dm in the median run (%v)", median, records)
+	}
+}
+
+func TestForwardSpeed(t *testing.T) {
+	g := newTestGame(t)
+	g.config.HeadwindStrength = 0
+
+	g.fly(0)
+	g.birdman.y = 100
+	g.controller.dive = true
+	for i := 0; i < simulationRate/2; i++ {
+		g.birds = nil
+		g.simulate()
+	}
+	if g.birdman.vx <= g.config.BirdmanSpeed {
+		t.Errorf("diving didn't gain speed: vx=%v", g.birdman.vx)
+	}
+
+	g.fly(0)
+	g.damageBirdman(CauseBird)
+	if g.birdman.vx >= g.config.BirdmanSpeed {
+		t.Errorf("damage didn't cost speed: vx=%v", g.birdman.vx)
+	}
+}
+
+func TestFlockMovesAsUnit(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(g.config.FlockStartX)
+	g.birds = g.birds[:0]
+	g.spawnFlock()
+	g.nextBirdX = math.Inf(1)
+	g.nextFlockX = math.Inf(1)
+
+	n := len(g.birds)
+	if n < g.config.FlockMinSize || n > g.config.FlockMaxSize {
+		t.Fatalf("flock of %d birds, want %d to %d", n, g.config.FlockMinSize, g.config.FlockMaxSize)
+	}
+	for i := 0; i < simulationRate; i++ {
+		g.birdman.y = screenHeight / 2
+		g.birdman.vy = 0
+		g.simulate()
+	}
+	f := g.findFlock(g.birds[0].flock)
+	if f == nil {
+		t.Fatal("flock is gone")
+	}
+	for _, b := range g.birds {
+		if b.x != f.x+b.offsetX || b.y != f.y+b.offsetY {
+			t.Errorf("bird at (%v, %v) left its slot in the formation", b.x, b.y)
+		}
+	}
+
+	g.birds = g.birds[:0]
+	g.removeEmptyFlocks()
+	if len(g.flocks) != 0 {
+		t.Errorf("%d flocks left after all the birds are gone", len(g.flocks))
+	}
+}
+
+func TestFishLeapAtSkimmers(t *testing.T) {
+	for _, low := range []bool{false, true} {
+		g := newTestGame(t)
+		g.fly(0)
+		y := float64(screenHeight / 2)
+		if low {
+			y = g.config.FishSkimY + 20
+		}
+		g.nextBirdX = math.Inf(1)
+		g.nextFlockX = math.Inf(1)
+
+		leapt := false
+		for i := 0; i < 4*simulationRate; i++ {
+			g.birdman.y = y
+			g.birdman.vy = 0
+			g.simulate()
+			leapt = leapt || len(g.fish) > 0
+		}
+		if leapt != low {
+			t.Errorf("fish leapt at y = %v: %v, want %v", y, leapt, low)
+		}
+	}
+}
+
+func TestBalloonRide(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(0)
+	g.nextBirdX = math.Inf(1)
+	g.nextFlockX = math.Inf(1)
+	g.nextBalloonX = math.Inf(1)
+	g.birdman.damagedCount = 1
+	g.balloons = append(g.balloons, Balloon{x: 0, y: 300, baseY: 300})
+
+	rode, popped := false, false
+	for i := 0; i < int(g.config.BalloonRideTime+1)*simulationRate && g.mode == ModeGame; i++ {
+		g.simulate()
+		rode = rode || g.birdman.riding
+		if rode && len(g.balloons) == 0 {
+			popped = true
+			break
+		}
+	}
+	if !rode {
+		t.Fatal("birdman did not land on the balloon")
+	}
+	if !popped {
+		t.Error("balloon did not pop")
+	}
+	if g.birdman.damagedCount != 0 {
+		t.Errorf("damagedCount = %d after resting, want 0", g.birdman.damagedCount)
+	}
+}
+
+func TestBalloonBasketDamage(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(0)
+	g.nextBirdX = math.Inf(1)
+	g.nextFlockX = math.Inf(1)
+	g.nextBalloonX = math.Inf(1)
+	g.balloons = append(g.balloons, Balloon{x: 0, y: g.birdman.y - balloonBasketY, baseY: g.birdman.y - balloonBasketY})
+	g.simulate()
+
+	if g.birdman.state != StateDamaged {
+		t.Errorf("state = %v after flying into a basket, want damaged", g.birdman.state)
+	}
+}
+
+func TestRingChain(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(0)
+	g.nextBirdX = math.Inf(1)
+	g.nextFlockX = math.Inf(1)
+	g.nextBalloonX = math.Inf(1)
+	g.nextRingX = math.Inf(1)
+	y := g.birdman.y
+	g.rings = append(g.rings, Ring{x: 20, y: y}, Ring{x: 40, y: y}, Ring{x: 60, y: y - 200})
+
+	for g.birdman.x < 60+birdmanWidth {
+		g.birdman.y = y
+		g.birdman.vy = 0
+		g.simulate()
+	}
+	if want := ringPoints + 2*ringPoints; g.stylePoints != want {
+		t.Errorf("stylePoints = %d after two rings in a row, want %d", g.stylePoints, want)
+	}
+	if g.ringChain != 0 {
+		t.Errorf("ringChain = %d after missing a ring, want 0", g.ringChain)
+	}
+}
+
+func TestSpaceZone(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(2000)
+	g.birds = append(g.birds, Bird{frames: birdFrames, x: 2400, y: 300})
+
+	// Without enough boosters the ceiling holds
+	g.boosterCount = g.config.SpaceBoosters - 1
+	g.birdman.y = 1
+	g.birdman.vy = -300
+	g.simulate()
+	if g.inSpace() {
+		t.Fatal("went to space without enough boosters")
+	}
+
+	g.boosterCount = g.config.SpaceBoosters
+	g.birdman.y = 1
+	g.birdman.vy = -300
+	g.simulate()
+	if !g.inSpace() {
+		t.Fatal("didn't go to space with enough boosters")
+	}
+	if len(g.birds) != 0 || g.boosterCount != 0 {
+		t.Errorf("birds %d and boosters %d left after entering space", len(g.birds), g.boosterCount)
+	}
+
+	for i := 0; g.inSpace() && i < int(g.config.SpaceDuration*simulationRate)+1; i++ {
+		// Keep him afloat
+		if g.birdman.y > screenHeight/2 {
+			g.birdman.vy = -100
+		}
+		g.simulate()
+		if len(g.birds) > 0 || len(g.fish) > 0 {
+			t.Fatal("hazards spawned in space")
+		}
+	}
+	if g.inSpace() {
+		t.Fatal("space segment didn't end")
+	}
+	if g.mode != ModeGame || g.birdman.y >= screenHeight/2 {
+		t.Errorf("birdman at y=%v in mode %v after re-entry", g.birdman.y, g.mode)
+	}
+	if len(g.spaceStars) != 0 {
+		t.Errorf("%d stars left after re-entry", len(g.spaceStars))
+	}
+}
+
+func TestPopups(t *testing.T) {
+	var p Popups
+	for i := 0; i < popupPoolSize+3; i++ {
+		p.Spawn(fmt.Sprint(i), 0, 0, popupPointsColor)
+	}
+	// The oldest ones were replaced
+	if p.pool[0].text != fmt.Sprint(popupPoolSize) {
+		t.Errorf("slot 0 holds %q, want %q", p.pool[0].text, fmt.Sprint(popupPoolSize))
+	}
+
+	p.Update(popupLifetime / 2)
+	if !p.pool[0].alive || p.pool[0].y >= 0 {
+		t.Errorf("popup alive=%v at y=%v halfway through", p.pool[0].alive, p.pool[0].y)
+	}
+	p.Update(popupLifetime / 2)
+	for i := range p.pool {
+		if p.pool[i].alive {
+			t.Fatalf("popup %d still alive after its lifetime", i)
+		}
+	}
+}
+
+func TestNearMiss(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+	g.fly(0)
+	g.nextBirdX = math.Inf(1)
+	// Just above the birdman's hitbox, and far below it
+	g.birds = append(g.birds,
+		Bird{frames: birdFrames, x: 100, y: g.birdman.y - 60},
+		Bird{frames: birdFrames, x: 100, y: g.birdman.y + 200},
+	)
+	for g.birdman.x < 150 {
+		g.simulate()
+	}
+	if g.birdman.damagedCount != 0 {
+		t.Fatal("birdman got hit")
+	}
+	if g.stylePoints != nearMissPoints {
+		t.Errorf("got %d style points, want %d", g.stylePoints, nearMissPoints)
+	}
+}
+
+func TestPersonalBest(t *testing.T) {
+	g := newTestGame(t)
+	g.records.BestDistance = 50
+	g.fly(1000)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.mode != ModeGameOver {
+		t.Fatalf("mode %v, want game over", g.mode)
+	}
+	if g.records.BestDistance != 100 {
+		t.Errorf("best distance %d, want 100", g.records.BestDistance)
+	}
+
+	// A shorter run doesn't replace it
+	g.initialize()
+	g.startGame()
+	g.fly(500)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.records.BestDistance != 100 {
+		t.Errorf("best distance %d, want 100", g.records.BestDistance)
+	}
+	if g.records.Runs != 2 || g.records.TotalDistance != 150 {
+		t.Errorf("%d runs for %dm in total, want 2 for 150m", g.records.Runs, g.records.TotalDistance)
+	}
+}
+
+func TestSplits(t *testing.T) {
+	g := newTestGame(t)
+	g.records.BestSplits = []float64{100}
+	g.fly(splitDistance*10 - 1)
+	g.splits.time = 90
+	g.simulate()
+	if text, _, ok := g.splitText(); !ok || text != "-10.0s AHEAD AT 250M" {
+		t.Errorf("split text %q, %v", text, ok)
+	}
+
+	// The splits of a new best run are kept for the next runs
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if len(g.records.BestSplits) != 1 || g.records.BestSplits[0] >= 100 {
+		t.Errorf("best splits %v", g.records.BestSplits)
+	}
+}
+
+func TestCheckpointSectors(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.records.BestSectors = []float64{20}
+	g.checkpoints.Reset(g.records.BestSectors)
+	g.fly(checkpointDistance * 10)
+	g.splits.time = 15
+	g.simulate()
+	if diff, ok := g.sectorDiff(0); len(g.checkpoints.sectors) != 1 || !ok || diff > -4.9 {
+		t.Fatalf("sectors %v, diff %v", g.checkpoints.sectors, diff)
+	}
+	g.fly(checkpointDistance * 20)
+	g.simulate()
+
+	// The better sectors and the new ones are kept for the next runs
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if b := g.records.BestSectors; len(b) != 2 || b[0] > 15.1 {
+		t.Errorf("best sectors %v", b)
+	}
+
+	// Warping skips the sectors up to the next checkpoint
+	g.initialize()
+	g.startGame()
+	g.warpTo(checkpointDistance * 3)
+	g.simulate()
+	g.fly(checkpointDistance * 40)
+	g.simulate()
+	if c := &g.checkpoints; c.first != 3 || len(c.sectors) != 1 {
+		t.Errorf("first sector %d, %d timed, want one from the fourth", c.first, len(c.sectors))
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(1000)
+	g.controller.flap = true
+	g.simulate()
+	g.damageBirdman(CauseAirplane)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.mode != ModeGameOver {
+		t.Fatalf("mode %v, want game over", g.mode)
+	}
+	if g.stats.cause != CauseAirplane || g.stats.damage != 1 || g.stats.flaps != 1 {
+		t.Errorf("cause %v, damage %d, flaps %d", g.stats.cause, g.stats.damage, g.stats.flaps)
+	}
+
+	// Falling while flying is drowning
+	g.initialize()
+	g.startGame()
+	g.fly(1000)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.stats.cause != CauseDrowned || g.stats.damage != 0 {
+		t.Errorf("cause %v, damage %d", g.stats.cause, g.stats.damage)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(1000)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+
+	g.logger.actions = nil
+	g.restart()
+	if g.mode != ModeGame || g.birdman.state != StateRunning || g.stats.damage != 0 {
+		t.Errorf("mode %v, state %v after restart", g.mode, g.birdman.state)
+	}
+	if want := []string{"restart", "initialize", "start_game"}; fmt.Sprint(g.logger.actions) != fmt.Sprint(want) {
+		t.Errorf("logged %v, want %v", g.logger.actions, want)
+	}
+}
+
+func TestIdleReturnsToTitle(t *testing.T) {
+	g := newTestGame(t)
+	g.Game.audio = NewAudioManager(audioContext, &AudioSettings{})
+	g.fly(1000)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+
+	ticks := idleTimeout * ebiten.MaxTPS()
+	for i := 0; i < ticks-1; i++ {
+		g.updateIdle(false)
+	}
+	if g.mode != ModeGameOver {
+		t.Fatalf("mode %v before the timeout", g.mode)
+	}
+	g.updateIdle(false)
+	if g.mode != ModeTitle || !g.idle || g.birdman.x >= 0 {
+		t.Errorf("mode %v, idle %v, birdman at x=%v after the timeout", g.mode, g.idle, g.birdman.x)
+	}
+	g.updateIdle(true)
+	if g.idle {
+		t.Error("still idle after input")
+	}
+}
+
+func TestRunSummaryEvent(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(3000)
+	g.damageBirdman(CauseBird)
+	g.birdman.y = screenHeight * 2
+	g.gameOver()
+
+	var summary map[string]interface{}
+	for _, p := range g.logger.payloads {
+		if p["action"] == "run_summary" {
+			summary = p
+		}
+		if p["player_id"] != g.playerID || p["play_id"] != g.playID {
+			t.Errorf("%s event without the ids: %v", p["action"], p)
+		}
+	}
+	if summary == nil {
+		t.Fatalf("no run summary in %v", g.logger.actions)
+	}
+	if summary["distance"] != 300.0 || summary["cause"] != CauseBird.String() || summary["new_best"] != true {
+		t.Errorf("summary %v", summary)
+	}
+	damages, ok := summary["damages"].([]interface{})
+	if !ok || len(damages) != 1 {
+		t.Fatalf("damages %v, want the one hit", summary["damages"])
+	}
+	if d := damages[0].(map[string]interface{}); d["x"] != 3000.0 || d["cause"] != CauseBird.String() {
+		t.Errorf("damage %v, want the bird hit at x=3000", d)
+	}
+	if _, ok := summary["settings"].(map[string]interface{}); !ok {
+		t.Errorf("settings %v", summary["settings"])
+	}
+	if s, ok := summary["session"].(SessionInfo); !ok || s.Platform == "" || s.Version == "" {
+		t.Errorf("session %v", summary["session"])
+	}
+}
+
+type crashingGame struct{}
+
+func (crashingGame) Update() error              { panic("boom") }
+func (crashingGame) Draw(screen *ebiten.Image)  {}
+func (crashingGame) Layout(w, h int) (int, int) { return screenWidth, screenHeight }
+
+func TestCrashGuard(t *testing.T) {
+	g := newTestGame(t)
+	storage := memoryStorage{}
+	g.storage = storage
+	for i := 0; i < inputHistorySize+5; i++ {
+		g.inputHistory.add(fmt.Sprint("key", i))
+	}
+	c := &CrashGuard{game: crashingGame{}, report: g.reportCrash}
+
+	if err := c.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if c.crashed == nil {
+		t.Fatal("no error screen after the panic")
+	}
+	report := string(storage[crashReportFileName])
+	for _, s := range []string{"panic: boom", "birdman state", "key5\n", fmt.Sprintf("key%d\n", inputHistorySize+4)} {
+		if !strings.Contains(report, s) {
+			t.Errorf("crash report lacks %q:\n%s", s, report)
+		}
+	}
+	if strings.Contains(report, "key4\n") {
+		t.Error("crash report has inputs older than the history keeps")
+	}
+	if last := g.logger.actions[len(g.logger.actions)-1]; last != "crash" {
+		t.Errorf("last logged action = %q, want crash", last)
+	}
+
+	// The error screen is shown from then on
+	if err := c.Update(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCrashGuardLoading(t *testing.T) {
+	storage := memoryStorage{}
+	l := &AssetLoader{}
+	l.add(func() error { panic("broken asset") })
+	c := &CrashGuard{
+		game: NewLoadingScene(l, false, nil),
+		report: func(r interface{}, stack []byte) []string {
+			return reportStartupCrash(storage, r, stack)
+		},
+	}
+
+	// The step panics in the loader's goroutine
+	for start := time.Now(); c.crashed == nil; {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("no error screen after the loader panicked")
+		}
+		if err := c.Update(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	report := string(storage[crashReportFileName])
+	for _, s := range []string{"panic: broken asset", "while starting up", "TestCrashGuardLoading.func1"} {
+		if !strings.Contains(report, s) {
+			t.Errorf("crash report lacks %q:\n%s", s, report)
+		}
+	}
+}
+
+func TestStreamStats(t *testing.T) {
+	g := newTestGame(t)
+	g.stream = NewStreamOverlay()
+	g.fly(2000)
+	g.gameOver()
+	g.initialize()
+	g.startGame()
+	g.fly(4560)
+	g.updateStream()
+
+	b, err := g.stream.StatsJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s StreamStats
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Mode != "playing" || s.Distance != 456 || s.Best != 200 || s.Runs != 1 || len(s.Recent) != 1 || s.Recent[0] != 200 {
+		t.Errorf("stream stats %s", b)
+	}
+	if ticker := g.streamTickerText(); !strings.Contains(ticker, "BEST 200m") {
+		t.Errorf("ticker %q lacks the best", ticker)
+	}
+}
+
+func TestAssetLoader(t *testing.T) {
+	finished := 0
+	l := &AssetLoader{finish: func() { finished++ }}
+	l.add(func() error { return nil })
+	l.add(func() error { return errors.New("broken.png") })
+	l.add(func() error { return nil })
+
+	steps := 1
+	for !l.Step() {
+		steps++
+	}
+	if steps != 3 || l.Progress() != 1 {
+		t.Errorf("done after %d steps at %v, want 3 steps", steps, l.Progress())
+	}
+	if err := l.Err(); err == nil || !strings.Contains(err.Error(), "broken.png") {
+		t.Errorf("err = %v, want the broken step", err)
+	}
+	if l.Step(); finished != 0 {
+		t.Error("finished in spite of the failed step")
+	}
+
+	l = &AssetLoader{finish: func() { finished++ }}
+	l.add(func() error { return nil })
+	l.Step()
+	l.Step()
+	if finished != 1 || l.Err() != nil {
+		t.Errorf("finished %d times, err %v", finished, l.Err())
+	}
+
+	l = &AssetLoader{finish: func() { finished++ }}
+	for i := 0; i < 3; i++ {
+		l.add(func() error { return nil })
+	}
+	l.Start()
+	l.Start()
+	for deadline := time.Now().Add(time.Second); !l.Done(); {
+		if time.Now().After(deadline) {
+			t.Fatal("the started loader didn't finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if finished != 2 || l.Progress() != 1 {
+		t.Errorf("finished %d times at %v after started", finished, l.Progress())
+	}
+}
+
+func TestReadAllYielding(t *testing.T) {
+	data := bytes.Repeat([]byte("birdman"), decodeChunk/3)
+	got, err := readAllYielding(bytes.NewReader(data))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes with %v, want %d", len(got), err, len(data))
+	}
+}
+
+func TestReadPacked(t *testing.T) {
+	var z bytes.Buffer
+	w := gzip.NewWriter(&z)
+	w.Write([]byte("font"))
+	w.Close()
+	m := NewAssetManager(fstest.MapFS{
+		"plain.png":   {Data: []byte("png")},
+		"font.ttf.gz": {Data: z.Bytes()},
+		"sound.ogg":   {Data: []byte("ogg")},
+	}, nil)
+
+	for _, c := range []struct{ name, data, packed string }{
+		{"plain.png", "png", "plain.png"},
+		{"font.ttf", "font", "font.ttf"},
+		{"sound.mp3", "ogg", "sound.ogg"},
+	} {
+		data, packed, err := m.readPacked(c.name)
+		if err != nil || string(data) != c.data || packed != c.packed {
+			t.Errorf("readPacked(%q) = %q, %q, %v, want %q, %q", c.name, data, packed, err, c.data, c.packed)
+		}
+	}
+	if _, err := m.readFile("missing.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("err = %v, want not exist", err)
+	}
+}
+
+func TestViewportUpscale(t *testing.T) {
+	for _, c := range []struct {
+		integer       bool
+		width, height int
+		upscale       int
+	}{
+		// A 1280x720 window at a device scale of 3
+		{false, 3840, 2160, 4},
+		{false, 1000, 700, 2},
+		{false, 320, 240, 1},
+		{true, 1920, 1440, 1},
+		{true, 1000, 700, 1},
+		{false, 1e5, 1e5, maxCanvasUpscale},
+	} {
+		v := NewViewport(c.integer)
+		v.Layout(c.width, c.height)
+		if v.upscale != c.upscale {
+			t.Errorf("canvas upscaled %dx to %dx%d (integer %v), want %dx", v.upscale, c.width, c.height, c.integer, c.upscale)
+		}
+		if w, h := v.upscaledCanvas().Size(); w != screenWidth*v.upscale || h != screenHeight*v.upscale {
+			t.Errorf("upscaled canvas %dx%d at %dx", w, h, v.upscale)
+		}
+	}
+
+	// The render scale fixes the upscale whatever the screen
+	v := NewViewport(true)
+	v.Layout(3840, 2160)
+	for scale := 1; scale <= maxRenderScale; scale++ {
+		v.SetRenderScale(scale)
+		if w, _ := v.upscaledCanvas().Size(); w != screenWidth*scale {
+			t.Errorf("canvas rendered %dpx wide at the render scale %dx", w, scale)
+		}
+	}
+	v.SetRenderScale(0)
+	if v.upscale != 1 {
+		t.Errorf("canvas upscaled %dx at the automatic render scale, want 1x", v.upscale)
+	}
+}
+
+func TestTypingKeepsHotkeys(t *testing.T) {
+	g := newTestGame(t)
+	if g.typing() {
+		t.Fatal("typing with no entry open")
+	}
+	for name, start := range map[string]func(){
+		"sync code":      func() { g.sync.entry.Start("", syncCodeLength+1) },
+		"challenge code": func() { g.challengeEntry.Start("", challengeCodeLength) },
+		"party name":     func() { g.party.entry.Start("", 8) },
+		"url":            func() { g.urlEntry.Start("", maxEndpointLength) },
+		"binding":        func() { g.rebinding = Rebinding{active: true, action: ActionFlap} },
+	} {
+		start()
+		if !g.typing() {
+			t.Errorf("hotkeys on while entering a %s", name)
+		}
+		g.sync.entry, g.challengeEntry, g.party.entry, g.urlEntry = TextEntry{}, TextEntry{}, TextEntry{}, TextEntry{}
+		g.rebinding = Rebinding{}
+	}
+}
+
+func TestSuspendAndResume(t *testing.T) {
+	g := newTestGame(t)
+	storage := memoryStorage{}
+	g.storage = storage
+	g.settings.Audio.Background = false
+	g.Game.audio = NewAudioManager(audioContext, &g.settings.Audio)
+	bindings := DefaultBindings()
+	g.input = NewInput(&bindings, NewViewport(false))
+	g.fly(1000)
+
+	now := time.Now()
+	g.updateLifecycle(now, true)
+	g.updateLifecycle(now.Add(time.Second/60), false)
+	if !g.suspended || !g.paused {
+		t.Fatalf("suspended %v, paused %v after losing the focus", g.suspended, g.paused)
+	}
+	if _, ok := storage[settingsFileName]; !ok {
+		t.Error("settings not saved on suspending")
+	}
+	g.updateLifecycle(now.Add(time.Second/30), true)
+	if g.suspended || !g.paused {
+		t.Errorf("suspended %v, paused %v after the focus is back", g.suspended, g.paused)
+	}
+
+	// The loop stopping in the background pauses the run as well
+	g.paused = false
+	g.stepAccumulator = 1
+	g.updateLifecycle(now.Add(time.Minute), true)
+	if !g.paused || g.stepAccumulator != 0 || g.suspended {
+		t.Errorf("paused %v, accumulator %v, suspended %v after a gap", g.paused, g.stepAccumulator, g.suspended)
+	}
+}
+
+func TestWindowTitle(t *testing.T) {
+	for lang, want := range map[string]string{
+		"ja-JP":       "鳥人間",
+		"ja_JP.UTF-8": "鳥人間",
+		"FR":          "Homme-oiseau",
+		"xx":          "Birdman",
+		"":            "Birdman",
+	} {
+		if got := windowTitle(lang); got != want {
+			t.Errorf("windowTitle(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+type testHaptics struct {
+	durations []time.Duration
+}
+
+func (h *testHaptics) IsAvailable() bool { return true }
+
+func (h *testHaptics) Vibrate(duration time.Duration, strength float64) {
+	h.durations = append(h.durations, duration)
+}
+
+func TestVibration(t *testing.T) {
+	g := newTestGame(t)
+	h := &testHaptics{}
+	g.haptics = h
+	g.fly(1000)
+	g.damageBirdman(CauseBird)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if len(h.durations) != 2 || h.durations[0] != damageVibration || h.durations[1] != seaVibration {
+		t.Errorf("vibrated %v, want the damage and the sea", h.durations)
+	}
+
+	g.settings.Vibration = false
+	g.initialize()
+	g.startGame()
+	g.fly(1000)
+	g.damageBirdman(CauseBird)
+	if len(h.durations) != 2 {
+		t.Errorf("vibrated %v while turned off", h.durations)
+	}
+}
+
+func TestKeySequence(t *testing.T) {
+	s := KeySequence{keys: konamiCode}
+	// The keys before the sequence don't matter
+	keys := append([]ebiten.Key{ebiten.KeyUp, ebiten.KeyZ, ebiten.KeyUp}, konamiCode...)
+	for i, k := range keys {
+		if done := s.Update([]ebiten.Key{k}); done != (i == len(keys)-1) {
+			t.Errorf("done %v at key %d", done, i)
+		}
+	}
+}
+
+func TestCheatedRunIsNotRecorded(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.cheats.Invincible = true
+	g.cheats.StartDistance = 1000
+	g.initialize()
+	g.startGame()
+	if g.birdman.state != StateFlying || g.birdman.x != 10000 {
+		t.Fatalf("state %v at x=%v, want flying from 1,000m", g.birdman.state, g.birdman.x)
+	}
+
+	g.damageBirdman(CauseBird)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.mode != ModeGame || g.birdman.state != StateFlying || g.birdman.y > screenHeight {
+		t.Fatalf("mode %v, state %v at y=%v while invincible", g.mode, g.birdman.state, g.birdman.y)
+	}
+
+	g.gameOver()
+	if g.records.Runs != 0 || g.records.BestDistance != 0 {
+		t.Errorf("records %+v after a cheated run", g.records)
+	}
+	if got := g.stats.breakdown(1000)[0]; got != "CHEATS ON: NOT RECORDED" {
+		t.Errorf("breakdown %q", got)
+	}
+}
+
+func TestPracticeWarp(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.records.BestDistance = 3100
+	g.practiceDistance = minPracticeDistance
+	g.adjustPracticeDistance(-1)
+	if g.practiceDistance != 3000 {
+		t.Errorf("wrapped to %dm, want up to the best", g.practiceDistance)
+	}
+
+	g.practice = true
+	g.initialize()
+	g.startGame()
+	if g.birdman.state != StateFlying || g.birdman.x != 30000 || g.nextBirdX != 30000 {
+		t.Fatalf("state %v at x=%v, next bird at %v", g.birdman.state, g.birdman.x, g.nextBirdX)
+	}
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.mode != ModeGameOver || g.records.Runs != 0 {
+		t.Errorf("mode %v, %d runs recorded after a practice", g.mode, g.records.Runs)
+	}
+}
+
+func TestAdaptiveDifficulty(t *testing.T) {
+	d := &AdaptiveDifficulty{}
+	if s := d.adjust(500); s != 1 {
+		t.Errorf("scale %v after one early death", s)
+	}
+	if s := d.adjust(500); s != 1+gapScaleStep {
+		t.Errorf("scale %v after two early deaths", s)
+	}
+	if s := d.adjust(1500); s != 1+gapScaleStep || d.EarlyDeaths != 0 {
+		t.Errorf("scale %v, %d early deaths after an ordinary run", s, d.EarlyDeaths)
+	}
+	for i := 0; i < 10; i++ {
+		d.adjust(3500)
+	}
+	if s := d.scale(); s != minGapScale {
+		t.Errorf("scale %v after breezing through, want %v", s, minGapScale)
+	}
+
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.settings.AdaptiveDifficulty = true
+	for i := 0; i < 2; i++ {
+		g.initialize()
+		g.startGame()
+		g.fly(1000)
+		g.gameOver()
+	}
+	if want := g.config.BirdSpawnInterval * (1 + gapScaleStep); g.birdSpawnInterval() != want {
+		t.Errorf("interval %v, want %v", g.birdSpawnInterval(), want)
+	}
+	if a := g.logger.actions; !strings.Contains(strings.Join(a, ","), "difficulty_adjusted") {
+		t.Errorf("actions %v, want the adjustment logged", a)
+	}
+}
+
+func TestFlapPowerRecovery(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(5000)
+	lost := g.config.FlapPower(g.birdman.x)
+	if g.flapPower() != lost {
+		t.Fatalf("flap power %v before recovering, want %v", g.flapPower(), lost)
+	}
+
+	for i := 0.0; i < g.config.GlideRecoveryTime*simulationRate+1; i++ {
+		g.updateFlapRecovery(false)
+	}
+	if g.birdman.flapRecovery != g.config.GlideRecovery {
+		t.Errorf("recovered %v after a glide, want %v", g.birdman.flapRecovery, g.config.GlideRecovery)
+	}
+	g.updateFlapRecovery(true)
+	if want := g.config.GlideRecovery - g.config.FlapRecoveryCost; math.Abs(g.birdman.flapRecovery-want) > 1e-9 {
+		t.Errorf("recovered %v after a flap, want %v", g.birdman.flapRecovery, want)
+	}
+
+	g.nextFeatherX = math.Inf(1)
+	g.feathers = append(g.feathers[:0], Feather{x: g.birdman.x, y: g.birdman.y})
+	before := g.birdman.flapRecovery
+	g.updateFeathers()
+	if len(g.feathers) != 0 || g.birdman.flapRecovery <= before {
+		t.Errorf("%d feathers left, recovered %v", len(g.feathers), g.birdman.flapRecovery)
+	}
+	full := g.config.FullFlapPower()
+	if want := lost + (full-lost)*g.birdman.flapRecovery; g.flapPower() != want {
+		t.Errorf("flap power %v, want %v", g.flapPower(), want)
+	}
+}
+
+func TestCompanionBlocksABird(t *testing.T) {
+	g := newTestGame(t)
+	g.startGame()
+	if g.companion != nil {
+		t.Fatal("the companion flies along before unlocked")
+	}
+	g.records.BestDistance = companionUnlockDistance
+	g.fly(1000)
+	g.startGame()
+	g.fly(1000)
+	if g.companion == nil {
+		t.Fatal("no companion once unlocked")
+	}
+
+	for i := 0; i < 20; i++ {
+		g.birds = append(g.birds, Bird{frames: birdFrames, x: g.birdman.x + companionBlockRange/2, y: g.birdman.y})
+	}
+	g.updateCompanion()
+	struck := 0
+	for i := range g.birds {
+		if g.birds[i].struck {
+			struck++
+		}
+	}
+	if !g.companion.gone || struck != 1 {
+		t.Errorf("gone %v with %d birds blocked, want one", g.companion.gone, struck)
+	}
+}
+
+func TestUpgradeShop(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.initialize()
+	g.startGame()
+	g.birdman.x = 20000
+	g.stats.items = 2
+	g.gameOver()
+	if want := 200 + 2*coinsPerItem; g.records.Coins != want || g.stats.coins != want {
+		t.Fatalf("%d coins (%d earned), want %d", g.records.Coins, g.stats.coins, want)
+	}
+
+	heart := &upgrades[0]
+	if !g.buyUpgrade(heart) || g.upgradeLevel(upgradeHeart) != 1 || g.records.Coins != 2*coinsPerItem {
+		t.Fatalf("level %d with %d coins left after buying", g.upgradeLevel(upgradeHeart), g.records.Coins)
+	}
+	if g.buyUpgrade(heart) {
+		t.Error("bought without enough coins")
+	}
+	if r := LoadRecords(g.storage); r.Upgrades[upgradeHeart] != 1 {
+		t.Errorf("saved upgrades %v", r.Upgrades)
+	}
+
+	// The heart takes the first hit
+	g.initialize()
+	g.startGame()
+	g.birdman.state = StateFlying
+	g.damageBirdman(CauseBird)
+	if g.birdman.state != StateFlying || g.hearts != 0 {
+		t.Errorf("state %v with %d hearts after a hit", g.birdman.state, g.hearts)
+	}
+	g.birdman.heartLossTime = 0
+	g.damageBirdman(CauseBird)
+	if g.birdman.state != StateDamaged {
+		t.Errorf("state %v after a hit without hearts", g.birdman.state)
+	}
+
+	// A continue puts the birdman back in the air once
+	g.continues = 1
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.mode != ModeGame || g.birdman.state != StateFlying || g.continues != 0 {
+		t.Fatalf("mode %v, state %v after continuing", g.mode, g.birdman.state)
+	}
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if g.mode != ModeGameOver {
+		t.Errorf("mode %v after falling without continues", g.mode)
+	}
+}
+
+func TestUnlockables(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.initialize()
+	g.startGame()
+	g.birdman.x = 12000
+	g.gameOver()
+	if got := g.stats.unlocked; len(got) != 1 || got[0] != "CITY SKYLINE" {
+		t.Fatalf("unlocked %v, want the city", got)
+	}
+	if r := LoadRecords(g.storage); len(r.Unlocked) != 1 || r.Unlocked[0] != "city" {
+		t.Errorf("saved unlocks %v", r.Unlocked)
+	}
+
+	g.cycleUnlockable(UnlockScene, 1)
+	if g.settings.Scene != "city" {
+		t.Errorf("scene %q after cycling", g.settings.Scene)
+	}
+	g.cycleUnlockable(UnlockScene, 1)
+	if g.settings.Scene != defaultScene {
+		t.Errorf("scene %q after cycling past the locked ones", g.settings.Scene)
+	}
+	g.settings.Track = "stardust"
+	if u := g.selected(UnlockTrack); u.ID != defaultMusicTrack {
+		t.Errorf("track %q selected while locked", u.ID)
+	}
+}
+
+func TestBiomes(t *testing.T) {
+	g := newTestGame(t)
+	c := g.config
+	if cur, _, fade := c.BiomeAt(100); cur != &c.Biomes[0] || fade != 0 {
+		t.Errorf("%q with fade %v at the start", cur.Name, fade)
+	}
+	cur, next, fade := c.BiomeAt(c.BiomeLength - biomeFadeLength/2)
+	if cur != &c.Biomes[0] || next != &c.Biomes[1] || fade != 0.5 {
+		t.Errorf("%q to %q with fade %v, want halfway to the second", cur.Name, next.Name, fade)
+	}
+	if cur, _, _ := c.BiomeAt(c.BiomeLength * float64(len(c.Biomes))); cur != &c.Biomes[0] {
+		t.Errorf("%q after the last biome, want the first again", cur.Name)
+	}
+
+	storm := len(c.Biomes) - 1
+	g.fly(c.BiomeLength * float64(storm))
+	h := BandHazard{Kind: hazardAirplane, Weight: 1}
+	if w := g.hazardWeight(h); w != c.Biomes[storm].HazardScale[hazardAirplane] {
+		t.Errorf("airplane weight %v in %q", w, c.Biomes[storm].Name)
+	}
+	g.simulate()
+	if g.currentBiome != &c.Biomes[storm] || g.biomeBannerTime != 0 {
+		t.Errorf("biome %v, banner %v when starting in it", g.currentBiome, g.biomeBannerTime)
+	}
+	g.fly(c.BiomeLength * float64(storm+1))
+	g.simulate()
+	if g.currentBiome != &c.Biomes[0] || g.biomeBannerTime <= 0 {
+		t.Errorf("biome %v, banner %v after flying into the next", g.currentBiome, g.biomeBannerTime)
+	}
+}
+
+func TestLaunchesTakeTurns(t *testing.T) {
+	g := newTestGame(t)
+	for i := range launches {
+		g.records.Runs = i
+		g.initialize()
+		g.startGame()
+		l := &launches[i]
+		if g.launch != l || g.birdman.x != -l.RunUp {
+			t.Fatalf("run %d starts from %q at x=%v", i, g.launch.Name, g.birdman.x)
+		}
+		for g.birdman.state == StateRunning {
+			g.simulate()
+		}
+		if want := g.config.BirdmanSpeed * l.SpeedScale; g.birdman.vx != want || g.birdman.vy != l.TakeOffVy {
+			t.Errorf("%s: took off at (%v, %v), want (%v, %v)", l.Name, g.birdman.vx, g.birdman.vy, want, l.TakeOffVy)
+		}
+	}
+}
+
+func TestCommentary(t *testing.T) {
+	g := newTestGame(t)
+	g.records.BestDistance = 1200
+	g.fly(10100)
+	g.updateFlavor()
+	g.fly(12100)
+	g.updateFlavor()
+	g.updateFlavor()
+	want := []string{"WHAT A FLIGHT, 1,000m!", "HE'S PAST THE OLD RECORD!"}
+	if got := append([]string{g.commentary.text}, g.commentary.queue...); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("commentary %q, want %q", got, want)
+	}
+	if g.nextCheer != 1500 {
+		t.Errorf("next cheer at %dm", g.nextCheer)
+	}
+
+	g.initialize()
+	g.settings.Flavor = false
+	g.fly(10100)
+	g.updateFlavor()
+	if g.commentary.text != "" || len(g.commentary.queue) != 0 {
+		t.Error("commented with the flavor off")
+	}
+}
+
+func TestMenuScrolls(t *testing.T) {
+	m := &Menu{rows: 2}
+	for i := 0; i < 4; i++ {
+		m.items = append(m.items, MenuItem{label: func() string { return "ITEM" }})
+	}
+	m.cursor = 3
+	m.scrollToCursor(4)
+	if m.scroll != 2 || m.inView(1) || !m.inView(3) {
+		t.Errorf("scrolled to %d with the cursor at the end", m.scroll)
+	}
+	m.cursor = 0
+	m.scrollToCursor(4)
+	if m.scroll != 0 {
+		t.Errorf("scrolled to %d with the cursor at the top", m.scroll)
+	}
+}
+
+func TestFedBirdEscorts(t *testing.T) {
+	g := newTestGame(t)
+	g.config.Gravity = 0
+	g.config.HeadwindStrength = 0
+
+	g.fly(1000)
+	g.nextBirdX = 1e9
+	g.nextFlockX = 1e9
+	g.nextBreadcrumbX = 1e9
+	g.carriedCrumbs = 1
+	g.birds = []Bird{{frames: birdFrames, x: g.birdman.x + 200, y: g.birdman.y - 10}}
+	g.controller.throw = true
+	g.simulate()
+	for i := 0; i < simulationRate/2 && g.birds[0].isHazard(); i++ {
+		g.simulate()
+	}
+	b := &g.birds[0]
+	if b.isHazard() || g.carriedCrumbs != 0 || g.stats.birdsFed != 1 {
+		t.Fatalf("bird not fed: state %v, %d crumbs left", b.state, g.carriedCrumbs)
+	}
+
+	for i := 0; i < simulationRate*2; i++ {
+		g.simulate()
+	}
+	if g.birdman.state != StateFlying || b.state != BirdEscorting {
+		t.Fatalf("birdman %v, bird %v, want flying with an escort", g.birdman.state, b.state)
+	}
+	if !g.inSlipstream() {
+		t.Error("not in the escort's slipstream")
+	}
+
+	for i := 0; i < int(g.config.EscortTime*simulationRate); i++ {
+		g.simulate()
+	}
+	if len(g.birds) > 0 && g.birds[0].state != BirdLeaving {
+		t.Errorf("bird %v after the escort time, want leaving", g.birds[0].state)
+	}
+}
+
+func TestRhythmFlaps(t *testing.T) {
+	g := newTestGame(t)
+	if g.rhythmFlap() != 1 {
+		t.Error("flaps are stronger on the beat with the rhythm off")
+	}
+
+	g.settings.Rhythm = true
+	g.music.pos = g.music.beatFrames / 2
+	if g.rhythmFlap() != 1 || g.groove != 0 {
+		t.Errorf("a flap off the beat got the bonus, groove %v", g.groove)
+	}
+	g.music.pos = g.music.beatFrames * 3
+	flaps := 0
+	for g.stylePoints == 0 && flaps < 10 {
+		if g.rhythmFlap() <= 1 {
+			t.Fatal("no bonus for a flap on the beat")
+		}
+		flaps++
+	}
+	if flaps != int(math.Ceil(1/grooveGain)) || g.stylePoints != groovePoints {
+		t.Errorf("%d style points after %d flaps on the beat", g.stylePoints, flaps)
+	}
+	if beat, _ := g.music.Beat(); beat != 3 {
+		t.Errorf("beat = %d, want 3", beat)
+	}
+}
+
+func TestFocusSlowsDown(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(1000)
+	g.controller.focus = true
+	g.simulate()
+	if g.timeScale != focusTimeScale {
+		t.Fatalf("time scale = %v, want %v", g.timeScale, focusTimeScale)
+	}
+
+	steps := 0
+	for g.timeScale != 1 && steps < simulationRate*focusDuration {
+		g.simulate()
+		steps++
+		if steps == 10 && g.focusSaturation() >= 1 {
+			t.Error("the screen keeps its colors while focused")
+		}
+	}
+	// The duration is in real time, of which a step is longer while focused
+	if want := int(focusDuration * focusTimeScale * simulationRate); steps != want || g.focusMeter > simulationStep {
+		t.Fatalf("focused for %d steps, want %d, meter %v", steps, want, g.focusMeter)
+	}
+	g.controller.focus = true
+	g.simulate()
+	if g.timeScale != 1 {
+		t.Error("focused again during the cooldown")
+	}
+}
+
+type testAutosplitter struct {
+	events []string
+}
+
+func (a *testAutosplitter) Start()                 { a.events = append(a.events, "start") }
+func (a *testAutosplitter) Split(gameTime float64) { a.events = append(a.events, "split") }
+func (a *testAutosplitter) Reset()                 { a.events = append(a.events, "reset") }
+
+func TestSpeedrun(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	a := &testAutosplitter{}
+	g.autosplitter = a
+	g.speedrunTarget = 1000
+	g.initialize()
+	g.startGame()
+
+	g.fly(checkpointDistance * 10)
+	g.simulate()
+	g.fly(float64(speedrunTargets[0]*10 - 1))
+	g.splits.time = 80
+	for i := 0; i < simulationRate && g.mode == ModeGame; i++ {
+		g.simulate()
+	}
+	if g.mode != ModeGameOver || g.stats.cause != CauseFinished {
+		t.Fatalf("mode %v with cause %v, want finished", g.mode, g.stats.cause)
+	}
+	if got := strings.Join(a.events, ","); got != "start,split,split" {
+		t.Errorf("autosplit %s", got)
+	}
+	best, ok := g.records.BestTimes[1000]
+	if !ok || best <= 80 || best > 80+simulationRate*simulationStep {
+		t.Errorf("best time %v, %v", best, ok)
+	}
+	if r := g.speedrunResult(); r[len(r)-1] != "NEW BEST TIME!" {
+		t.Errorf("result %q", r)
+	}
+
+	// A run ending short of the target resets the timer
+	g.restart()
+	g.fly(1000)
+	g.birdman.y = screenHeight + 1
+	g.simulate()
+	if got := strings.Join(a.events, ","); got != "start,split,split,start,reset" {
+		t.Errorf("autosplit %s", got)
+	}
+	if g.records.BestTimes[1000] != best {
+		t.Error("the best time changed by a run which didn't finish")
+	}
+}
+
+func TestRaceRanking(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.raceDistance = 1000
+	g.newRaceMenu().items[1].action()
+	if !g.racing || g.speedrunTarget != 1000 {
+		t.Fatalf("racing %v to %d", g.racing, g.speedrunTarget)
+	}
+
+	race := func(time float64) {
+		g.fly(1000*10 - 1)
+		g.splits.time = time
+		for i := 0; i < simulationRate && g.mode == ModeGame; i++ {
+			g.simulate()
+		}
+		if g.mode != ModeResults {
+			t.Fatalf("mode %v after the finish line, want results", g.mode)
+		}
+	}
+	race(90)
+	if g.stats.raceRank != 1 || len(g.records.RaceTimes[1000]) != 1 {
+		t.Fatalf("rank %d of %v", g.stats.raceRank, g.records.RaceTimes)
+	}
+
+	// Retrying from the results races again, and a slower time ranks after
+	g.restart()
+	race(120)
+	if g.stats.raceRank != 2 || len(g.records.RaceTimes[1000]) != 2 {
+		t.Fatalf("rank %d of %v", g.stats.raceRank, g.records.RaceTimes)
+	}
+	if times := g.records.RaceTimes[1000]; times[0] >= times[1] {
+		t.Errorf("ranking %v not fastest first", times)
+	}
+}
+
+func TestMirrorModeUnlock(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.settings.Mirror = true
+	if g.mirrored() || g.mirrorX(100) != 100 {
+		t.Fatal("mirrored while locked")
+	}
+
+	g.fly(5000 * 10)
+	g.gameOver()
+	if !g.mirrored() {
+		t.Fatalf("not mirrored after unlocking with %v", g.stats.unlocked)
+	}
+	if x := g.mirrorX(100); x != screenWidth-100 {
+		t.Errorf("mirrored x %v", x)
+	}
+	// The bird ahead is heard on the left, where it is seen
+	g.fly(0)
+	b := Bird{frames: birdFrames, x: screenWidth / 2, y: screenHeight / 2}
+	b.updateSound(g.Game)
+	if b.sound == nil || b.sound.pan >= 0 {
+		t.Errorf("bird ahead panned to %+v in the mirror mode", b.sound)
+	}
+	if s := g.runSettings(); !s.Mirror {
+		t.Error("the mirror mode is not logged")
+	}
+}
+
+func TestNightLightning(t *testing.T) {
+	g := newTestGame(t)
+	g.settings.Night = true
+	if g.isNight() {
+		t.Fatal("night while locked")
+	}
+	g.records.Unlocked = append(g.records.Unlocked, nightUnlockID)
+	if !g.isNight() {
+		t.Fatal("not night once unlocked")
+	}
+
+	var n Night
+	n.Reset()
+	flashes := 0
+	for i := 0; i < maxLightningInterval*2*simulationRate; i++ {
+		n.Update(simulationStep)
+		if n.flash == 1 {
+			flashes++
+		}
+	}
+	if flashes < 2 {
+		t.Errorf("%d lightning flashes", flashes)
+	}
+}
+
+func TestModifiers(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	gravity := g.config.Gravity
+	g.toggleModifier("moon_gravity")
+	g.toggleModifier("giant_birdman")
+	g.startGame()
+	if g.config.Gravity != gravity*0.3 || g.baseConfig.Gravity != gravity {
+		t.Errorf("gravity %v of base %v", g.config.Gravity, g.baseConfig.Gravity)
+	}
+	_, _, maxX, _ := g.birdman.hitbox().Bounds(0, 0)
+	_, _, normalMaxX, _ := birdmanHitboxes.Frame(g.birdman.animation.Frame()).Bounds(0, 0)
+	if maxX <= normalMaxX {
+		t.Errorf("giant hitbox reaches %v, normal %v", maxX, normalMaxX)
+	}
+
+	g.fly(3000)
+	g.gameOver()
+	if g.stats.recorded() || g.records.BestDistance != 0 {
+		t.Errorf("a run with %v was recorded", g.stats.modifiers)
+	}
+
+	g.modifiersMenu = g.newModifiersMenu()
+	g.modifiersMenu.items[len(modifiers)].action()
+	g.restart()
+	if g.config != g.baseConfig || g.birdman.scale != 0 || !g.stats.recorded() {
+		t.Error("the modifiers stayed on")
+	}
+}
+
+func TestPartyTurns(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.newPartyMenu()
+	g.party.setCount(3)
+	g.party.players[1].Name = "ALICE"
+	g.startParty()
+
+	for _, d := range []int{500, 1500, 1000} {
+		if g.mode != ModeGame {
+			t.Fatalf("mode %v at the start of turn %d", g.mode, g.party.turn)
+		}
+		g.fly(float64(d * 10))
+		g.gameOver()
+		if g.mode != ModeStandings {
+			t.Fatalf("mode %v after turn %d, want standings", g.mode, g.party.turn)
+		}
+		if !g.party.over() {
+			g.startPartyTurn()
+		}
+	}
+	order := g.party.standings()
+	if got := g.party.players[order[0]]; got.Name != "ALICE" || got.Distance != 1500 {
+		t.Errorf("winner %+v", got)
+	}
+	if order[1] != 2 || order[2] != 0 {
+		t.Errorf("standings %v", order)
+	}
+}
+
+func TestPartyHandicaps(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.newPartyMenu()
+	g.party.players[0].Handicap = 3
+	g.party.players[1].Handicap = 4
+	if handicaps[3].multiplier != 2 || handicaps[4].hearts != 1 {
+		t.Fatal("the handicaps have changed")
+	}
+	g.startParty()
+	g.fly(800 * 10)
+	g.gameOver()
+
+	hearts := g.upgradeLevel(upgradeHeart)
+	g.startPartyTurn()
+	if g.hearts != hearts+1 || g.stats.recorded() {
+		t.Errorf("%d hearts, recorded %v", g.hearts, g.stats.recorded())
+	}
+	g.fly(1500 * 10)
+	g.gameOver()
+
+	order := g.party.standings()
+	if order[0] != 0 || g.party.players[0].score() != 1600 {
+		t.Errorf("standings %v with the first scoring %d", order, g.party.players[0].score())
+	}
+}
+
+func TestChallengeCode(t *testing.T) {
+	c := &Challenge{Seed: 0xabcdef, Kind: ChallengeRace, Target: 2500, Launch: len(launches) - 1, Modifiers: []string{"tiny_birds", "turbo"}, Result: 9876}
+	code := c.Encode()
+	if len(code) != challengeCodeLength {
+		t.Fatalf("code %q", code)
+	}
+	got, err := DecodeChallenge(strings.ToLower(formatChallengeCode(code)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("decoded %+v, want %+v", got, c)
+	}
+
+	typo := []byte(code)
+	typo[3] = syncCodeChars[(strings.IndexByte(syncCodeChars, typo[3])+1)%len(syncCodeChars)]
+	if _, err := DecodeChallenge(string(typo)); err == nil {
+		t.Error("a typo was taken")
+	}
+}
+
+func TestChallengeReplay(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.startGame()
+	firstBird := func() (float64, float64) {
+		g.fly(0)
+		for len(g.birds) == 0 {
+			g.simulate()
+			g.birdman.y = screenHeight / 2
+		}
+		return g.birds[0].x, g.birds[0].y
+	}
+	x, y := firstBird()
+	g.gameOver()
+	c := g.lastChallenge
+	if c == nil || c.Kind != ChallengeDistance {
+		t.Fatalf("challenge %+v", c)
+	}
+
+	// Another run goes differently, but the challenge the same
+	c, _ = DecodeChallenge(c.Encode())
+	g.initialize()
+	g.startChallenge(c)
+	if gx, gy := firstBird(); gx != x || gy != y {
+		t.Errorf("first bird at %v,%v, want %v,%v", gx, gy, x, y)
+	}
+	g.fly(float64(c.Result*10 + 1000))
+	g.gameOver()
+	if g.stats.challengeResult != "CHALLENGE BEATEN BY 100m!" {
+		t.Errorf("result %q", g.stats.challengeResult)
+	}
+}
+
+func TestChallengeIgnoresLocalRecords(t *testing.T) {
+	c := &Challenge{Seed: 1234, Kind: ChallengeDistance, Result: 500}
+	birds := func(adaptive bool) [][2]float64 {
+		g := newTestGame(t)
+		g.storage = memoryStorage{}
+		g.settings.AdaptiveDifficulty = adaptive
+		g.records.Difficulty.GapScale = maxGapScale
+		g.records.Upgrades = map[string]int{upgradeHeart: 3, upgradeFlap: 3}
+		g.startChallenge(c)
+		if g.hearts != 0 || g.flapUpgradeRecovery() != 0 {
+			t.Errorf("%d hearts and %v flap recovery of the upgrades in a challenge", g.hearts, g.flapUpgradeRecovery())
+		}
+		g.fly(0)
+		var ys [][2]float64
+		for len(ys) < 5 {
+			g.simulate()
+			g.birdman.y = screenHeight / 2
+			for _, b := range g.birds {
+				ys = append(ys, [2]float64{math.Round(b.x), b.y})
+			}
+			g.birds = nil
+		}
+		return ys
+	}
+	if on, off := birds(true), birds(false); !reflect.DeepEqual(on, off) {
+		t.Errorf("birds at %v with the adaptive difficulty, want %v as without", on, off)
+	}
+
+	// Nor are the runs played with them made challenges of
+	for name, setup := range map[string]func(g *testGame){
+		"adaptive gaps": func(g *testGame) { g.records.Difficulty.GapScale = maxGapScale },
+		"upgrades":      func(g *testGame) { g.records.Upgrades = map[string]int{upgradeDive: 1} },
+		"rhythm":        func(g *testGame) { g.settings.Rhythm = true },
+		"nothing":       func(g *testGame) {},
+	} {
+		g := newTestGame(t)
+		g.storage = memoryStorage{}
+		g.settings.AdaptiveDifficulty = true
+		setup(g)
+		g.startGame()
+		g.fly(3000)
+		g.gameOver()
+		if made := g.lastChallenge != nil; made != (name == "nothing") {
+			t.Errorf("challenge made %v of a run with %s", made, name)
+		}
+	}
+}
+
+func TestDailyRun(t *testing.T) {
+	day := time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC)
+	// The same day in another time zone
+	tokyo := day.In(time.FixedZone("JST", 9*60*60))
+	if dailyDate(day) != "2026-10-14" || dailyDate(tokyo) != "2026-10-14" {
+		t.Errorf("daily dates %s and %s", dailyDate(day), dailyDate(tokyo))
+	}
+	if dailySeed("2026-10-14") == dailySeed("2026-10-15") {
+		t.Error("the same daily seed on two days")
+	}
+
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.startDaily()
+	if g.challenge == nil || g.runSeed != dailySeed(dailyDate(time.Now())) {
+		t.Fatalf("daily run seeded %d, want %d", g.runSeed, dailySeed(dailyDate(time.Now())))
+	}
+	g.fly(6000)
+	g.gameOver()
+	if g.records.DailyDate != g.daily || g.records.DailyBest != 600 {
+		t.Errorf("daily best %dm on %q", g.records.DailyBest, g.records.DailyDate)
+	}
+
+	// The next daily run of the day is against the best of the day
+	g.restart()
+	if g.challenge.Result != 600 {
+		t.Errorf("daily run against %dm, want 600m", g.challenge.Result)
+	}
+	g.fly(4000)
+	g.gameOver()
+	if g.records.DailyBest != 600 || g.stats.challengeResult != "200m SHORT OF THE CHALLENGE" {
+		t.Errorf("daily best %dm, result %q", g.records.DailyBest, g.stats.challengeResult)
+	}
+	if c := g.dailyChallenge(day.AddDate(0, 0, 1)); c.Result != 0 {
+		t.Errorf("daily run of the next day against %dm", c.Result)
+	}
+}
+
+func TestCutscenes(t *testing.T) {
+	g := newTestGame(t)
+	g.settings.Cutscenes = true
+	g.initialize()
+	g.startIntro()
+	if g.mode != ModeCutscene || !g.hidesBirdman() {
+		t.Fatalf("mode %v after the start, want the intro", g.mode)
+	}
+	if g.camera.x != -screenWidth/2 {
+		t.Errorf("camera at %v at the start of the intro", g.camera.x)
+	}
+	for i := 0; i < 1000 && g.mode == ModeCutscene; i++ {
+		g.advanceCutscene(simulationStep)
+	}
+	if g.mode != ModeGame || g.camera.x != -cameraOffsetX {
+		t.Errorf("mode %v with the camera at %v after the intro, want a run from the start", g.mode, g.camera.x)
+	}
+
+	// Once a session
+	g.initialize()
+	g.startIntro()
+	if g.mode != ModeGame {
+		t.Errorf("mode %v after the second start, want a run", g.mode)
+	}
+
+	g.fly(float64(3200 * 10))
+	g.gameOver()
+	if g.mode != ModeCutscene || g.hidesBirdman() {
+		t.Fatalf("mode %v after passing 3,000m, want a vignette", g.mode)
+	}
+	g.endCutscene()
+	if g.mode != ModeGameOver {
+		t.Errorf("mode %v after the vignette, want game over", g.mode)
+	}
+
+	g.restart()
+	g.fly(float64(3500 * 10))
+	g.gameOver()
+	if g.mode != ModeGameOver {
+		t.Errorf("mode %v after a run past no new milestone", g.mode)
+	}
+}
+
+func TestEnding(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.endingMenu = g.newEndingMenu()
+	g.fly(endingDistance*10 - 5)
+	for i := 0; i < 100 && g.mode == ModeGame; i++ {
+		g.simulate()
+	}
+	if g.mode != ModeEnding || g.stats.cause != CauseLanded {
+		t.Fatalf("mode %v with cause %v at the ending distance, want the ending", g.mode, g.stats.cause)
+	}
+	if g.records.Loops != 1 || g.records.Runs != 1 {
+		t.Errorf("%d loops cleared in %d runs", g.records.Loops, g.records.Runs)
+	}
+
+	g.startNewGamePlus()
+	if g.loop != 1 || g.config.BirdSpawnInterval >= g.baseConfig.BirdSpawnInterval || g.config.BirdSpeed <= g.baseConfig.BirdSpeed {
+		t.Errorf("loop %d with birds every %v at %v, want harder than the game", g.loop, g.config.BirdSpawnInterval, g.config.BirdSpeed)
+	}
+	if g.runChallenge() != nil {
+		t.Error("a new game+ run made a challenge")
+	}
+
+	// The speedruns finish on their own
+	g.speedrunTarget = maxRaceDistance * 2
+	g.initialize()
+	g.startGame()
+	g.fly(endingDistance * 10)
+	g.simulate()
+	if g.mode != ModeGame {
+		t.Errorf("mode %v in a speedrun past the ending distance", g.mode)
+	}
+}
+
+func TestCoach(t *testing.T) {
+	g := newTestGame(t)
+	g.fly(2000)
+	g.birdman.y = g.config.AltitudeZoneHeight / 2
+	g.updateCoach()
+	if g.coach.text != "DON'T FLY TOO HIGH!" {
+		t.Errorf("coach said %q in the altitude zone", g.coach.text)
+	}
+
+	// Quiet for a while after a line, and the same hint only once a run
+	g.birdman.y = screenHeight / 2
+	for i := 0; i < int((coachBubbleTime+coachQuietTime)*simulationRate)+2; i++ {
+		g.updateCoach()
+	}
+	g.birdman.y = g.config.AltitudeZoneHeight / 2
+	g.updateCoach()
+	if g.coach.text == "DON'T FLY TOO HIGH!" {
+		t.Error("coach gave the same hint twice")
+	}
+
+	// Only the jokes once the player knows the ropes
+	g.records.Runs = coachHintRuns
+	g.initialize()
+	g.startGame()
+	g.fly(1000 * 10)
+	g.birdman.y = g.config.AltitudeZoneHeight / 2
+	g.updateCoach()
+	if g.coach.text != "I'D HAVE TURNED BACK BY NOW" {
+		t.Errorf("coach said %q at 1,000m", g.coach.text)
+	}
+
+	g.settings.Hints = false
+	g.initialize()
+	g.startGame()
+	g.fly(1000 * 10)
+	g.updateCoach()
+	if g.coach.text != "" {
+		t.Errorf("coach said %q with the hints off", g.coach.text)
+	}
+}
+
+func TestLiveSplitCommands(t *testing.T) {
+	l := &LiveSplit{commands: make(chan string, liveSplitQueueSize)}
+	l.Split(3723.4567)
+	close(l.commands)
+	var got []string
+	for c := range l.commands {
+		got = append(got, c)
+	}
+	if strings.Join(got, ",") != "setgametime 1:02:03.457,split" {
+		t.Errorf("sent %q", got)
+	}
+	if s := formatRunTime(83.4564); s != "1:23.456" {
+		t.Errorf("run time %q", s)
+	}
+}
@@ -10,7 +10,7 @@ require (
 	github.com/tsujio/game-logging-server/client v0.0.0-20230108051530-a7e4a1b23b63
 	golang.org/x/exp v0.0.0-20210817205419-cf631fceb1a5 // indirect
 	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
-	golang.org/x/mobile v0.0.0-20210716004757-34ab1303b554 // indirect
+	golang.org/x/mobile v0.0.0-20210716004757-34ab1303b554
 	golang.org/x/sys v0.0.0-20210818153620-00dd8d7831e7 // indirect
 	golang.org/x/text v0.3.7 // indirect
 )
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// headlessMaxSteps caps a single simulated run (one hour of game time)
+	// in case the controller never loses.
+	headlessMaxSteps = 60 * 60 * simulationRate
+	// in pixels, of the last section of the difficulty curve checked, which
+	// goes on without a limit
+	curveCheckLength = 2000
+)
+
+type headlessResult struct {
+	distance     float64
+	damagedCount int
+	steps        int
+}
+
+// runHeadless plays runs back to back without a window or audio, as fast as
+// the simulation allows, and writes statistics of the results to w.
+func runHeadless(w io.Writer, config *GameConfig, src rand.Source, runs int, newController func(g *Game) Controller) {
+	g := NewGame(config, NewSettings(), src, silentAudio{}, NewEventLogger(false, "", nil))
+	g.controller = newController(g)
+
+	results := make([]headlessResult, 0, runs)
+	for i := 0; i < runs; i++ {
+		g.initialize()
+		g.startGame()
+		steps := 0
+		for g.mode == ModeGame && steps < headlessMaxSteps {
+			g.simulate()
+			steps++
+		}
+		results = append(results, headlessResult{
+			distance:     g.birdman.x,
+			damagedCount: g.birdman.damagedCount,
+			steps:        steps,
+		})
+	}
+
+	writeHeadlessStats(w, results)
+}
+
+func writeHeadlessStats(w io.Writer, results []headlessResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	distances := make([]float64, len(results))
+	var sumDistance, sumSeconds float64
+	var sumDamaged int
+	for i, r := range results {
+		distances[i] = r.distance / 10
+		sumDistance += distances[i]
+		sumSeconds += float64(r.steps) / simulationRate
+		sumDamaged += r.damagedCount
+	}
+	sort.Float64s(distances)
+	percentile := func(p float64) float64 {
+		return distances[int(p*float64(len(distances)-1))]
+	}
+
+	n := float64(len(results))
+	fmt.Fprintf(w, "runs:          %d\n", len(results))
+	fmt.Fprintf(w, "distance mean: %0.1fm\n", sumDistance/n)
+	fmt.Fprintf(w, "distance min:  %0.1fm\n", distances[0])
+	fmt.Fprintf(w, "distance p25:  %0.1fm\n", percentile(0.25))
+	fmt.Fprintf(w, "distance p50:  %0.1fm\n", percentile(0.5))
+	fmt.Fprintf(w, "distance p75:  %0.1fm\n", percentile(0.75))
+	fmt.Fprintf(w, "distance max:  %0.1fm\n", distances[len(distances)-1])
+	fmt.Fprintf(w, "damaged mean:  %0.2f\n", float64(sumDamaged)/n)
+	fmt.Fprintf(w, "duration mean: %0.1fs\n", sumSeconds/n)
+}
+
+// holdController flaps to keep the birdman to the middle of the sky, or at
+// the height of the next feather to pick it up, as often as the quickest
+// player would.
+type holdController struct {
+	game  *Game
+	steps int
+}
+
+func (c *holdController) targetY() float64 {
+	b := c.game.birdman
+	for i := range c.game.feathers {
+		// The feathers falling low are left for the sea
+		if f := &c.game.feathers[i]; f.x > b.x && f.y < screenHeight*2/3 {
+			return math.Max(f.y, c.game.config.AltitudeZoneHeight+birdmanHeight/2)
+		}
+	}
+	return screenHeight / 2
+}
+
+func (c *holdController) ConsumeFlap() (ok, strong bool) {
+	c.steps++
+	b := c.game.birdman
+	if b.y < c.targetY() || b.vy < 0 || c.steps < int(math.Round(reachFlapInterval*simulationRate)) {
+		return false, false
+	}
+	c.steps = 0
+	return true, false
+}
+
+func (c *holdController) IsDivePressed() bool { return false }
+func (c *holdController) ConsumeRoll() bool   { return false }
+func (c *holdController) ConsumeThrow() bool  { return false }
+func (c *holdController) ConsumeFocus() bool  { return false }
+
+// validateDifficultyCurve flies through the difficulty curve without a
+// window, and returns an error for the first section that can't be got
+// through. The birdman must keep in the sky with the flap power carried over
+// and the feathers he can pick up on the way, and have the time between two
+// birds to reach a gap in the wall the second makes by the end of every
+// section.
+func validateDifficultyCurve(config *GameConfig) error {
+	c := *config
+	// Nothing but the birdman and the feathers is in the air
+	c.FishSpawnRate = 0
+	g := NewGame(&c, NewSettings(), rand.NewSource(1), silentAudio{}, NewEventLogger(false, "", nil))
+	g.settings.Cutscenes = false
+	g.initialize()
+	g.startGame()
+	g.controller = &holdController{game: g}
+
+	b := g.birdman
+	b.state = StateFlying
+	b.x, b.y = 0, screenHeight/2
+	b.vx, b.vy = c.BirdmanSpeed, 0
+	g.camera.Reset(-cameraOffsetX, 0)
+	g.nextBirdX, g.nextFlockX = math.Inf(1), math.Inf(1)
+	g.nextBalloonX, g.nextRingX, g.nextBoosterX = math.Inf(1), math.Inf(1), math.Inf(1)
+	for i, p := range c.DifficultyCurve {
+		end := p.Until
+		if end == 0 {
+			end = c.DifficultyStart(i) + curveCheckLength
+		}
+		// Just short of the end, still in the section
+		for g.mode == ModeGame && b.state == StateFlying && b.x < end-b.vx*simulationStep {
+			g.simulate()
+		}
+		if g.mode != ModeGame || b.state != StateFlying {
+			return fmt.Errorf("difficulty_curve[%d]: the birdman can't keep in the sky at %dm with a flap power of %v", i, int(b.x)/10, p.FlapPower)
+		}
+
+		interval := c.BirdSpawnInterval / p.SpawnRate
+		top, bottom := g.reachableEnvelope(interval / (b.vx + g.birdSpeed()))
+		if bottom-top < fairGapHeight+g.flapSwing() {
+			return fmt.Errorf("difficulty_curve[%d]: the birds come every %0.0fpx, too often to reach a gap between them", i, interval)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// seconds the title, game over or any menu screen may sit untouched
+const idleTimeout = 3 * 60
+
+// updateIdle resets the game to a clean title, and silences it, once the
+// screens out of a run have been left untouched for a while, so that an
+// unattended demo doesn't stay on a stale game over screen. It wakes up on
+// any input.
+func (g *Game) updateIdle(active bool) {
+	if active {
+		g.idleTime = 0
+		if g.idle {
+			g.idle = false
+			g.audio.Resume()
+		}
+		return
+	}
+	if g.mode == ModeGame {
+		g.idleTime = 0
+		return
+	}
+
+	g.idleTime += 1 / float64(ebiten.MaxTPS())
+	if !g.idle && g.idleTime >= idleTimeout {
+		g.logEvent(IdleEvent{})
+		g.idle = true
+		g.initialize()
+		g.audio.Suspend()
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	touchButtonBaseRadius = 36
+	swipeThreshold        = 40
+	swipeDiveTicks        = 20
+	tapMaxTicks           = 15
+	tapMaxMove            = 16
+	flapBufferTicks       = 4
+)
+
+type TouchButtonType int
+
+const (
+	TouchButtonFlap TouchButtonType = iota
+	TouchButtonDive
+	TouchButtonPause
+	TouchButtonRoll
+	TouchButtonThrow
+	TouchButtonFocus
+)
+
+type TouchButton struct {
+	typ   TouchButtonType
+	label string
+	x, y  float64
+	scale float64
+}
+
+func (b *TouchButton) radius() float64 {
+	return touchButtonBaseRadius * b.scale
+}
+
+func (b *TouchButton) contains(x, y int) bool {
+	dx, dy := float64(x)-b.x, float64(y)-b.y
+	r := b.radius()
+	return dx*dx+dy*dy < r*r
+}
+
+func (b *TouchButton) Draw(screen *ebiten.Image, pressed bool) {
+	r := b.radius()
+	w, _ := touchButtonImg.Size()
+	opt := scratchDrawOptions()
+	opt.GeoM.Scale(2*r/float64(w), 2*r/float64(w))
+	opt.GeoM.Translate(b.x-r, b.y-r)
+	if pressed {
+		opt.ColorM.Scale(1, 1, 1, 0.6)
+	} else {
+		opt.ColorM.Scale(1, 1, 1, 0.3)
+	}
+	screen.DrawImage(touchButtonImg, opt)
+
+	face, size := smallFont, smallFontSize
+	if b.scale >= 2 {
+		face, size = regularFont, regularFontSize
+	}
+	text.Draw(screen, b.label, face, int(b.x)-len(b.label)*size/2, int(b.y)+size/2, color.White)
+}
+
+var touchButtonImg *ebiten.Image
+
+func newCircleImage(r int, clr color.Color) *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 2*r, 2*r))
+	for y := 0; y < 2*r; y++ {
+		for x := 0; x < 2*r; x++ {
+			dx, dy := float64(x-r)+0.5, float64(y-r)+0.5
+			if dx*dx+dy*dy < float64(r*r) {
+				img.Set(x, y, clr)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+type touchTrack struct {
+	startX, startY int
+	x, y           int
+	ticks          int
+	onButton       bool
+	swiped         bool
+}
+
+type Input struct {
+	bindings        *Bindings
+	viewport        *Viewport
+	layoutW         int
+	layoutH         int
+	pixelScale      float64
+	touchMode       bool
+	touchButtons    []TouchButton
+	justPressed     map[TouchButtonType]bool
+	pressed         map[TouchButtonType]bool
+	touchTracks     map[ebiten.TouchID]*touchTrack
+	multiTouch      bool
+	gestureInvalid  bool
+	strongFlap      bool
+	swipeDiveTicks  int
+	twoFingerTapped bool
+	flapBuffer      int
+	strongBuffered  bool
+	rollBuffered    bool
+	throwBuffered   bool
+	focusBuffered   bool
+	active          bool
+	cursorX         int
+	cursorY         int
+}
+
+func NewInput(bindings *Bindings, viewport *Viewport) *Input {
+	i := &Input{
+		bindings:    bindings,
+		viewport:    viewport,
+		justPressed: make(map[TouchButtonType]bool),
+		pressed:     make(map[TouchButtonType]bool),
+		touchTracks: make(map[ebiten.TouchID]*touchTrack),
+		touchMode:   mobilePlatform,
+	}
+	i.Layout(screenWidth, screenHeight)
+	return i
+}
+
+// Layout anchors the touch buttons to the corners of the whole screen rather
+// than the canvas, so they sit in the letterbox bars when there are any.
+// The screen is in device pixels, so button sizes and gesture thresholds are
+// multiplied by the device scale factor to keep their physical size.
+func (i *Input) Layout(width, height int) {
+	if width == i.layoutW && height == i.layoutH {
+		return
+	}
+	i.layoutW, i.layoutH = width, height
+
+	scale := math.Max(ebiten.DeviceScaleFactor(), 1)
+	i.pixelScale = scale
+	margin := touchButtonBaseRadius*scale + 16
+	w, h := float64(width), float64(height)
+	i.touchButtons = []TouchButton{
+		{typ: TouchButtonFlap, label: "FLAP", x: w - margin, y: h - margin, scale: scale},
+		{typ: TouchButtonDive, label: "DIVE", x: margin, y: h - margin, scale: scale},
+		{typ: TouchButtonPause, label: "II", x: w - margin*0.6, y: margin * 0.6, scale: scale * 0.6},
+		{typ: TouchButtonRoll, label: "ROLL", x: w - margin, y: h - margin*2.4, scale: scale * 0.8},
+		{typ: TouchButtonThrow, label: "FEED", x: margin, y: h - margin*2.4, scale: scale * 0.8},
+		{typ: TouchButtonFocus, label: "FOCUS", x: w - margin*2.4, y: h - margin, scale: scale * 0.8},
+	}
+}
+
+func (i *Input) Update() {
+	for k := range i.justPressed {
+		delete(i.justPressed, k)
+	}
+	for k := range i.pressed {
+		delete(i.pressed, k)
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && len(ebiten.TouchIDs()) == 0 {
+		i.touchMode = false
+	}
+	justPressedTouchIDs := inpututil.JustPressedTouchIDs()
+	if len(justPressedTouchIDs) > 0 {
+		i.touchMode = true
+	}
+
+	for _, id := range justPressedTouchIDs {
+		x, y := ebiten.TouchPosition(id)
+		t := &touchTrack{startX: x, startY: y, x: x, y: y}
+		for j := range i.touchButtons {
+			if i.touchButtons[j].contains(x, y) {
+				i.justPressed[i.touchButtons[j].typ] = true
+				t.onButton = true
+			}
+		}
+		i.touchTracks[id] = t
+	}
+
+	i.updateGestures()
+
+	for _, id := range ebiten.TouchIDs() {
+		x, y := ebiten.TouchPosition(id)
+		for j := range i.touchButtons {
+			if i.touchButtons[j].contains(x, y) {
+				i.pressed[i.touchButtons[j].typ] = true
+			}
+		}
+	}
+
+	i.updateFlapBuffer()
+	if i.IsRollJustPressed() {
+		i.rollBuffered = true
+	}
+	if i.IsThrowJustPressed() {
+		i.throwBuffered = true
+	}
+	if i.IsFocusJustPressed() {
+		i.focusBuffered = true
+	}
+	i.updateActive()
+}
+
+// updateActive checks whether the player is doing anything at all: pressing
+// a key or a button, touching the screen or moving the cursor.
+func (i *Input) updateActive() {
+	x, y := ebiten.CursorPosition()
+	i.active = x != i.cursorX || y != i.cursorY || len(inpututil.PressedKeys()) > 0 || len(ebiten.TouchIDs()) > 0
+	i.cursorX, i.cursorY = x, y
+	for b := ebiten.MouseButtonLeft; b <= ebiten.MouseButtonMiddle && !i.active; b++ {
+		i.active = ebiten.IsMouseButtonPressed(b)
+	}
+	for _, id := range ebiten.GamepadIDs() {
+		for b := ebiten.GamepadButton0; b <= ebiten.GamepadButtonMax && !i.active; b++ {
+			i.active = ebiten.IsGamepadButtonPressed(id, b)
+		}
+	}
+}
+
+func (i *Input) IsActive() bool {
+	return i.active
+}
+
+// updateFlapBuffer keeps a flap alive for a few ticks so that a tap landing
+// while the birdman is not controllable (e.g. the last frames of the damaged
+// spin) is not lost.
+func (i *Input) updateFlapBuffer() {
+	if strong := i.IsStrongFlapJustPressed(); strong || i.IsFlapJustPressed() {
+		i.flapBuffer = flapBufferTicks
+		i.strongBuffered = strong
+	} else if i.flapBuffer > 0 {
+		i.flapBuffer--
+	}
+}
+
+// ConsumeFlap reports whether a flap was requested within the buffer window
+// and clears it.
+func (i *Input) ConsumeFlap() (ok, strong bool) {
+	if i.flapBuffer == 0 {
+		return false, false
+	}
+	ok, strong = true, i.strongBuffered
+	i.ClearFlapBuffer()
+	return
+}
+
+func (i *Input) ClearFlapBuffer() {
+	i.flapBuffer = 0
+	i.strongBuffered = false
+	i.rollBuffered = false
+	i.throwBuffered = false
+	i.focusBuffered = false
+}
+
+// ConsumeRoll reports whether a roll was requested since the last call.
+func (i *Input) ConsumeRoll() bool {
+	ok := i.rollBuffered
+	i.rollBuffered = false
+	return ok
+}
+
+// ConsumeThrow reports whether a breadcrumb throw was requested since the
+// last call.
+func (i *Input) ConsumeThrow() bool {
+	ok := i.throwBuffered
+	i.throwBuffered = false
+	return ok
+}
+
+// ConsumeFocus reports whether focus was requested since the last call.
+func (i *Input) ConsumeFocus() bool {
+	ok := i.focusBuffered
+	i.focusBuffered = false
+	return ok
+}
+
+func (i *Input) updateGestures() {
+	i.strongFlap = false
+	i.twoFingerTapped = false
+	if i.swipeDiveTicks > 0 {
+		i.swipeDiveTicks--
+	}
+
+	for id, t := range i.touchTracks {
+		if inpututil.IsTouchJustReleased(id) {
+			maxMove := int(tapMaxMove * i.pixelScale)
+			if t.ticks > tapMaxTicks || abs(t.x-t.startX) > maxMove || abs(t.y-t.startY) > maxMove {
+				i.gestureInvalid = true
+			}
+			delete(i.touchTracks, id)
+			continue
+		}
+
+		t.x, t.y = ebiten.TouchPosition(id)
+		t.ticks++
+
+		if t.onButton || t.swiped {
+			continue
+		}
+		threshold := int(swipeThreshold * i.pixelScale)
+		if dy := t.y - t.startY; dy < -threshold {
+			t.swiped = true
+			i.strongFlap = true
+		} else if dy > threshold {
+			t.swiped = true
+			i.swipeDiveTicks = swipeDiveTicks
+		}
+		if t.swiped {
+			i.gestureInvalid = true
+		}
+	}
+
+	if len(i.touchTracks) >= 2 {
+		i.multiTouch = true
+	}
+	if len(i.touchTracks) == 0 {
+		if i.multiTouch && !i.gestureInvalid {
+			i.twoFingerTapped = true
+		}
+		i.multiTouch = false
+		i.gestureInvalid = false
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (i *Input) IsJustTapped() bool {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return true
+	}
+	if touchIDs := inpututil.JustPressedTouchIDs(); len(touchIDs) > 0 {
+		return true
+	}
+	return i.IsActionJustPressed(ActionFlap)
+}
+
+// IsAnyJustPressed reports whether anything at all was just pressed: a key,
+// a mouse or gamepad button, or the screen.
+func (i *Input) IsAnyJustPressed() bool {
+	if i.IsJustTapped() || len(justPressedKeys()) > 0 {
+		return true
+	}
+	for b := ebiten.MouseButtonLeft; b <= ebiten.MouseButtonMiddle; b++ {
+		if inpututil.IsMouseButtonJustPressed(b) {
+			return true
+		}
+	}
+	for _, id := range ebiten.GamepadIDs() {
+		for b := ebiten.GamepadButton0; b <= ebiten.GamepadButtonMax; b++ {
+			if inpututil.IsGamepadButtonJustPressed(id, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsActionJustPressed and IsActionPressed check the bindings, which work
+// along with the touch buttons. The mouse is left out while touching.
+func (i *Input) IsActionJustPressed(a Action) bool {
+	return i.bindings.Get(a).isJustPressed(len(ebiten.TouchIDs()) == 0)
+}
+
+func (i *Input) IsActionPressed(a Action) bool {
+	return i.bindings.Get(a).isPressed(len(ebiten.TouchIDs()) == 0)
+}
+
+func (i *Input) JustTappedPosition() (x, y int, ok bool) {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y = i.viewport.ToCanvas(ebiten.CursorPosition())
+		return x, y, true
+	}
+	if touchIDs := inpututil.JustPressedTouchIDs(); len(touchIDs) > 0 {
+		x, y = i.viewport.ToCanvas(ebiten.TouchPosition(touchIDs[0]))
+		return x, y, true
+	}
+	return 0, 0, false
+}
+
+// The touch buttons are on in the touch mode, and the bindings always, so
+// that a tap doesn't take the keyboard and the gamepads away on touchscreen
+// laptops.
+
+func (i *Input) IsFlapJustPressed() bool {
+	return i.touchMode && i.justPressed[TouchButtonFlap] || i.IsActionJustPressed(ActionFlap)
+}
+
+func (i *Input) IsStrongFlapJustPressed() bool {
+	return i.touchMode && i.strongFlap
+}
+
+func (i *Input) IsDivePressed() bool {
+	return i.touchMode && (i.pressed[TouchButtonDive] || i.swipeDiveTicks > 0) || i.IsActionPressed(ActionDive)
+}
+
+func (i *Input) IsRollJustPressed() bool {
+	return i.touchMode && i.justPressed[TouchButtonRoll] || i.IsActionJustPressed(ActionRoll)
+}
+
+func (i *Input) IsThrowJustPressed() bool {
+	return i.touchMode && i.justPressed[TouchButtonThrow] || i.IsActionJustPressed(ActionThrow)
+}
+
+func (i *Input) IsFocusJustPressed() bool {
+	return i.touchMode && i.justPressed[TouchButtonFocus] || i.IsActionJustPressed(ActionFocus)
+}
+
+func (i *Input) IsPauseJustPressed() bool {
+	return i.touchMode && (i.justPressed[TouchButtonPause] || i.twoFingerTapped) || i.IsActionJustPressed(ActionPause)
+}
+
+func (i *Input) DrawTouchButtons(screen *ebiten.Image) {
+	if !i.touchMode {
+		return
+	}
+	for j := range i.touchButtons {
+		b := &i.touchButtons[j]
+		b.Draw(screen, i.pressed[b.typ])
+	}
+}
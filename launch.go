@@ -0,0 +1,150 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	shipHullWidth    = 180
+	shipHullHeight   = 40
+	shipMastWidth    = 8
+	shipSwayPeriod   = 2.5
+	lighthouseWidth  = 56
+	lighthouseStripe = 40
+)
+
+var (
+	shipHullColor       = color.RGBA{0x6a, 0x40, 0x28, 0xff}
+	shipMastColor       = color.RGBA{0x8a, 0x60, 0x38, 0xff}
+	shipSailColor       = color.RGBA{0xf0, 0xe8, 0xd8, 0xff}
+	lighthouseColor     = color.RGBA{0xf4, 0xf4, 0xf0, 0xff}
+	lighthouseRedColor  = color.RGBA{0xc8, 0x30, 0x30, 0xff}
+	lighthouseLampColor = color.RGBA{0xff, 0xf0, 0xa0, 0xff}
+)
+
+// Launch is where a run takes off from. The birdman runs up along it and
+// takes off at its edge, at x = 0.
+type Launch struct {
+	Name string
+	// the y position of the birdman on it, and the pixels of the run-up
+	Y     float64
+	RunUp float64
+	// multiplies the cruise speed for the run-up and the take-off
+	SpeedScale float64
+	// the vertical speed at the take-off, negative for a jump
+	TakeOffVy float64
+	// pixels the launch bobs up and down by on the waves
+	Sway float64
+
+	animation *Animation
+	draw      func(l *Launch, g *Game, screen *ebiten.Image)
+}
+
+var (
+	birdmanBalancingAnimation = &Animation{frames: []int{0, 1}, frameDuration: 24, loop: true}
+	birdmanSprintingAnimation = &Animation{frames: []int{0, 1}, frameDuration: 5, loop: true}
+)
+
+// The launches take turns run by run
+var launches = []Launch{
+	{
+		Name:       "CLIFF",
+		Y:          initialBirdmanPosY,
+		RunUp:      60,
+		SpeedScale: 1,
+		animation:  birdmanRunningAnimation,
+		draw:       drawCliff,
+	},
+	{
+		Name:       "SHIP MAST",
+		Y:          screenHeight / 2,
+		RunUp:      30,
+		SpeedScale: 0.8,
+		TakeOffVy:  -240,
+		Sway:       6,
+		animation:  birdmanBalancingAnimation,
+		draw:       drawShip,
+	},
+	{
+		Name:       "LIGHTHOUSE",
+		Y:          screenHeight / 5,
+		RunUp:      90,
+		SpeedScale: 1.3,
+		animation:  birdmanSprintingAnimation,
+		draw:       drawLighthouse,
+	},
+}
+
+func (g *Game) nextLaunch() *Launch {
+	if g.challenge != nil {
+		return &launches[g.challenge.Launch]
+	}
+	return &launches[g.records.Runs%len(launches)]
+}
+
+// sway is how far the launch is off its height.
+func (l *Launch) sway(t float64) float64 {
+	return l.Sway * math.Sin(2*math.Pi*t/shipSwayPeriod)
+}
+
+// top is the y position of what the birdman stands on.
+func (l *Launch) top() float64 {
+	return l.Y + birdmanHeight/3
+}
+
+// updateRunUp runs the birdman up along the launch and takes off at its
+// edge.
+func (g *Game) updateRunUp() {
+	b, l := g.birdman, g.launch
+	b.x += g.config.BirdmanSpeed * l.SpeedScale * simulationStep
+	b.y = l.Y + l.sway(g.splits.time)
+	if b.x >= 0 {
+		b.state = StateFlying
+		b.vx = g.config.BirdmanSpeed * l.SpeedScale
+		b.vy = l.TakeOffVy
+	}
+}
+
+func (g *Game) drawLaunch(screen *ebiten.Image) {
+	g.launch.draw(g.launch, g, screen)
+}
+
+func drawCliff(l *Launch, g *Game, screen *ebiten.Image) {
+	g.backdrop.DrawCliff(screen, g.camera.ViewX(), g.camera.ViewY())
+}
+
+// drawShip draws a ship in the sea whose yard the birdman runs along.
+func drawShip(l *Launch, g *Game, screen *ebiten.Image) {
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	sway := l.sway(g.splits.time)
+	mastX := -l.RunUp - shipMastWidth - viewX
+	seaY := float64(screenHeight-g.backdrop.seaHeight) + 10 - viewY + sway
+	yardY := l.top() - viewY + sway
+	ebitenutil.DrawRect(screen, mastX, yardY-30, shipMastWidth, seaY-yardY+30, shipMastColor)
+	ebitenutil.DrawRect(screen, mastX-l.RunUp, yardY, l.RunUp*2+shipMastWidth, 4, shipMastColor)
+	ebitenutil.DrawRect(screen, mastX-l.RunUp+6, yardY+4, l.RunUp*2+shipMastWidth-12, (seaY-yardY)*0.6, shipSailColor)
+	ebitenutil.DrawRect(screen, mastX-shipHullWidth/2, seaY-shipHullHeight/2, shipHullWidth, shipHullHeight, shipHullColor)
+}
+
+// drawLighthouse draws a striped tower whose gallery the birdman runs
+// around.
+func drawLighthouse(l *Launch, g *Game, screen *ebiten.Image) {
+	viewX, viewY := g.camera.ViewX(), g.camera.ViewY()
+	top := l.top() - viewY
+	bottom := float64(screenHeight) - viewY
+	x := -lighthouseWidth - viewX
+	for y, i := top, 0; y < bottom; y, i = y+lighthouseStripe, i+1 {
+		clr := lighthouseColor
+		if i%2 == 1 {
+			clr = lighthouseRedColor
+		}
+		ebitenutil.DrawRect(screen, x, y, lighthouseWidth, math.Min(lighthouseStripe, bottom-y), clr)
+	}
+	// The gallery, and the lamp room above it
+	ebitenutil.DrawRect(screen, -l.RunUp-viewX, top-4, l.RunUp, 4, lighthouseRedColor)
+	ebitenutil.DrawRect(screen, x+lighthouseWidth/4, top-birdmanHeight, lighthouseWidth/2, birdmanHeight-4, lighthouseLampColor)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	logging "github.com/tsujio/game-logging-server/client"
+)
+
+// the entries shown of a list
+const leaderboardSize = 7
+
+type leaderboardResult struct {
+	race   int
+	scores []logging.GameScore
+	err    error
+}
+
+// Leaderboard is the state of the leaderboard screen, which shows the best
+// distances and the race times uploaded by the sync. The lists are fetched
+// in the background like the sync requests.
+type Leaderboard struct {
+	// the distance of the race shown, 0 for the best distances
+	race    int
+	busy    bool
+	lines   []string
+	results chan leaderboardResult
+}
+
+func (g *Game) leaderboardList() string {
+	if g.leaderboard.race > 0 {
+		return raceScoreList(g.leaderboard.race)
+	}
+	return syncScoreList
+}
+
+func (g *Game) openLeaderboard() {
+	g.mode = ModeLeaderboard
+	g.fetchLeaderboard()
+}
+
+func (g *Game) fetchLeaderboard() {
+	lb := &g.leaderboard
+	if !g.syncAvailable() {
+		lb.lines = []string{"THE LEADERBOARD IS OFF", "WITHOUT THE EVENT LOGGING"}
+		return
+	}
+	// The list switched to is fetched once the one in flight is back
+	if lb.busy {
+		return
+	}
+	if lb.results == nil {
+		lb.results = make(chan leaderboardResult, 1)
+	}
+	lb.busy = true
+	lb.lines = []string{"LOADING..."}
+	race, list, results := lb.race, g.leaderboardList(), lb.results
+	go func() {
+		scores, err := g.syncer.FetchScores(list)
+		results <- leaderboardResult{race: race, scores: scores, err: err}
+	}()
+}
+
+// adjustLeaderboard switches between the best distances and the races by
+// distance.
+func (g *Game) adjustLeaderboard(delta int) {
+	lb := &g.leaderboard
+	lb.race += delta * raceDistanceStep
+	if lb.race < 0 {
+		lb.race = maxRaceDistance
+	} else if lb.race > maxRaceDistance {
+		lb.race = 0
+	}
+	g.fetchLeaderboard()
+}
+
+// topScores keeps the best score of every player, best first.
+func topScores(scores []logging.GameScore, n int) []logging.GameScore {
+	best := map[string]logging.GameScore{}
+	for _, s := range scores {
+		if b, ok := best[s.PlayerID]; !ok || s.Score > b.Score {
+			best[s.PlayerID] = s
+		}
+	}
+	var top []logging.GameScore
+	for _, s := range best {
+		top = append(top, s)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Score != top[j].Score {
+			return top[i].Score > top[j].Score
+		}
+		return top[i].Timestamp.Before(top[j].Timestamp)
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// leaderboardLines formats the list, marking the player's own entry.
+func (g *Game) leaderboardLines(race int, scores []logging.GameScore) []string {
+	top := topScores(scores, leaderboardSize)
+	if len(top) == 0 {
+		return []string{"NO ENTRIES YET"}
+	}
+	own := ""
+	if g.records.SyncCode != "" {
+		own = syncPlayerID(g.records.SyncCode)
+	}
+	var lines []string
+	for i, s := range top {
+		// The race times are negated milliseconds
+		score := formatIntComma(s.Score) + "m"
+		if race > 0 {
+			score = formatRunTime(float64(-s.Score) / 1000)
+		}
+		// The mark is padded on the others too to keep the lines aligned
+		mark := "    "
+		if s.PlayerID == own {
+			mark = " YOU"
+		}
+		lines = append(lines, fmt.Sprintf("%d. %10s%s", i+1, score, mark))
+	}
+	return lines
+}
+
+// receiveLeaderboard takes the list fetched, if any.
+func (g *Game) receiveLeaderboard() {
+	lb := &g.leaderboard
+	if !lb.busy {
+		return
+	}
+	var r leaderboardResult
+	select {
+	case r = <-lb.results:
+	default:
+		return
+	}
+	lb.busy = false
+
+	switch {
+	case r.race != lb.race:
+		g.fetchLeaderboard()
+	case r.err != nil:
+		log.Printf("Failed to fetch the leaderboard: %v", r.err)
+		lb.lines = []string{"LOADING FAILED"}
+	default:
+		lb.lines = g.leaderboardLines(r.race, r.scores)
+	}
+}
+
+func (g *Game) updateLeaderboard() {
+	g.receiveLeaderboard()
+	g.leaderboardMenu.Update(g.input)
+}
+
+func (g *Game) newLeaderboardMenu() *Menu {
+	m := g.newBackMenu()
+	m.items = append([]MenuItem{
+		{
+			label: func() string {
+				if g.leaderboard.race > 0 {
+					return fmt.Sprintf("RACE TO %sm", formatIntComma(g.leaderboard.race))
+				}
+				return "BEST DISTANCE"
+			},
+			action: func() { g.adjustLeaderboard(1) },
+			adjust: g.adjustLeaderboard,
+		},
+	}, m.items...)
+	m.y -= m.itemHeight()
+	return m
+}
+
+func (g *Game) drawLeaderboard(screen *ebiten.Image) {
+	drawInfoScreen(screen, "LEADERBOARD", g.leaderboard.lines, g.leaderboardMenu)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"time"
+)
+
+// Updates further apart than this mean the game loop was stopped, as it is
+// while a mobile app is in the background or a laptop sleeps.
+const resumeGap = time.Second
+
+// updateLifecycle suspends the game when it loses the focus or its loop was
+// stopped, and resumes it once it has the focus again. Mobile platforms give
+// no chance to save at exit, so the settings and the events not sent yet are
+// saved on suspending.
+func (g *Game) updateLifecycle(now time.Time, focused bool) {
+	if !g.lastUpdate.IsZero() && now.Sub(g.lastUpdate) > resumeGap {
+		g.suspend()
+	}
+	g.lastUpdate = now
+
+	if !focused {
+		g.suspend()
+	} else if g.suspended {
+		g.resume()
+	}
+}
+
+func (g *Game) suspend() {
+	if g.suspended {
+		return
+	}
+	g.suspended = true
+	if g.mode == ModeGame {
+		g.paused = true
+		g.input.ClearFlapBuffer()
+	}
+	// Don't catch up with the time spent in the background
+	g.stepAccumulator = 0
+	// Give up the audio focus, e.g. for a call coming in
+	if !g.settings.Audio.Background {
+		g.audio.Suspend()
+	}
+	g.saveSettings()
+	g.logger.Save()
+}
+
+func (g *Game) resume() {
+	g.suspended = false
+	if !g.settings.Audio.Background && !g.idle {
+		g.audio.Resume()
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// the default port of the LiveSplit Server component
+	defaultLiveSplitAddr = "localhost:16834"
+	liveSplitQueueSize   = 32
+)
+
+// LiveSplit drives the LiveSplit timer through its server component, which
+// takes a command per line. The game time is set at every split so that
+// LiveSplit shows the time of the simulation rather than the time taken by
+// the frames.
+type LiveSplit struct {
+	commands chan string
+}
+
+// send queues a command, dropping it if LiveSplit is not keeping up so that
+// the run goes on without it.
+func (l *LiveSplit) send(command string) {
+	select {
+	case l.commands <- command:
+	default:
+	}
+}
+
+func (l *LiveSplit) Start() {
+	l.send("reset")
+	l.send("starttimer")
+	l.send("initgametime")
+	l.send("pausegametime")
+}
+
+func (l *LiveSplit) Split(gameTime float64) {
+	l.send("setgametime " + formatLiveSplitTime(gameTime))
+	l.send("split")
+}
+
+func (l *LiveSplit) Reset() {
+	l.send("reset")
+}
+
+// formatLiveSplitTime formats seconds as a time span LiveSplit parses.
+func formatLiveSplitTime(t float64) string {
+	ms := int(math.Round(t * 1000))
+	return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
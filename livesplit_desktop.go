@@ -0,0 +1,40 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+const liveSplitDialTimeout = time.Second
+
+// NewLiveSplit sends the speedrun events to the LiveSplit server at addr,
+// connecting on the first event and again whenever the connection is lost.
+func NewLiveSplit(addr string) Autosplitter {
+	l := &LiveSplit{commands: make(chan string, liveSplitQueueSize)}
+	go l.run(addr)
+	return l
+}
+
+func (l *LiveSplit) run(addr string) {
+	var conn net.Conn
+	for command := range l.commands {
+		if conn == nil {
+			c, err := net.DialTimeout("tcp", addr, liveSplitDialTimeout)
+			if err != nil {
+				log.Printf("Failed to connect to LiveSplit: %v", err)
+				continue
+			}
+			conn = c
+		}
+		if _, err := io.WriteString(conn, command+"\r\n"); err != nil {
+			log.Printf("Failed to send to LiveSplit: %v", err)
+			conn.Close()
+			conn = nil
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"log"
+)
+
+// NewLiveSplit is not available in browsers, which can't open sockets.
+func NewLiveSplit(addr string) Autosplitter {
+	log.Print("LiveSplit is not available on this platform")
+	return nil
+}
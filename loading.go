@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"io"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	loadingBarWidth  = 320
+	loadingBarHeight = 8
+	loadingBarY      = screenHeight / 2
+	// seconds a cycle of blinking of the tap prompt takes
+	tapPromptBlinkPeriod = 1.0
+	// how long decoding may run before giving the event loop a turn, which
+	// is a frame at most
+	decodeSlice = 10 * time.Millisecond
+	decodeChunk = 64 * 1024
+)
+
+var (
+	loadingBarColor     = color.RGBA{0xff, 0xff, 0xff, 0x40}
+	loadingBarFillColor = color.RGBA{0xff, 0xe0, 0x60, 0xff}
+)
+
+// AssetLoader loads the assets a step at a time, so that the loading scene
+// can show the progress in between. Start runs the steps in the background
+// while the scene keeps drawing.
+type AssetLoader struct {
+	steps []func() error
+	// run once all the steps succeeded
+	finish func()
+
+	mu       sync.Mutex
+	started  bool
+	next     int
+	finished bool
+	errs     AssetErrors
+	// of a step run by Start, for the loading scene to panic with
+	panicked *recoveredPanic
+}
+
+func (l *AssetLoader) add(step func() error) {
+	l.steps = append(l.steps, step)
+}
+
+// Step runs the next step and reports whether all of them are done.
+func (l *AssetLoader) Step() bool {
+	l.mu.Lock()
+	next := l.next
+	l.mu.Unlock()
+
+	if next < len(l.steps) {
+		err := l.steps[next]()
+		l.mu.Lock()
+		if err != nil {
+			l.errs = append(l.errs, err)
+		}
+		l.next++
+		next = l.next
+		l.mu.Unlock()
+	}
+	if next < len(l.steps) {
+		return false
+	}
+
+	l.mu.Lock()
+	finish := !l.finished && len(l.errs) == 0 && l.finish != nil
+	l.mu.Unlock()
+	// The finish is counted as loading, so it's done before finished is set
+	if finish {
+		l.finish()
+	}
+	l.mu.Lock()
+	l.finished = true
+	l.mu.Unlock()
+	return true
+}
+
+// Start runs the steps in a goroutine, giving the browser's event loop a
+// turn in between so that the tab keeps responding. It does nothing once
+// started.
+func (l *AssetLoader) Start() {
+	l.mu.Lock()
+	started := l.started
+	l.started = true
+	l.mu.Unlock()
+	if started {
+		return
+	}
+	go func() {
+		// A panic here would end the program past the CrashGuard
+		defer func() {
+			if r := recover(); r != nil {
+				l.mu.Lock()
+				l.panicked = &recoveredPanic{value: r, stack: debug.Stack()}
+				l.mu.Unlock()
+			}
+		}()
+		for !l.Step() {
+			yieldToEventLoop()
+		}
+	}()
+}
+
+// Panicked returns the panic of a step run by Start, if any.
+func (l *AssetLoader) Panicked() *recoveredPanic {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.panicked
+}
+
+// Done reports whether all the steps and the finish have run.
+func (l *AssetLoader) Done() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.finished
+}
+
+func (l *AssetLoader) Progress() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.steps) == 0 {
+		return 1
+	}
+	return float64(l.next) / float64(len(l.steps))
+}
+
+func (l *AssetLoader) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.errs) == 0 {
+		return nil
+	}
+	return l.errs
+}
+
+// LoadingScene runs before the game. It loads the assets with a progress
+// bar, and in browsers, which keep the audio suspended until the page is
+// interacted with, waits for a tap so that the title starts with sound.
+// Then it makes the game with start and hands over to it.
+type LoadingScene struct {
+	loader   *AssetLoader
+	start    func() (ebiten.Game, error)
+	needsTap bool
+	touches  []ebiten.TouchID
+	ticks    int
+
+	game   ebiten.Game
+	failed *ErrorScreen
+}
+
+func NewLoadingScene(loader *AssetLoader, needsTap bool, start func() (ebiten.Game, error)) *LoadingScene {
+	return &LoadingScene{loader: loader, needsTap: needsTap, start: start}
+}
+
+func (s *LoadingScene) fail(title string, err error) {
+	log.Printf("%s: %v", title, err)
+	s.failed = NewErrorScreen(title, err)
+}
+
+// isTapReleased tells a tap, a click or a key press once it is released,
+// which is when browsers let the audio resume.
+func (s *LoadingScene) isTapReleased() bool {
+	released := inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft)
+	// Released touches are gone from TouchIDs, so look at those of the
+	// frame before
+	for _, id := range s.touches {
+		if inpututil.IsTouchJustReleased(id) {
+			released = true
+		}
+	}
+	s.touches = append(s.touches[:0], ebiten.TouchIDs()...)
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if inpututil.IsKeyJustReleased(k) {
+			released = true
+		}
+	}
+	return released
+}
+
+func (s *LoadingScene) Update() error {
+	switch {
+	case s.failed != nil:
+		return s.failed.Update()
+	case s.game != nil:
+		return s.game.Update()
+	}
+
+	s.ticks++
+	s.loader.Start()
+	if p := s.loader.Panicked(); p != nil {
+		panic(p)
+	}
+	if !s.loader.Done() {
+		return nil
+	}
+	if err := s.loader.Err(); err != nil {
+		s.fail("Failed to load resources", err)
+		return nil
+	}
+	if s.needsTap && !s.isTapReleased() {
+		return nil
+	}
+
+	game, err := s.start()
+	if err != nil {
+		s.fail("Failed to start the game", err)
+		return nil
+	}
+	s.game = game
+	return nil
+}
+
+func (s *LoadingScene) Draw(screen *ebiten.Image) {
+	switch {
+	case s.failed != nil:
+		s.failed.Draw(screen)
+		return
+	case s.game != nil:
+		s.game.Draw(screen)
+		return
+	}
+
+	x := float64(screenWidth-loadingBarWidth) / 2
+	if !s.loader.Done() {
+		ebitenutil.DebugPrintAt(screen, "LOADING...", int(x), loadingBarY-24)
+		ebitenutil.DrawRect(screen, x, loadingBarY, loadingBarWidth, loadingBarHeight, loadingBarColor)
+		ebitenutil.DrawRect(screen, x, loadingBarY, loadingBarWidth*s.loader.Progress(), loadingBarHeight, loadingBarFillColor)
+		return
+	}
+	// The game's font is loaded by now
+	t := float64(s.ticks) / float64(ebiten.MaxTPS())
+	if int(t/(tapPromptBlinkPeriod/2))%2 == 0 {
+		const tapText = "TAP TO ENABLE SOUND"
+		text.Draw(screen, tapText, regularFont, screenWidth/2-len(tapText)*regularFontSize/2, loadingBarY, color.White)
+	}
+}
+
+func (s *LoadingScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if s.game != nil {
+		return s.game.Layout(outsideWidth, outsideHeight)
+	}
+	return screenWidth, screenHeight
+}
+
+// readAllYielding reads r to the end like ioutil.ReadAll, but yields to the
+// event loop every decodeSlice, as decoding a stream of audio takes seconds
+// in WASM and the tab would freeze otherwise.
+func readAllYielding(r io.Reader) ([]byte, error) {
+	var b bytes.Buffer
+	last := time.Now()
+	for {
+		if _, err := io.CopyN(&b, r, decodeChunk); err != nil {
+			if err == io.EOF {
+				return b.Bytes(), nil
+			}
+			return nil, err
+		}
+		if time.Since(last) >= decodeSlice {
+			yieldToEventLoop()
+			last = time.Now()
+		}
+	}
+}
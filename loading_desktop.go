@@ -0,0 +1,8 @@
+//go:build !js
+// +build !js
+
+package main
+
+// yieldToEventLoop does nothing on desktops, where the loader runs on a
+// thread of its own.
+func yieldToEventLoop() {}
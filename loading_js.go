@@ -0,0 +1,15 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"time"
+)
+
+// yieldToEventLoop lets the browser handle the events and draw the loading
+// scene. Goroutines in WASM share the only thread, and only sleeping hands it
+// back to JavaScript.
+func yieldToEventLoop() {
+	time.Sleep(time.Millisecond)
+}
@@ -0,0 +1,19 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"os"
+)
+
+// userLanguage returns the language of the locale in the environment, which
+// is empty when it isn't set, as on Windows.
+func userLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return v
+		}
+	}
+	return ""
+}
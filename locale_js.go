@@ -0,0 +1,17 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// userLanguage returns the preferred language of the browser.
+func userLanguage() string {
+	lang := js.Global().Get("navigator").Get("language")
+	if lang.Type() != js.TypeString {
+		return ""
+	}
+	return lang.String()
+}
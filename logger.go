@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	logging "github.com/tsujio/game-logging-server/client"
+)
+
+// Logger records gameplay events.
+type Logger interface {
+	LogAsync(payload map[string]interface{})
+	// Save keeps the events not sent yet, for the game may not get to run
+	// again, e.g. in the background on mobile
+	Save()
+}
+
+// EventLogger sends gameplay events to the game logging server. The default
+// server is reached through the logging client; a custom endpoint (e.g. a
+// self-hosted server) is posted to directly using the same request format.
+// The events go through a queue kept in the storage, so that those logged
+// while offline are sent later.
+//
+// Logging stays off until the player opts in, see Configure.
+type EventLogger struct {
+	// whether there is a secret to sign the events with; logging can't be
+	// switched on without it
+	available bool
+	secret    string
+	storage   Storage
+
+	// guards the fields below, which the queue reads in the background
+	mu       sync.Mutex
+	enabled  bool
+	endpoint string
+	queue    *EventQueue
+}
+
+func NewEventLogger(available bool, secret string, storage Storage) *EventLogger {
+	logging.Disable()
+	return &EventLogger{
+		available: available,
+		secret:    secret,
+		storage:   storage,
+	}
+}
+
+func (l *EventLogger) Available() bool {
+	return l.available
+}
+
+// Configure follows the privacy settings. Switching logging off throws away
+// the events still queued.
+func (l *EventLogger) Configure(p PrivacySettings) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.enabled = l.available && p.Logging
+	l.endpoint = strings.TrimSuffix(p.Endpoint, "/")
+	if l.enabled && l.endpoint == "" {
+		logging.Enable(l.secret)
+	} else {
+		logging.Disable()
+	}
+
+	if l.enabled && l.queue == nil {
+		l.queue = NewEventQueue(l.storage, l.send)
+		go l.queue.Run()
+	}
+	if !l.enabled && l.queue != nil {
+		l.queue.Clear()
+	}
+}
+
+// target returns whether logging is on and the custom endpoint to send to,
+// empty for the default server.
+func (l *EventLogger) target() (bool, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enabled, l.endpoint
+}
+
+func (l *EventLogger) LogAsync(payload map[string]interface{}) {
+	if enabled, _ := l.target(); !enabled {
+		return
+	}
+	l.queue.Push(payload)
+}
+
+func (l *EventLogger) Save() {
+	l.mu.Lock()
+	q := l.queue
+	l.mu.Unlock()
+	if q != nil {
+		q.Save()
+	}
+}
+
+func (l *EventLogger) send(payload map[string]interface{}) error {
+	_, endpoint := l.target()
+	if endpoint == "" {
+		return logging.Log(gameName, payload)
+	}
+	return l.post(endpoint+"/log", map[string]interface{}{
+		"game_name": gameName,
+		"payload":   payload,
+	})
+}
+
+func (l *EventLogger) post(url string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	h := hmac.New(sha256.New, []byte(l.secret))
+	h.Write(b)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+hex.EncodeToString(h.Sum(nil)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error response from game logging server: %s", resp.Status)
+	}
+
+	return nil
+}
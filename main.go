@@ -1,16 +1,16 @@
 package main
 
 import (
-	"embed"
+	"flag"
 	"fmt"
-	"image"
 	"image/color"
-	_ "image/png"
-	"io/ioutil"
+	"io/fs"
 	"log"
 	"math"
 	"math/rand"
 	"os"
+	"runtime"
+	"runtime/debug"
 	"strconv"
 	"time"
 
@@ -19,113 +19,164 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
-	logging "github.com/tsujio/game-logging-server/client"
 	"golang.org/x/image/font"
-	"golang.org/x/image/font/opentype"
 )
 
 const (
-	gameName                      = "birdman"
-	screenWidth                   = 640
-	screenHeight                  = 480
-	birdmanHeight                 = 100
-	birdmanWidth                  = 100
-	birdHeight                    = 100
-	birdWidth                     = 100
-	birdmanAndBirdCollisionRadius = 50
-	initialBirdmanPosY            = screenHeight / 3
-	cliffWidth                    = 100
-	titleFontSize                 = regularFontSize * 1.5
-	regularFontSize               = 24
-	smallFontSize                 = regularFontSize / 2
+	gameName              = "birdman"
+	screenWidth           = 640
+	screenHeight          = 480
+	birdmanHeight         = 100
+	birdmanWidth          = 100
+	birdHeight            = 100
+	birdWidth             = 100
+	initialBirdmanPosY    = screenHeight / 3
+	cliffWidth            = 100
+	titleFontSize         = regularFontSize * 1.5
+	regularFontSize       = 24
+	smallFontSize         = regularFontSize / 2
+	simulationRate        = 60
+	simulationStep        = 1.0 / simulationRate
+	collisionGridCellSize = 128
+	initialBirdCapacity   = 16
+	damageShakeStrength   = 6
+	damageShakeDuration   = 0.3
 )
 
-//go:embed resources/*.ttf resources/*.png resources/*.dat resources/secret
-var resources embed.FS
-
 var (
-	seaImg                            = loadImage("resources/sea.png")
-	cliffImg                          = loadImage("resources/cliff.png")
-	backgroundImg                     = loadImage("resources/background.png")
-	birdmanImg                        = loadImage("resources/birdman.png")
-	birdImg                           = loadImage("resources/bird.png")
-	titleFont, regularFont, smallFont = loadFont("resources/PressStart2P-Regular.ttf")
+	seaImg                            *ebiten.Image
+	cliffImg                          *ebiten.Image
+	backgroundImg                     *ebiten.Image
+	birdmanImg                        *ebiten.Image
+	birdImg                           *ebiten.Image
+	birdmanFrames, birdFrames         []*ebiten.Image
+	titleFont, regularFont, smallFont font.Face
 	audioContext                      = audio.NewContext(48000)
-	damageAudioData                   = loadAudioData("resources/魔王魂  レトロ22.mp3.dat", audioContext)
-	gameOverAudioData                 = loadAudioData("resources/魔王魂  レトロ12.mp3.dat", audioContext)
-	flyingAudioData                   = loadAudioData("resources/魔王魂 効果音 羽音01.mp3.dat", audioContext)
+	damageAudioData                   []byte
+	gameOverAudioData                 []byte
+	flyingAudioData                   []byte
+	flyingSound                       *Sound
+	whooshAudioData                   []byte
+	warningAudioData                  []byte
+	popAudioData                      []byte
+	ringAudioData                     []byte
+	chirpAudioData                    []byte
+	menuMoveAudioData                 []byte
+	menuSelectAudioData               []byte
+	focusAudioData                    []byte
 )
 
-func loadImage(name string) *ebiten.Image {
-	f, err := resources.Open(name)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-	img, _, err := image.Decode(f)
-	if err != nil {
-		log.Fatal(err)
+const fontName = "PressStart2P-Regular.ttf"
+
+// newAssetLoader lists the steps filling the package-level assets from the
+// asset manager. Every asset is tried, and AssetErrors lists all of those
+// that failed.
+func newAssetLoader(m *AssetManager) *AssetLoader {
+	l := &AssetLoader{}
+
+	images := []struct {
+		dst  **ebiten.Image
+		name string
+	}{
+		{&seaImg, "sea.png"},
+		{&cliffImg, "cliff.png"},
+		{&backgroundImg, "background.png"},
+		{&birdmanImg, "birdman.png"},
+		{&birdImg, "bird.png"},
 	}
-	return ebiten.NewImageFromImage(img)
-}
-
-func loadFont(name string) (titleFont, regularFont, smallFont font.Face) {
-	f, err := resources.Open(name)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-	fontData, err := ioutil.ReadAll(f)
-	if err != nil {
-		log.Fatal(err)
-	}
-	tt, err := opentype.Parse(fontData)
-	if err != nil {
-		log.Fatal(err)
+	for _, i := range images {
+		i := i
+		l.add(func() error {
+			img, err := m.GetImage(i.name)
+			if err != nil {
+				return err
+			}
+			*i.dst = img
+			return nil
+		})
 	}
 
-	const dpi = 72
-	titleFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    titleFontSize,
-		DPI:     dpi,
-		Hinting: font.HintingFull,
-	})
-	if err != nil {
-		log.Fatal(err)
+	fonts := []struct {
+		dst  *font.Face
+		size float64
+	}{
+		{&titleFont, titleFontSize},
+		{&regularFont, regularFontSize},
+		{&smallFont, smallFontSize},
 	}
-	regularFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    regularFontSize,
-		DPI:     dpi,
-		Hinting: font.HintingFull,
+	l.add(func() error {
+		for _, f := range fonts {
+			face, err := m.GetFontFace(fontName, f.size)
+			if err != nil {
+				// The other sizes fail the same way
+				return err
+			}
+			*f.dst = face
+		}
+		return nil
 	})
-	if err != nil {
-		log.Fatal(err)
+
+	sounds := []struct {
+		dst  *[]byte
+		name string
+	}{
+		{&damageAudioData, "魔王魂  レトロ22.mp3"},
+		{&gameOverAudioData, "魔王魂  レトロ12.mp3"},
+		{&flyingAudioData, "魔王魂 効果音 羽音01.mp3"},
 	}
-	smallFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    smallFontSize,
-		DPI:     dpi,
-		Hinting: font.HintingFull,
-	})
-	if err != nil {
-		log.Fatal(err)
+	for _, s := range sounds {
+		s := s
+		l.add(func() error {
+			data, err := m.GetAudio(s.name)
+			if err != nil {
+				return err
+			}
+			*s.dst = data
+			return nil
+		})
 	}
 
-	return
-}
+	// The images drawn by code
+	l.add(func() error {
+		airplaneImg = newAirplaneImage()
+		balloonImg = newBalloonImage()
+		fishImg = newFishImage()
+		touchButtonImg = newCircleImage(64, color.White)
+		menuCursorImg = newArrowImage(regularFontSize/3, color.White)
+		boosterImg = newCircleImage(boosterRadius, boosterColor)
+		boosterCoreImg = newCircleImage(boosterRadius/2, boosterCoreColor)
+		spaceStarImg = newSparkleImage(spaceStarRadius, spaceStarColor)
+		warningArrowImg = newArrowImage(warningArrowSize, warningColor)
+		spotlightImg = newSpotlightImage(spotlightRadius)
+		sceneImages = newSceneImages()
+		biomeArts = newBiomeArts()
+		return nil
+	})
 
-func loadAudioData(name string, audioContext *audio.Context) []byte {
-	f, err := resources.Open(name)
-	if err != nil {
-		log.Fatal(err)
+	l.finish = func() {
+		birdmanFrames = spriteFrames(birdmanImg, birdmanWidth, birdmanHeight)
+		birdFrames = spriteFrames(birdImg, birdWidth, birdHeight)
+
+		flyingSound = NewSound(flyingAudioData, 0.2, 0.92, 0.96, 1.0, 1.04, 1.08)
+		whooshAudioData = newWhooshData(audioContext.SampleRate(), 0.8)
+		warningAudioData = newBeepData(audioContext.SampleRate(), 1320, 0.08)
+		popAudioData = newWhooshData(audioContext.SampleRate(), 0.15)
+		ringAudioData = newBeepData(audioContext.SampleRate(), 1760, 0.12)
+		chirpAudioData = newBeepData(audioContext.SampleRate(), 2640, 0.05)
+		menuMoveAudioData = newBeepData(audioContext.SampleRate(), 880, 0.04)
+		menuSelectAudioData = newBeepData(audioContext.SampleRate(), 1320, 0.1)
+		cheerAudioData = newCheerData(audioContext.SampleRate(), 1.5)
+		focusAudioData = newBeepData(audioContext.SampleRate(), 440, 0.25)
 	}
-	defer f.Close()
+	return l
+}
 
-	data, err := ioutil.ReadAll(f)
-	if err != nil {
-		log.Fatal(err)
+// loadAssets loads all the assets at once, e.g. when reloading them.
+func loadAssets(m *AssetManager) error {
+	l := newAssetLoader(m)
+	for !l.Step() {
 	}
-
-	return data
+	return l.Err()
 }
 
 func formatIntComma(n int) string {
@@ -140,383 +191,1098 @@ func formatIntComma(n int) string {
 	return ret
 }
 
-type BirdmanState int
+type Mode int
 
 const (
-	StateRunning BirdmanState = iota
-	StateFlying
-	StateDamaged
+	ModeTitle Mode = iota
+	ModeGame
+	ModeGameOver
+	ModeSettings
+	ModeControls
+	ModeStats
+	ModeCredits
+	ModeSync
+	ModeConsent
+	ModePrivacy
+	ModeCheats
+	ModePractice
+	ModeShop
+	ModeScenery
+	ModeSpeedrun
+	ModeRace
+	ModeResults
+	ModeModifiers
+	ModeParty
+	ModeStandings
+	ModeChallenge
+	ModeCutscene
+	ModeEnding
+	ModeLeaderboard
 )
 
-type Birdman struct {
-	img          *ebiten.Image
-	state        BirdmanState
-	x, y         int
-	vy           int
-	damagedCount int
-	damagedTicks int
+const (
+	pausedRetryButtonY   = 320
+	gameOverRetryButtonY = 468
+	retryText            = "RETRY (R)"
+)
+
+type Game struct {
+	playerID        string
+	seed            int64
+	sessionStart    time.Time
+	playID          string
+	initializeCount int
+	mode            Mode
+	birdman         *Birdman
+	birds           []Bird
+	camera          Camera
+	nextBirdX       float64
+	flocks          []Flock
+	nextFlockX      float64
+	nextFlockID     int
+	airplanes       []Airplane
+	fish            []Fish
+	splashes        []Splash
+	featherPuffs    []FeatherPuff
+	balloons        []Balloon
+	nextBalloonX    float64
+	rings           []Ring
+	nextRingX       float64
+	feathers        []Feather
+	breadcrumbs     []Breadcrumb
+	crumbs          []Crumb
+	nextBreadcrumbX float64
+	carriedCrumbs   int
+	groove          float64
+	companion       *Companion
+	nextFeatherX    float64
+	lastRingY       float64
+	ringChain       int
+	boosters        []Booster
+	nextBoosterX    float64
+	boosterCount    int
+	spaceTime       float64
+	spaceElapsed    float64
+	spaceStars      []SpaceStar
+	currentBiome    *Biome
+	launch          *Launch
+	commentary      Commentary
+	coach           Coach
+	nextCheer       int
+	passedBest      bool
+	biomeBannerTime float64
+	nextSpaceStarX  float64
+	tricks          TrickDetector
+	popups          Popups
+	splits          SplitTimer
+	checkpoints     Checkpoints
+	stats           RunStats
+	stylePoints     int
+	rand            *rand.Rand
+	collisionGrid   *SpatialGrid
+	debugHitboxes   bool
+	debugOverlay    DebugOverlay
+	profiler        *FrameProfiler
+	input           *Input
+	controller      Controller
+	paused          bool
+	settings        *Settings
+	records         *Records
+	storage         Storage
+	haptics         Haptics
+	viewport        *Viewport
+	backdrop        *Backdrop
+	audio           *AudioManager
+	sfx             AudioSink
+	music           *MusicManager
+	ambience        *Ambience
+	config          *GameConfig
+	logger          Logger
+	// posts a message to a webhook, nil to post nothing
+	webhook func(url, message string)
+	// nil in tests and headless runs
+	eventLogger     *EventLogger
+	syncer          ScoreSyncer
+	sync            CloudSync
+	timeScale       float64
+	stepAccumulator float64
+	focusMeter      float64
+	focusTime       float64
+	lastUpdate      time.Time
+	suspended       bool
+	assets          *AssetManager
+	assetWatcher    *AssetWatcher
+	titleMenu       *Menu
+	settingsMenu    *Menu
+	controlsMenu    *Menu
+	statsMenu       *Menu
+	creditsMenu     *Menu
+	syncMenu        *Menu
+	leaderboard     Leaderboard
+	leaderboardMenu *Menu
+	consentMenu     *Menu
+	privacyMenu     *Menu
+	cheatMenu       *Menu
+	practiceMenu    *Menu
+	speedrunMenu    *Menu
+	raceMenu        *Menu
+	shopMenu        *Menu
+	sceneryMenu     *Menu
+	hearts          int
+	continues       int
+	// the runs start at practiceDistance until back to the title
+	practice         bool
+	practiceDistance int
+	cheats           Cheats
+	cheatCode        KeySequence
+	cheatsUnlocked   bool
+	urlEntry         TextEntry
+	urlEntryTarget   *string
+	rebinding        Rebinding
+	idle             bool
+	idleTime         float64
+	inputHistory     InputHistory
+	exportStatus     string
+	// the runs race to speedrunTarget in meters until back to the title, 0
+	// for none, splitting at every checkpoint
+	speedrunTarget int
+	speedrunSplits int
+	// the runs are races to speedrunTarget, which is raceDistance
+	racing       bool
+	raceDistance int
+	fireworks    *Fireworks
+	// the world is drawn on to be flipped in the mirror mode
+	mirrorLayer *ebiten.Image
+	// the lightning and the dark over the world in the night mode
+	night         Night
+	darknessLayer *ebiten.Image
+	// the modifiers on by id, which change config from baseConfig and scale
+	// the birds spawned by birdScale
+	modifiers     map[string]bool
+	baseConfig    *GameConfig
+	birdScale     float64
+	modifiersMenu *Menu
+	party         Party
+	partyMenu     *Menu
+	// the random source is seeded with runSeed at the start of every run,
+	// which is the challenge's while one is played
+	runSeed          uint32
+	challenge        *Challenge
+	enteredChallenge *Challenge
+	lastChallenge    *Challenge
+	challengeStatus  string
+	challengeEntry   TextEntry
+	challengeMenu    *Menu
+	// the date of the daily run while one is played
+	daily string
+	// the one played in ModeCutscene, and whether the intro was this session
+	cutscene    *Cutscene
+	introPlayed bool
+	// the new game+ loop played, 0 for the game itself
+	loop       int
+	endingMenu *Menu
+	// nil unless timing the speedruns from outside the game
+	autosplitter Autosplitter
+	// nil unless streaming
+	stream *StreamOverlay
 }
 
-func (b *Birdman) Draw(screen *ebiten.Image, game *Game) {
-	switch b.state {
-	case StateRunning:
-		img := b.img.SubImage(image.Rect(
-			0,
-			0,
-			birdmanWidth,
-			birdmanHeight,
-		)).(*ebiten.Image)
-		x := float64(b.x-game.cameraX) - float64(birdmanWidth)/2
-		y := float64(b.y) - float64(birdmanHeight)/2
-		opt := &ebiten.DrawImageOptions{}
-		opt.GeoM.Translate(x, y)
-		screen.DrawImage(img, opt)
-	case StateFlying:
-		frameIndex := b.x / 10 % 2
-		img := b.img.SubImage(image.Rect(
-			birdmanWidth*frameIndex,
-			0,
-			birdmanWidth*(frameIndex+1),
-			birdmanHeight,
-		)).(*ebiten.Image)
-		x := float64(b.x-game.cameraX) - float64(birdmanWidth)/2
-		y := float64(b.y) - float64(birdmanHeight)/2
-		opt := &ebiten.DrawImageOptions{}
-		opt.GeoM.Translate(x, y)
-		screen.DrawImage(img, opt)
-	case StateDamaged:
-		img := b.img.SubImage(image.Rect(
-			birdmanWidth,
-			0,
-			birdmanWidth*2,
-			birdmanHeight,
-		)).(*ebiten.Image)
-		x := float64(b.x - game.cameraX)
-		y := float64(b.y)
-		opt := &ebiten.DrawImageOptions{}
-		opt.GeoM.Translate(-float64(birdmanWidth)/2, -float64(birdmanHeight)/2)
-		opt.GeoM.Rotate(float64(b.damagedTicks) / 3)
-		opt.GeoM.Translate(x, y)
-		screen.DrawImage(img, opt)
+// NewGame creates a game with the dependencies of its simulation. Frontend
+// parts (input, viewport, music players, ...) are set by the caller.
+func NewGame(config *GameConfig, settings *Settings, src rand.Source, sfx AudioSink, logger Logger) *Game {
+	return &Game{
+		cheats:           DefaultCheats(),
+		practiceDistance: minPracticeDistance,
+		raceDistance:     defaultRaceDistance,
+		fireworks:        NewFireworks(),
+		settings:         settings,
+		sessionStart:     time.Now(),
+		records:          &Records{},
+		config:           config,
+		baseConfig:       config,
+		rand:             rand.New(src),
+		sfx:              sfx,
+		logger:           logger,
+		music:            NewMusicManager(audioContext.SampleRate()),
+		collisionGrid:    NewSpatialGrid(collisionGridCellSize),
+		birds:            make([]Bird, 0, initialBirdCapacity),
 	}
 }
 
-type Bird struct {
-	img  *ebiten.Image
-	x, y int
+// isTextButtonTapped reports whether the label drawn centered in the small
+// font at y was tapped.
+func (g *Game) isTextButtonTapped(label string, buttonY int) bool {
+	x, y, ok := g.input.JustTappedPosition()
+	if !ok {
+		return false
+	}
+	w := len(label) * smallFontSize
+	return y >= buttonY-smallFontSize*2 && y < buttonY+smallFontSize &&
+		x >= screenWidth/2-w/2-smallFontSize && x < screenWidth/2+w/2+smallFontSize
 }
 
-func (b *Bird) Draw(screen *ebiten.Image, game *Game) {
-	frameIndex := b.x / 10 % 2
-	img := b.img.SubImage(image.Rect(
-		birdWidth*frameIndex,
-		0,
-		birdWidth*(frameIndex+1),
-		birdHeight,
-	)).(*ebiten.Image)
-	x := float64(b.x-game.cameraX) - float64(birdWidth)/2
-	y := float64(b.y) - float64(birdHeight)/2
-	opt := &ebiten.DrawImageOptions{}
-	opt.GeoM.Translate(x, y)
-	screen.DrawImage(img, opt)
+// isRetryButtonTapped is for the game over screen and the pause screen, where
+// R is free from rolling. A party turn can't be retried, which would throw a
+// bad turn away.
+func (g *Game) isRetryButtonTapped(buttonY int) bool {
+	if g.party.active {
+		return false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		return true
+	}
+	return g.isTextButtonTapped(retryText, buttonY)
 }
 
-type Mode int
+func (g *Game) musicIntensity() int {
+	if g.mode != ModeGame {
+		return musicLayerBass
+	}
+	switch x := g.birdman.x; {
+	case x < 1000:
+		return musicLayerDrums
+	case x < 2000:
+		return musicLayerArpeggio
+	default:
+		return musicLayerLead
+	}
+}
 
-const (
-	ModeTitle Mode = iota
-	ModeGame
-	ModeGameOver
-)
+func (g *Game) gameOver() {
+	switch {
+	case g.stats.landed:
+		g.stats.cause = CauseLanded
+	case g.stats.finished:
+		g.stats.cause = CauseFinished
+	case g.birdman.state == StateDamaged:
+		g.stats.cause = g.stats.lastDamage
+	}
+	if !g.stats.finished {
+		g.autosplit(Autosplitter.Reset)
+	}
+	g.logEvent(GameOverEvent{
+		X:            int(g.birdman.x),
+		DamagedCount: g.birdman.damagedCount,
+		StylePoints:  g.stylePoints,
+		Cause:        g.stats.cause.String(),
+	})
 
-type Game struct {
-	playerID         string
-	playID           string
-	initializeCount  int
-	mode             Mode
-	birdman          *Birdman
-	birds            []Bird
-	cameraX, cameraY int
+	g.mode = ModeGameOver
+	g.vibrate(seaVibration, seaVibrationPower)
+	g.stats.previousBest = g.records.BestDistance
+	g.stats.previousBestTime = g.records.BestTimes[g.speedrunTarget]
+	if g.stats.recorded() {
+		if g.daily != "" {
+			g.updateDailyBest()
+		}
+		g.updateRecords()
+	}
+	g.logRunSummary()
+	if g.records.BestDistance > g.stats.previousBest {
+		g.uploadBest()
+		g.notifyNewBest()
+	}
+	if g.challenge != nil {
+		g.stats.challengeResult = g.challengeComparison()
+		// The retries of the daily run are against the best of the day
+		if g.daily != "" {
+			g.challenge.Result = g.records.DailyBest
+		}
+	}
+	if c := g.runChallenge(); c != nil {
+		g.lastChallenge = c
+	}
+	if g.party.active {
+		g.endPartyTurn()
+	}
+	if g.racing && g.stats.finished {
+		g.mode = ModeResults
+		g.fireworks.Reset()
+		if g.stats.recorded() {
+			g.uploadRaceTime()
+		}
+	}
+	// The results, the standings and the ending celebrate on their own
+	if g.stats.landed {
+		return
+	}
+	if c := g.milestoneVignette(); c != nil && g.mode == ModeGameOver {
+		g.playCutscene(c)
+	}
+
+	g.sfx.PlaySE(gameOverAudioData)
 }
 
-func (g *Game) isJustTapped() bool {
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		return true
+// restart starts a new run straight away, skipping the title.
+func (g *Game) restart() {
+	from := "game_over"
+	if g.mode == ModeGame {
+		from = "pause"
 	}
-	if touchIDs := inpututil.JustPressedTouchIDs(); len(touchIDs) > 0 {
-		return true
+	g.logEvent(RestartEvent{From: from, X: int(g.birdman.x)})
+
+	g.initialize()
+	g.startGame()
+}
+
+func (g *Game) startGame() {
+	g.logEvent(StartGameEvent{})
+
+	g.mode = ModeGame
+	g.nextRunSeed()
+	g.applyModifiers()
+	g.stats.cheated = g.cheats.Active()
+	g.stats.practice = g.practice
+	g.stats.modifiers = g.activeModifiers()
+	g.stats.gapScale = g.gapScale()
+	g.resetCompanion()
+	g.dealUpgrades()
+	g.stats.upgraded = g.runUpgraded()
+	g.stats.rhythm = g.rhythmOn()
+	g.autosplit(Autosplitter.Start)
+	switch {
+	case g.practice:
+		g.warpTo(g.practiceDistance)
+	case g.cheats.StartDistance > 0:
+		g.warpTo(g.cheats.StartDistance)
 	}
-	return false
 }
 
 func (g *Game) Update() error {
 	birdman := g.birdman
 
+	g.profiler.Tick()
+	g.input.Update()
+	g.inputHistory.Update(g.input)
+	g.updateLifecycle(time.Now(), ebiten.IsFocused())
+	g.updateWindow()
+	g.reloadChangedAssets()
+	g.updateDebug()
+	g.debugOverlay.Update()
+	g.updateIdle(g.input.IsActive())
+	g.updateSync()
+	g.updateStream()
+	g.audio.Update(g.typing())
+	g.music.SetIntensity(g.musicIntensity())
+	if g.mode == ModeGame {
+		g.ambience.SetAltitude(birdman.y)
+	} else {
+		g.ambience.SetGains(0.3, 0)
+	}
+
 	switch g.mode {
 	case ModeTitle:
-		if g.isJustTapped() {
-			logging.LogAsync(gameName, map[string]interface{}{
-				"player_id": g.playerID,
-				"play_id":   g.playID,
-				"action":    "start_game",
-			})
-
-			g.mode = ModeGame
+		g.updateCheatCode()
+		if g.mode != ModeTitle {
+			break
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			g.mode = ModeSettings
+		} else {
+			g.titleMenu.Update(g.input)
 		}
 	case ModeGame:
-		switch birdman.state {
-		case StateRunning:
-			birdman.x += 1
-			if birdman.x >= 0 {
-				birdman.state = StateFlying
+		g.updateFreeCamera()
+		if g.paused {
+			if g.isRetryButtonTapped(pausedRetryButtonY) {
+				g.restart()
+				return nil
 			}
-		case StateFlying:
-			// Camera move
-			g.cameraX += 1
-
-			// Birds appearance
-			if birdman.x%200 == 0 {
-				b := Bird{
-					img: birdImg,
-					x:   birdman.x + screenWidth,
-					y:   50 + rand.Int()%(screenHeight-100),
-				}
-				g.birds = append(g.birds, b)
+			if g.input.IsJustTapped() || g.input.IsPauseJustPressed() {
+				g.paused = false
+				g.input.ClearFlapBuffer()
 			}
+			return nil
+		}
+		// Losing the focus pauses too, see updateLifecycle
+		if g.input.IsPauseJustPressed() {
+			g.paused = true
+			g.input.ClearFlapBuffer()
+			return nil
+		}
 
-			// Birds move
-			var newBirds []Bird
-			for i := 0; i < len(g.birds); i++ {
-				g.birds[i].x -= 1
-				if g.birds[i].x+birdWidth > g.cameraX {
-					newBirds = append(newBirds, g.birds[i])
-				}
-			}
-			g.birds = newBirds
-
-			// User input
-			if g.isJustTapped() {
-				var ay int
-				if birdman.x < 1000 {
-					ay = -20
-				} else if birdman.x < 2000 {
-					ay = -15
-				} else if birdman.x < 3000 {
-					ay = -10
-				} else if birdman.x < 4000 {
-					ay = -7
-				} else {
-					ay = -5
-				}
-				ay /= birdman.damagedCount + 1
-				birdman.vy += ay
-
-				audio.NewPlayerFromBytes(audioContext, flyingAudioData).Play()
-			}
+		// Run the simulation at a fixed rate independent of TPS
+		g.stepAccumulator += g.timeScale / float64(ebiten.MaxTPS())
+		for g.stepAccumulator >= simulationStep && g.mode == ModeGame {
+			g.stepAccumulator -= simulationStep
+			g.simulate()
+		}
+	case ModeGameOver:
+		if g.isRetryButtonTapped(gameOverRetryButtonY) {
+			g.restart()
+		} else if g.input.IsJustTapped() {
+			g.challenge, g.daily = nil, ""
+			g.initialize()
+		}
+	case ModeSettings:
+		g.settingsMenu.Update(g.input)
+	case ModeControls:
+		g.updateControls()
+	case ModeStats:
+		g.statsMenu.Update(g.input)
+	case ModeLeaderboard:
+		g.updateLeaderboard()
+	case ModeCredits:
+		g.creditsMenu.Update(g.input)
+	case ModeSync:
+		g.updateSyncScreen()
+	case ModeConsent:
+		g.consentMenu.Update(g.input)
+	case ModePrivacy:
+		g.updatePrivacy()
+	case ModeCheats:
+		g.cheatMenu.Update(g.input)
+	case ModePractice:
+		g.practiceMenu.Update(g.input)
+	case ModeShop:
+		g.shopMenu.Update(g.input)
+	case ModeScenery:
+		g.sceneryMenu.Update(g.input)
+	case ModeSpeedrun:
+		g.speedrunMenu.Update(g.input)
+	case ModeRace:
+		g.raceMenu.Update(g.input)
+	case ModeResults:
+		g.updateResults()
+	case ModeModifiers:
+		g.modifiersMenu.Update(g.input)
+	case ModeParty:
+		g.updatePartyMenu()
+	case ModeStandings:
+		g.updateStandings()
+	case ModeChallenge:
+		g.updateChallengeScreen()
+	case ModeCutscene:
+		g.updateCutscene()
+	case ModeEnding:
+		g.updateEndingScreen()
+	}
 
-			// Birdman gravity
-			birdman.vy += 1
-			if birdman.vy > 5 {
-				birdman.vy = 5
-			}
+	return nil
+}
 
-			// Birdman move
-			birdman.x += 1
-			birdman.y += birdman.vy
+// findCollidingBird returns the index of a bird overlapping the birdman, or
+// -1 if there is none.
+func (g *Game) findCollidingBird() int {
+	g.collisionGrid.Clear()
+	for i := range g.birds {
+		minX, minY, maxX, maxY := g.birds[i].hitbox().Bounds(g.birds[i].x, g.birds[i].y)
+		g.collisionGrid.Insert(i, minX, minY, maxX, maxY)
+	}
 
-			// Birdman too high
-			if birdman.y < 0 {
-				birdman.damagedCount += 1
-				birdman.state = StateDamaged
+	birdman := g.birdman
+	hitbox := birdman.hitbox()
+	minX, minY, maxX, maxY := hitbox.Bounds(birdman.x, birdman.y)
+	found := -1
+	g.collisionGrid.Query(minX, minY, maxX, maxY, func(i int) bool {
+		b := &g.birds[i]
+		if b.isHazard() && Collides(hitbox, birdman.x, birdman.y, b.hitbox(), b.x, b.y) {
+			found = i
+			return false
+		}
+		return true
+	})
+	return found
+}
 
-				audio.NewPlayerFromBytes(audioContext, damageAudioData).Play()
-			}
+// removeBirdsBehindCamera drops the birds which have left the screen, behind
+// or down into the sea, compacting the slice in place so that it never needs
+// to be reallocated.
+func (g *Game) removeBirdsBehindCamera() {
+	n := 0
+	for i := range g.birds {
+		if g.birds[i].x+birdWidth > g.camera.x && !g.birds[i].fallen() {
+			g.birds[n] = g.birds[i]
+			n++
+		}
+	}
+	for i := n; i < len(g.birds); i++ {
+		g.birds[i] = Bird{}
+	}
+	g.birds = g.birds[:n]
+}
 
-			// Birdman and birds collision
-			for i := 0; i < len(g.birds); i++ {
-				if math.Pow(float64(birdman.x-g.birds[i].x), 2)+math.Pow(float64(birdman.y-g.birds[i].y), 2) <
-					math.Pow(birdmanAndBirdCollisionRadius, 2) {
-					birdman.damagedCount += 1
-					birdman.state = StateDamaged
+func (g *Game) damageBirdman(cause DeathCause) {
+	if g.cheats.Invincible || g.loseHeart() {
+		return
+	}
+	birdman := g.birdman
+	birdman.damagedCount += 1
+	g.stats.damage++
+	g.stats.lastDamage = cause
+	g.stats.damages = append(g.stats.damages, DamagePosition{
+		Cause: cause.String(),
+		X:     int(birdman.x),
+		Y:     int(birdman.y),
+	})
+	birdman.state = StateDamaged
+	birdman.vx *= 1 - g.config.DamageSpeedLoss
+	birdman.cancelRoll()
+	birdman.draftTime = 0
+	birdman.boostTime = 0
+	birdman.knockbackVx = -g.config.KnockbackSpeed
+	birdman.vy = g.config.KnockbackFallSpeed
+
+	g.sfx.PlaySE(damageAudioData)
+	g.music.DropToSparse()
+	g.camera.Shake(damageShakeStrength, damageShakeDuration)
+	g.vibrate(damageVibration, damageVibrationPower)
+}
 
-					audio.NewPlayerFromBytes(audioContext, damageAudioData).Play()
+// simulate advances the run by one fixed simulation step.
+func (g *Game) simulate() {
+	birdman := g.birdman
 
-					break
-				}
+	switch birdman.state {
+	case StateRunning:
+		g.updateRunUp()
+	case StateFlying:
+		// Birds appearance, though none fly in space
+		if !g.inSpace() {
+			if birdman.x >= g.config.FlockStartX && birdman.x >= g.nextFlockX {
+				g.nextFlockX = birdman.x + g.config.FlockInterval/g.config.DifficultyAt(birdman.x).SpawnRate
+				g.spawnFlock()
+				// Keep single birds from crowding the formation
+				g.nextBirdX = birdman.x + g.birdSpawnInterval()
+			} else if birdman.x >= g.nextBirdX {
+				g.nextBirdX += g.birdSpawnInterval()
+				g.spawnHazard()
 			}
+		}
 
-			// Birdman fall
-			if birdman.y > screenHeight {
-				logging.LogAsync(gameName, map[string]interface{}{
-					"player_id":     g.playerID,
-					"play_id":       g.playID,
-					"action":        "game_over",
-					"x":             birdman.x,
-					"damaged_count": birdman.damagedCount,
-				})
-
-				g.mode = ModeGameOver
-
-				audio.NewPlayerFromBytes(audioContext, gameOverAudioData).Play()
+		// Birds move
+		g.moveBirds()
+		g.moveAirplanes()
+		g.removeBirdsBehindCamera()
+		g.removeEmptyFlocks()
+		g.updateFish()
+
+		// User input
+		birdman.heartLossTime = math.Max(0, birdman.heartLossTime-simulationStep)
+
+		flapped, strong := g.controller.ConsumeFlap()
+		if flapped {
+			ay := -g.flapPower() * g.rhythmFlap()
+			if strong {
+				ay *= g.config.StrongFlapMultiplier
 			}
-		case StateDamaged:
-			// Birds move
-			for i := 0; i < len(g.birds); i++ {
-				g.birds[i].x -= 1
+			ay /= float64(birdman.damagedCount + 1)
+			birdman.vy += ay
+			if strong {
+				birdman.vx += g.config.StrongFlapBoost
 			}
 
-			// Birdman move
-			birdman.damagedTicks += 1
-			birdman.vy = 0
-			birdman.y += 1
+			g.stats.flaps++
+			g.sfx.PlaySound(flyingSound)
+		}
+		g.updateFlapRecovery(flapped)
+		g.updateGroove()
 
-			if birdman.y > screenHeight {
-				logging.LogAsync(gameName, map[string]interface{}{
-					"player_id":     g.playerID,
-					"play_id":       g.playID,
-					"action":        "game_over",
-					"x":             birdman.x,
-					"damaged_count": birdman.damagedCount,
-				})
+		if g.controller.ConsumeRoll() {
+			birdman.startRoll()
+		}
+		g.updateFocus()
+		g.updateNight()
 
-				g.mode = ModeGameOver
+		// Birdman gravity and drag
+		gravity := g.config.Gravity
+		if g.inSpace() {
+			gravity *= g.config.SpaceGravityScale
+		}
+		terminalVy := g.config.MaxFallSpeed
+		diving := g.controller.IsDivePressed()
+		if diving {
+			gravity *= 2
+			terminalVy = g.config.DiveMaxFallSpeed * g.diveBoost()
+		} else if g.settings.TiltEnabled && deviceTilt.IsAvailable() {
+			terminalVy += deviceTilt.Value(g.settings.TiltOffset) * g.config.MaxFallSpeed * 0.6
+		}
+		birdman.vy += (gravity - g.config.Drag*birdman.vy) * simulationStep
+		if birdman.vy > terminalVy {
+			birdman.vy = terminalVy
+		}
 
-				audio.NewPlayerFromBytes(audioContext, gameOverAudioData).Play()
-			}
+		// Forward speed drifts back to the cruise speed, diving gains speed
+		// and headwinds cost it, though there is no wind in space
+		ax := (g.config.BirdmanSpeed - birdman.vx) * g.config.ForwardSpeedRecovery
+		if diving {
+			ax += g.config.DiveAcceleration * g.diveBoost()
+		}
+		if !g.inSpace() {
+			ax -= g.config.Headwind(birdman.x) + g.bandDrag()
+		}
+		birdman.vx += ax * simulationStep
+		birdman.vx = math.Max(g.config.MinForwardSpeed, math.Min(g.config.MaxForwardSpeed, birdman.vx))
+
+		// Birdman move
+		prevX, prevY := birdman.x, birdman.y
+		birdman.x += birdman.vx * simulationStep
+		birdman.y += birdman.vy * simulationStep
+		hitBasket := g.updateBalloons(prevY)
+		g.updateRings(prevX, prevY)
+		g.updateFeathers()
+		g.updateBreadcrumbs()
+		g.updateSpace()
+
+		// Birdman too high
+		if g.updateAltitudePressure() {
+			g.damageBirdman(CauseCeiling)
+		}
 
-			if birdman.damagedTicks%ebiten.MaxTPS() == 0 {
-				birdman.damagedTicks = 0
-				birdman.state = StateFlying
-			}
+		// Birdman and birds collision
+		if i := g.findCollidingBird(); i >= 0 {
+			g.strikeBird(i)
+			g.damageBirdman(CauseBird)
 		}
-	case ModeGameOver:
-		if g.isJustTapped() {
-			g.initialize()
+
+		// Birdman and fish collision
+		if g.birdman.state == StateFlying && g.collidesFish() {
+			g.damageBirdman(CauseFish)
+		}
+
+		// Birdman and airplanes collision
+		if g.birdman.state == StateFlying && g.collidesAirplane() {
+			g.damageBirdman(CauseAirplane)
+		}
+
+		// Birdman and balloon basket collision
+		if g.birdman.state == StateFlying && hitBasket {
+			g.damageBirdman(CauseBasket)
+		}
+
+		g.updateTricks()
+		g.updateSlipstream()
+		g.updateFlavor()
+
+		// Birdman fall, or skim over the sea when invincible
+		if birdman.y > screenHeight && g.cheats.Invincible {
+			birdman.y = screenHeight
+			birdman.vy = math.Min(birdman.vy, 0)
+		} else if birdman.y > screenHeight {
+			g.fallIntoSea()
+		}
+	case StateDamaged:
+		// Birds move
+		g.moveBirds()
+		g.moveAirplanes()
+		g.updateFish()
+		g.updateBalloons(birdman.y)
+
+		// Weak flaps slow the fall and shorten the spin
+		if ok, _ := g.controller.ConsumeFlap(); ok {
+			ay := -g.flapPower() * g.config.DamagedFlapMultiplier
+			birdman.vy += ay / float64(birdman.damagedCount+1)
+			birdman.damagedSkippedTicks += int(g.config.DamagedFlapRecovery * simulationRate)
+
+			g.stats.flaps++
+			g.sfx.PlaySound(flyingSound)
+		}
+
+		// Birdman move: the knockback fades out into a steady fall
+		birdman.damagedTicks += 1
+		damping := g.config.KnockbackDamping * simulationStep
+		birdman.knockbackVx -= birdman.knockbackVx * damping
+		birdman.vy += (g.config.DamagedFallSpeed - birdman.vy) * damping
+		birdman.x += birdman.knockbackVx * simulationStep
+		birdman.y += birdman.vy * simulationStep
+		if birdman.y < 0 {
+			birdman.y = 0
+			birdman.vy = 0
+		}
+
+		if birdman.y > screenHeight {
+			g.fallIntoSea()
+		}
+
+		if float64(birdman.damagedTicks+birdman.damagedSkippedTicks) >= g.config.DamagedDuration*simulationRate {
+			birdman.damagedTicks = 0
+			birdman.damagedSkippedTicks = 0
+			birdman.knockbackVx = 0
+			birdman.state = StateFlying
 		}
 	}
 
-	return nil
+	// Camera, which stays where it is moved to when free
+	switch {
+	case g.cheats.FreeCamera:
+	case birdman.state == StateFlying:
+		g.camera.Follow(birdman.x, birdman.y, birdman.vx, simulationStep)
+	case birdman.state == StateDamaged:
+		g.camera.Follow(birdman.x, birdman.y, birdman.knockbackVx, simulationStep)
+	}
+	g.updateCompanion()
+	g.updateCoach()
+	g.updateBiome()
+	g.biomeBannerTime = math.Max(0, g.biomeBannerTime-simulationStep)
+	g.camera.Update(simulationStep)
+	g.popups.Update(simulationStep)
+	g.updateFeatherPuffs()
+	g.updateSplits()
+	g.updateCheckpoints()
+	g.updateSpeedrun()
+	g.checkEnding()
+
+	// Animations
+	birdman.updateAnimation()
+	for i := 0; i < len(g.birds); i++ {
+		g.birds[i].animation.Update()
+	}
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	backgroundImgWidth, backgroundImgHeight := backgroundImg.Size()
-	seaImgWidth, seaImgHeight := seaImg.Size()
-
-	// Background sky
-	for i := -1; i < screenWidth/backgroundImgWidth+2; i++ {
-		backgroundImgOpt := &ebiten.DrawImageOptions{}
-		backgroundImgOpt.GeoM.Scale(
-			1.0,
-			float64(screenHeight-seaImgHeight)/float64(backgroundImgHeight),
-		)
-		backgroundImgOpt.GeoM.Translate(
-			float64(i*backgroundImgWidth-g.cameraX%backgroundImgWidth),
-			0,
-		)
-		screen.DrawImage(backgroundImg, backgroundImgOpt)
-	}
-
-	// Sea
-	for i := -1; i < screenWidth/seaImgWidth+2; i++ {
-		seaImgOpt := &ebiten.DrawImageOptions{}
-		seaImgOpt.GeoM.Translate(
-			float64(i*seaImgWidth-g.cameraX%seaImgWidth),
-			float64(screenHeight-seaImgHeight),
-		)
-		screen.DrawImage(seaImg, seaImgOpt)
-	}
-
-	// Cliff
-	cliffImgWidth, _ := cliffImg.Size()
-	cliffImgOpt := &ebiten.DrawImageOptions{}
-	cliffImgOpt.GeoM.Scale(cliffWidth/float64(cliffImgWidth), 1.0)
-	cliffImgOpt.GeoM.Translate(
-		float64(-cliffWidth-g.cameraX),
-		initialBirdmanPosY+birdmanHeight/3,
-	)
-	screen.DrawImage(cliffImg, cliffImgOpt)
+	g.viewport.canvas.Clear()
+	g.drawCanvas(g.viewport.canvas)
+	g.viewport.saturation = g.focusSaturation()
+	g.viewport.Draw(screen)
+
+	if g.mode == ModeGame {
+		g.input.DrawTouchButtons(screen)
+	}
+
+	g.debugOverlay.Draw(screen, g)
+	g.profiler.Draw(screen)
+}
+
+// drawWorld draws the world the birdman flies through under the texts.
+func (g *Game) drawWorld(screen *ebiten.Image) {
+	// Sky, sea and cliff
+	g.backdrop.Draw(screen, g.camera.ViewX(), g.camera.ViewY())
+	g.drawBiome(screen)
+	g.drawLaunch(screen)
+	g.drawIsland(screen)
+	g.drawBoats(screen)
+	g.drawCheckpointFlags(screen)
+	g.drawFinishLine(screen)
+	g.drawAltitudeZone(screen)
+	g.drawBands(screen)
+	g.drawSpace(screen)
 
 	// Birdman
-	g.birdman.Draw(screen, g)
+	g.drawSlipstreamTrail(screen)
+	g.drawCompanion(screen)
+	if !g.hidesBirdman() {
+		g.birdman.Draw(screen, g)
+	}
+	g.drawCutsceneActors(screen)
+
+	g.drawBalloons(screen)
+	g.drawRings(screen)
+	g.drawFeathers(screen)
+	g.drawBreadcrumbs(screen)
+	g.drawSpacePickups(screen)
 
 	// Birds
 	for i := 0; i < len(g.birds); i++ {
 		g.birds[i].Draw(screen, g)
 	}
+	g.drawFeatherPuffs(screen)
+	g.drawAirplanes(screen)
+	g.drawFish(screen)
+
+	g.drawDebugHitboxes(screen)
+}
+
+func (g *Game) drawCanvas(screen *ebiten.Image) {
+	if g.mirrored() {
+		g.drawMirrored(screen, g.drawWorld)
+	} else {
+		g.drawWorld(screen)
+	}
+	g.drawCheckpointLabels(screen)
+	g.popups.Draw(screen, g.camera.ViewX(), g.camera.ViewY(), g.mirrored())
+	g.drawDarkness(screen)
 
 	// Texts
-	record := g.birdman.x / 10
+	record := int(g.birdman.x) / 10
 	switch g.mode {
 	case ModeTitle:
-		titleText := "BIRDMAN CHALLENGE"
-		text.Draw(screen, titleText, titleFont, screenWidth/2-len(titleText)*titleFontSize/2, 90, color.White)
-		descriptionText := "CLICK TO START"
-		text.Draw(screen, descriptionText, regularFont, screenWidth/2-len(descriptionText)*regularFontSize/2, 170, color.White)
-
-		licenseTexts := []string{"CREATOR: NAOKI TSUJIO", "PHOTO: OITA-SHI (FIND/47)", "FONT: Press Start 2P by CodeMan38", "SOUND: MaouDamashii"}
-		for i, s := range licenseTexts {
-			text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, int(410+float32(i)*smallFontSize*1.7), color.White)
-		}
+		g.titleMenu.Draw(screen)
 	case ModeGame:
+		g.drawProgressBar(screen)
+		g.drawThreatWarnings(screen)
+		g.drawMinimap(screen)
 		recordText := fmt.Sprintf("%sm", formatIntComma(record))
 		text.Draw(screen, recordText, smallFont, 24, 24, color.White)
+		// 10px is 1m
+		speedText := fmt.Sprintf("%dkm/h", int(g.birdman.vx/10*3.6))
+		text.Draw(screen, speedText, smallFont, 24, 24+smallFontSize*2, color.White)
+		if g.stylePoints > 0 {
+			styleText := fmt.Sprintf("STYLE %s", formatIntComma(g.stylePoints))
+			text.Draw(screen, styleText, smallFont, 24, 24+smallFontSize*4, color.White)
+		}
+		flapText := fmt.Sprintf("FLAP %d%%", int(math.Round(g.flapPower()/g.config.FullFlapPower()*100)))
+		text.Draw(screen, flapText, smallFont, 24, 24+smallFontSize*6, color.White)
+		hudY := 24 + smallFontSize*8
+		if g.hearts > 0 {
+			text.Draw(screen, fmt.Sprintf("HEARTS %d", g.hearts), smallFont, 24, hudY, color.White)
+			hudY += smallFontSize * 2
+		}
+		if g.continues > 0 {
+			text.Draw(screen, fmt.Sprintf("CONTINUES %d", g.continues), smallFont, 24, hudY, color.White)
+			hudY += smallFontSize * 2
+		}
+		if g.carriedCrumbs > 0 {
+			text.Draw(screen, fmt.Sprintf("CRUMBS %d", g.carriedCrumbs), smallFont, 24, hudY, color.White)
+			hudY += smallFontSize * 2
+		}
+		drawMeter(screen, "FOCUS", hudY, g.focusMeter, g.focusMeterColor())
+		hudY += smallFontSize * 2
+		if g.rhythmOn() {
+			g.drawGroove(screen, hudY)
+			hudY += smallFontSize * 2
+		}
+		if g.stats.practice {
+			text.Draw(screen, "PRACTICE", smallFont, 24, hudY, color.White)
+		} else if g.party.active {
+			text.Draw(screen, g.party.players[g.party.turn].Name, smallFont, 24, hudY, color.White)
+		} else if g.loop > 0 {
+			text.Draw(screen, fmt.Sprintf("NEW GAME+ %d", g.loop), smallFont, 24, hudY, color.White)
+		}
+		if name, points, combo, fade, ok := g.trickBanner(); ok {
+			clr := color.RGBA{0xff, 0xff, 0x80, uint8(0xff * (1 - fade*fade))}
+			text.Draw(screen, name, regularFont, screenWidth/2-len(name)*regularFontSize/2, 150-int(fade*20), clr)
+			pointsText := fmt.Sprintf("+%d", points)
+			if combo > 1 {
+				pointsText = fmt.Sprintf("+%d x%d COMBO", points, combo)
+			}
+			text.Draw(screen, pointsText, smallFont, screenWidth/2-len(pointsText)*smallFontSize/2, 150+regularFontSize*2-int(fade*20), clr)
+		}
+		g.drawBiomeBanner(screen)
+		g.commentary.Draw(screen)
+		g.drawCoach(screen)
+		if splitText, clr, ok := g.splitText(); ok {
+			text.Draw(screen, splitText, smallFont, screenWidth/2-len(splitText)*smallFontSize/2, 60, clr)
+		}
+		g.drawSectorSplit(screen, 60+smallFontSize*2)
+		g.drawSpeedrunTimer(screen)
+		if !g.inSpace() && g.config.Headwind(g.birdman.x)+g.config.BandAt(g.birdman.y).Headwind > g.config.HeadwindStrength/2 {
+			const headwindText = "HEADWIND"
+			text.Draw(screen, headwindText, smallFont, screenWidth-24-len(headwindText)*smallFontSize, 24, color.White)
+		}
+		if g.inSpace() {
+			spaceText := fmt.Sprintf("SPACE %d", int(math.Ceil(g.spaceTime)))
+			text.Draw(screen, spaceText, smallFont, screenWidth-24-len(spaceText)*smallFontSize, 24+smallFontSize*2, color.White)
+		} else if g.boosterCount > 0 {
+			boostText := fmt.Sprintf("BOOST %d/%d", g.boosterCount, g.config.SpaceBoosters)
+			if g.spaceCharged() {
+				boostText = "BOOST FULL: CLIMB!"
+			}
+			text.Draw(screen, boostText, smallFont, screenWidth-24-len(boostText)*smallFontSize, 24+smallFontSize*2, color.White)
+		}
+
+		if g.isInAltitudeZone() && int(g.birdman.pressureTime/altitudeWarningInterval)%2 == 0 {
+			const warningText = "TOO HIGH!"
+			text.Draw(screen, warningText, regularFont, screenWidth/2-len(warningText)*regularFontSize/2, 110, color.White)
+		}
+
+		if g.paused {
+			const pausedText = "PAUSED"
+			text.Draw(screen, pausedText, titleFont, screenWidth/2-len(pausedText)*titleFontSize/2, 200, color.White)
+			const resumeText = "CLICK TO RESUME"
+			text.Draw(screen, resumeText, regularFont, screenWidth/2-len(resumeText)*regularFontSize/2, 260, color.White)
+			if !g.party.active {
+				text.Draw(screen, retryText, smallFont, screenWidth/2-len(retryText)*smallFontSize/2, pausedRetryButtonY, color.White)
+			}
+		}
 	case ModeGameOver:
 		const gameOverText = "GAME OVER"
-		text.Draw(screen, gameOverText, titleFont, screenWidth/2-len(gameOverText)*titleFontSize/2, 180, color.White)
+		text.Draw(screen, gameOverText, titleFont, screenWidth/2-len(gameOverText)*titleFontSize/2, 120, color.White)
+		causeText := g.stats.cause.String()
+		text.Draw(screen, causeText, regularFont, screenWidth/2-len(causeText)*regularFontSize/2, 170, color.White)
 		recordText := []string{"YOUR RECORD IS", fmt.Sprintf("%sm!", formatIntComma(record))}
+		if g.speedrunTarget > 0 {
+			recordText = g.speedrunResult()
+		} else if g.stylePoints > 0 {
+			recordText = append(recordText, fmt.Sprintf("STYLE %s", formatIntComma(g.stylePoints)))
+		}
 		for i, s := range recordText {
-			text.Draw(screen, s, regularFont, screenWidth/2-len(s)*regularFontSize/2, 250+i*(regularFontSize*2), color.White)
+			text.Draw(screen, s, regularFont, screenWidth/2-len(s)*regularFontSize/2, 230+i*(regularFontSize*2), color.White)
+		}
+		statsY := 230 + len(recordText)*regularFontSize*2
+		breakdown := g.stats.breakdown(record)
+		if g.stats.challengeResult != "" {
+			breakdown = append([]string{g.stats.challengeResult}, breakdown...)
 		}
+		for i, s := range breakdown {
+			text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, statsY+i*smallFontSize*2, color.White)
+		}
+		g.drawSectorTable(screen)
+		text.Draw(screen, retryText, smallFont, screenWidth/2-len(retryText)*smallFontSize/2, gameOverRetryButtonY, color.White)
+	case ModeSettings:
+		g.settingsMenu.Draw(screen)
+	case ModeControls:
+		g.controlsMenu.Draw(screen)
+	case ModeStats:
+		drawInfoScreen(screen, "STATS", g.statsTexts(), g.statsMenu)
+	case ModeLeaderboard:
+		g.drawLeaderboard(screen)
+	case ModeCredits:
+		drawInfoScreen(screen, "CREDITS", creditTexts, g.creditsMenu)
+	case ModeSync:
+		g.drawSyncScreen(screen)
+	case ModeConsent:
+		drawInfoScreen(screen, "PRIVACY", consentTexts, g.consentMenu)
+	case ModePrivacy:
+		g.drawPrivacy(screen)
+	case ModeCheats:
+		g.cheatMenu.Draw(screen)
+	case ModePractice:
+		g.practiceMenu.Draw(screen)
+	case ModeSpeedrun:
+		g.speedrunMenu.Draw(screen)
+	case ModeRace:
+		g.raceMenu.Draw(screen)
+	case ModeResults:
+		g.drawResults(screen)
+	case ModeModifiers:
+		g.modifiersMenu.Draw(screen)
+	case ModeParty:
+		g.drawPartyMenu(screen)
+	case ModeStandings:
+		g.drawStandings(screen)
+	case ModeChallenge:
+		g.drawChallengeScreen(screen)
+	case ModeCutscene:
+		g.drawCutscene(screen)
+	case ModeEnding:
+		g.drawEndingScreen(screen)
+	case ModeShop:
+		g.drawShop(screen)
+	case ModeScenery:
+		g.drawScenery(screen)
 	}
+	g.drawStream(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
+	// Lay out in device pixels on high-DPI displays, so that the canvas is
+	// scaled to them rather than filtered up again by the browser
+	s := ebiten.DeviceScaleFactor()
+	g.viewport.Layout(int(float64(outsideWidth)*s), int(float64(outsideHeight)*s))
+	width, height := g.viewport.Size()
+	g.input.Layout(width, height)
+	return width, height
 }
 
 func (g *Game) initialize() {
 	g.initializeCount++
 
-	logging.LogAsync(gameName, map[string]interface{}{
-		"player_id": g.playerID,
-		"play_id":   g.playID,
-		"action":    "initialize",
-		"count":     g.initializeCount,
-	})
+	g.logEvent(InitializeEvent{Count: g.initializeCount})
 
 	g.mode = ModeTitle
-	g.cameraX = -100
-	g.cameraY = 0
-
+	g.camera.Reset(-cameraOffsetX, 0)
+	g.nextBirdX = 0
+	g.flocks = g.flocks[:0]
+	g.nextFlockX = 0
+	g.tricks.Reset()
+	g.popups.Reset()
+	g.splits.Reset()
+	g.checkpoints.Reset(g.records.BestSectors)
+	g.stats.Reset()
+	g.stylePoints = 0
+
+	g.launch = g.nextLaunch()
 	birdman := &Birdman{
-		img:          birdmanImg,
+		frames:       birdmanFrames,
 		state:        StateRunning,
-		x:            -60,
-		y:            initialBirdmanPosY,
+		x:            -g.launch.RunUp,
+		y:            g.launch.Y,
+		vx:           g.config.BirdmanSpeed * g.launch.SpeedScale,
 		vy:           0,
 		damagedCount: 0,
 		damagedTicks: 0,
+		runAnimation: g.launch.animation,
 	}
 	g.birdman = birdman
 
-	g.birds = nil
+	for i := range g.birds {
+		g.birds[i] = Bird{}
+	}
+	g.birds = g.birds[:0]
+	g.airplanes = g.airplanes[:0]
+	g.fish = g.fish[:0]
+	g.splashes = g.splashes[:0]
+	g.featherPuffs = g.featherPuffs[:0]
+	g.balloons = g.balloons[:0]
+	g.nextBalloonX = 0
+	g.rings = g.rings[:0]
+	g.feathers = g.feathers[:0]
+	g.breadcrumbs = g.breadcrumbs[:0]
+	g.crumbs = g.crumbs[:0]
+	g.nextBreadcrumbX = 0
+	g.carriedCrumbs = 0
+	g.groove = 0
+	g.nextFeatherX = 0
+	g.nextRingX = 0
+	g.lastRingY = 0
+	g.ringChain = 0
+	g.boosters = g.boosters[:0]
+	g.nextBoosterX = 0
+	g.boosterCount = 0
+	g.spaceTime = 0
+	g.spaceStars = g.spaceStars[:0]
+	g.currentBiome = nil
+	g.commentary.Reset()
+	g.coach.Reset()
+	g.night.Reset()
+	g.nextCheer = cheerDistance
+	g.passedBest = false
+	g.biomeBannerTime = 0
+	g.paused = false
+	g.timeScale = 1
+	g.stepAccumulator = 0
+	g.focusMeter = 1
+	g.focusTime = 0
+	g.speedrunSplits = 0
 }
 
 func main() {
-	if os.Getenv("GAME_LOGGING") == "1" {
-		secret, err := resources.ReadFile("resources/secret")
-		if err == nil {
-			logging.Enable(string(secret))
-		}
-	} else {
-		logging.Disable()
+	resourcesDir := flag.String("resources", os.Getenv("GAME_RESOURCES"), "directory whose files override the embedded resources")
+	configPath := flag.String("config", os.Getenv("GAME_CONFIG"), "JSON file overriding the game balance config")
+	dev := flag.Bool("dev", os.Getenv("GAME_DEV") == "1", "reload changed resources at runtime")
+	fullscreen := flag.Bool("fullscreen", false, "start in fullscreen")
+	seed := flag.String("seed", os.Getenv("GAME_RAND_SEED"), "random seed")
+	mute := flag.Bool("mute", false, "start with audio muted")
+	scale := flag.Int("scale", 0, "window scale (1-3)")
+	debugHitboxes := flag.Bool("debug-hitboxes", false, "show collision shapes (toggle with F2)")
+	skipTitle := flag.Bool("skip-title", false, "start a run immediately")
+	logEndpoint := flag.String("log-endpoint", os.Getenv("GAME_LOG_ENDPOINT"), "URL of a self-hosted game logging server")
+	headlessRuns := flag.Int("headless", 0, "simulate this many runs without a window and print statistics")
+	headlessFlapInterval := flag.Float64("headless-flap-interval", 0.4, "seconds between flaps of the scripted player in headless mode")
+	validateCurve := flag.Bool("validate-curve", false, "check without a window that no section of the difficulty curve is impossible, and exit")
+	profile := flag.Bool("profile", os.Getenv("GAME_PROFILE") == "1", "show frame times, log their histogram and serve pprof")
+	profileAddr := flag.String("profile-addr", "localhost:6060", "address of the pprof server")
+	bot := flag.Bool("bot", false, "let the autopilot play (also in headless mode)")
+	stream := flag.Bool("stream", false, "show a layout for streaming with a bigger distance and a ticker of the latest runs")
+	streamAddr := flag.String("stream-addr", "", "serve the stats of the run as JSON for stream overlays at this address (with -stream)")
+	liveSplit := flag.Bool("livesplit", false, "send the splits of speedruns to the LiveSplit Server component")
+	liveSplitAddr := flag.String("livesplit-addr", defaultLiveSplitAddr, "address of the LiveSplit server (with -livesplit)")
+	challengeCode := flag.String("challenge", "", "challenge code to open the challenge screen with")
+	export := flag.String("export", "", "write the run history and stats to this .csv or .json file and exit")
+	flag.Parse()
+
+	if *dev && *resourcesDir == "" {
+		*resourcesDir = "resources"
 	}
 
-	if seed, err := strconv.Atoi(os.Getenv("GAME_RAND_SEED")); err == nil {
-		rand.Seed(int64(seed))
-	} else {
-		rand.Seed(time.Now().Unix())
+	storage, err := newPlatformStorage()
+	if err != nil {
+		log.Printf("Saving is not available: %v", err)
 	}
+	// The CrashGuards report the panics from the loading on; those before
+	// have no screen to show them on
+	defer func() {
+		if r := recover(); r != nil {
+			reportStartupCrash(storage, r, debug.Stack())
+			os.Exit(2)
+		}
+	}()
+
+	randSeed := time.Now().Unix()
+	if seed, err := strconv.Atoi(*seed); err == nil {
+		randSeed = int64(seed)
+	}
+	rand.Seed(randSeed)
 	playerID := os.Getenv("GAME_PLAYER_ID")
 	if playerID == "" {
 		if playerIDObj, err := uuid.NewRandom(); err == nil {
@@ -524,25 +1290,184 @@ func main() {
 		}
 	}
 
-	ebiten.SetWindowSize(screenWidth, screenHeight)
-	ebiten.SetWindowTitle("Birdman")
+	if *export != "" {
+		if err := exportToFile(LoadRecords(storage), *export); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	playIDObj, err := uuid.NewRandom()
-	var playID string
+	settings := LoadSettings(storage)
+	// GAME_LOGGING opts in without asking, e.g. when testing the server
+	if os.Getenv("GAME_LOGGING") == "1" {
+		settings.Privacy.Asked = true
+		settings.Privacy.Logging = true
+	}
+	if *logEndpoint != "" {
+		settings.Privacy.Endpoint = *logEndpoint
+	}
+	secret, err := resources.ReadFile(resourcesRoot + "/secret")
+	logger := NewEventLogger(err == nil, string(secret), storage)
+	logger.Configure(settings.Privacy)
+	if *fullscreen {
+		settings.Window.Fullscreen = true
+	}
+	if *scale >= 1 && *scale <= maxRenderScale {
+		settings.Window.Width, settings.Window.Height = screenWidth**scale, screenHeight**scale
+	}
+	if *mute {
+		settings.Audio.Muted = true
+	}
+	applyWindowSettings(&settings.Window)
+	ebiten.SetWindowTitle(windowTitle(userLanguage()))
+	ebiten.SetRunnableOnUnfocused(true)
+
+	assetSource, err := fs.Sub(resources, resourcesRoot)
 	if err != nil {
-		playID = "?"
-	} else {
-		playID = playIDObj.String()
+		log.Fatal(err)
+	}
+	if *resourcesDir != "" {
+		assetSource = NewOverlayFS(os.DirFS(*resourcesDir), assetSource)
+	}
 
+	if *validateCurve {
+		config, err := LoadConfig(assetSource, *configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := validateDifficultyCurve(config); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("difficulty curve ok")
+		return
 	}
-	game := &Game{
-		playerID:        playerID,
-		playID:          playID,
-		initializeCount: 0,
+
+	if *headlessRuns > 0 {
+		config, err := LoadConfig(assetSource, *configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var controller func(g *Game) Controller
+		if *bot {
+			controller = func(g *Game) Controller { return NewBot(g) }
+		} else {
+			controller = func(g *Game) Controller { return NewScriptedController(*headlessFlapInterval) }
+		}
+		runHeadless(os.Stdout, config, rand.NewSource(randSeed), *headlessRuns, controller)
+		return
 	}
-	game.initialize()
 
-	if err := ebiten.RunGame(game); err != nil {
+	assets := NewAssetManager(assetSource, audioContext)
+	if icons, err := loadWindowIcons(assets); err != nil {
+		log.Printf("Failed to load the window icon: %v", err)
+	} else {
+		ebiten.SetWindowIcon(icons)
+	}
+	var game *Game
+	start := func() (ebiten.Game, error) {
+		config, err := LoadConfig(assetSource, *configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the game config: %w", err)
+		}
+
+		playIDObj, err := uuid.NewRandom()
+		var playID string
+		if err != nil {
+			playID = "?"
+		} else {
+			playID = playIDObj.String()
+
+		}
+		viewport := NewViewport(settings.Window.IntegerScaling)
+		viewport.SetRenderScale(settings.Window.RenderScale)
+		input := NewInput(&settings.Bindings, viewport)
+		audioManager := NewAudioManager(audioContext, &settings.Audio)
+		game = NewGame(config, settings, rand.NewSource(randSeed), audioManager, logger)
+		game.playerID = playerID
+		game.seed = randSeed
+		game.webhook = sendWebhook
+		game.storage = storage
+		game.haptics = newPlatformHaptics()
+		startTiltSensor()
+		game.records = LoadRecords(storage)
+		if logger.Available() {
+			game.eventLogger = logger
+			game.syncer = logger
+		}
+		game.playID = playID
+		game.input = input
+		game.controller = input
+		if *bot {
+			game.controller = NewBot(game)
+		}
+		game.viewport = viewport
+		game.backdrop = NewBackdrop()
+		game.audio = audioManager
+		game.ambience = NewAmbience(audioContext.SampleRate())
+		game.debugHitboxes = *debugHitboxes
+		if *stream {
+			game.stream = NewStreamOverlay()
+			if *streamAddr != "" {
+				startStreamServer(*streamAddr, game.stream)
+			}
+		}
+		if *liveSplit {
+			game.autosplitter = NewLiveSplit(*liveSplitAddr)
+		}
+		if *profile {
+			game.profiler = NewFrameProfiler()
+			startProfileServer(*profileAddr)
+		}
+		game.audio.PlayBGM(game.music)
+		game.audio.PlayAmbient(game.ambience)
+		if *dev {
+			game.assets = assets
+			game.assetWatcher = NewAssetWatcher(*resourcesDir)
+		}
+		game.titleMenu = game.newTitleMenu()
+		game.settingsMenu = game.newSettingsMenu()
+		game.controlsMenu = game.newControlsMenu()
+		game.statsMenu = game.newStatsMenu()
+		game.leaderboardMenu = game.newLeaderboardMenu()
+		game.creditsMenu = game.newBackMenu()
+		game.syncMenu = game.newSyncMenu()
+		game.consentMenu = game.newConsentMenu()
+		game.privacyMenu = game.newPrivacyMenu()
+		game.cheatMenu = game.newCheatMenu()
+		game.practiceMenu = game.newPracticeMenu()
+		game.speedrunMenu = game.newSpeedrunMenu()
+		game.raceMenu = game.newRaceMenu()
+		game.modifiersMenu = game.newModifiersMenu()
+		game.partyMenu = game.newPartyMenu()
+		game.challengeMenu = game.newChallengeMenu()
+		game.endingMenu = game.newEndingMenu()
+		game.shopMenu = game.newShopMenu()
+		game.sceneryMenu = game.newSceneryMenu()
+		game.applyScenery()
+		game.initialize()
+		if *skipTitle {
+			game.startGame()
+		} else if logger.Available() && !settings.Privacy.Asked {
+			game.mode = ModeConsent
+		} else if code := *challengeCode + challengeFromURL(); code != "" {
+			game.enterChallenge(code)
+			game.mode = ModeChallenge
+		}
+		return NewCrashGuard(game), nil
+	}
+
+	loading := NewLoadingScene(newAssetLoader(assets), runtime.GOOS == "js", start)
+	guard := &CrashGuard{
+		game: loading,
+		report: func(r interface{}, stack []byte) []string {
+			return reportStartupCrash(storage, r, stack)
+		},
+	}
+	if err := ebiten.RunGame(guard); err != nil && err != errQuitErrorScreen {
 		log.Fatal(err)
 	}
+
+	if game != nil {
+		game.saveSettings()
+	}
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+type MenuItem struct {
+	label   func() string
+	action  func()
+	adjust  func(delta int)
+	visible func() bool
+}
+
+func (item *MenuItem) isVisible() bool {
+	return item.visible == nil || item.visible()
+}
+
+const (
+	menuStickThreshold = 0.5
+	// the width of the arrows at both ends of an adjustable item in touch
+	// mode, which adjust it down and up when tapped
+	menuTouchArrowWidth = screenWidth / 5
+)
+
+var menuCursorImg *ebiten.Image
+
+type Menu struct {
+	title  string
+	items  []MenuItem
+	cursor int
+	y      int
+	small  bool
+	sfx    AudioSink
+	// touch mode makes the rows tappable across the screen
+	touch bool
+	// the direction the gamepad sticks were pushed in last time, so that one
+	// push moves the cursor once
+	stickDir int
+	// the rows shown at once, scrolled to keep the cursor in view; 0 shows
+	// every item
+	rows   int
+	scroll int
+}
+
+func (m *Menu) play(data []byte) {
+	if m.sfx != nil {
+		m.sfx.PlaySE(data)
+	}
+}
+
+// gamepadInput reads the vertical stick of the gamepads as up (-1) or down
+// (1) and their first button as a confirmation.
+func (m *Menu) gamepadInput() (move int, confirm bool) {
+	dir := 0
+	for _, id := range ebiten.GamepadIDs() {
+		if v := ebiten.GamepadAxis(id, 1); math.Abs(v) >= menuStickThreshold {
+			dir = int(math.Copysign(1, v))
+		}
+		if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton0) {
+			confirm = true
+		}
+	}
+	if dir != m.stickDir {
+		move = dir
+	}
+	m.stickDir = dir
+	return move, confirm
+}
+
+func (m *Menu) fontSize() int {
+	if m.small {
+		return smallFontSize
+	}
+	return regularFontSize
+}
+
+func (m *Menu) itemHeight() int {
+	return m.fontSize() * 2
+}
+
+func (m *Menu) visibleItems() []*MenuItem {
+	var items []*MenuItem
+	for i := range m.items {
+		if m.items[i].isVisible() {
+			items = append(items, &m.items[i])
+		}
+	}
+	return items
+}
+
+// inView reports whether the item at index is scrolled into view.
+func (m *Menu) inView(index int) bool {
+	return m.rows == 0 || index >= m.scroll && index < m.scroll+m.rows
+}
+
+func (m *Menu) scrollToCursor(count int) {
+	if m.rows == 0 {
+		return
+	}
+	if m.cursor < m.scroll {
+		m.scroll = m.cursor
+	} else if m.cursor >= m.scroll+m.rows {
+		m.scroll = m.cursor - m.rows + 1
+	}
+	if max := count - m.rows; m.scroll > max {
+		m.scroll = int(math.Max(0, float64(max)))
+	}
+}
+
+func (m *Menu) Update(input *Input) {
+	items := m.visibleItems()
+	if len(items) == 0 {
+		return
+	}
+	if m.cursor >= len(items) {
+		m.cursor = len(items) - 1
+	}
+	defer m.scrollToCursor(len(items))
+
+	m.touch = input.touchMode
+	move, confirm := m.gamepadInput()
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) || move < 0 {
+		m.cursor = (m.cursor + len(items) - 1) % len(items)
+		m.play(menuMoveAudioData)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) || move > 0 {
+		m.cursor = (m.cursor + 1) % len(items)
+		m.play(menuMoveAudioData)
+	}
+	if adjust := items[m.cursor].adjust; adjust != nil {
+		if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+			adjust(-1)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+			adjust(1)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace) || confirm {
+		m.play(menuSelectAudioData)
+		items[m.cursor].action()
+		return
+	}
+
+	if x, y, ok := input.JustTappedPosition(); ok {
+		for i := range items {
+			if !m.inView(i) || !m.contains(i, items[i], x, y) {
+				continue
+			}
+			m.cursor = i
+			if adjust := items[i].adjust; adjust != nil && m.touch {
+				switch {
+				case x < menuTouchArrowWidth:
+					adjust(-1)
+					m.play(menuMoveAudioData)
+					return
+				case x >= screenWidth-menuTouchArrowWidth:
+					adjust(1)
+					m.play(menuMoveAudioData)
+					return
+				}
+			}
+			m.play(menuSelectAudioData)
+			items[i].action()
+			return
+		}
+	}
+}
+
+func (m *Menu) itemTop(index int) int {
+	return m.y + (index-m.scroll)*m.itemHeight()
+}
+
+func (m *Menu) contains(index int, item *MenuItem, x, y int) bool {
+	top := m.itemTop(index)
+	w, h := len(item.label())*m.fontSize(), m.itemHeight()
+	if y < top-h/2 || y >= top+h/2 {
+		return false
+	}
+	// Fingers are less precise than the cursor
+	if m.touch {
+		return true
+	}
+	return x >= screenWidth/2-w/2-m.fontSize() && x < screenWidth/2+w/2+m.fontSize()
+}
+
+func (m *Menu) Draw(screen *ebiten.Image) {
+	if m.title != "" {
+		text.Draw(screen, m.title, titleFont, screenWidth/2-len(m.title)*titleFontSize/2, m.y-regularFontSize*3, color.White)
+	}
+
+	face := regularFont
+	if m.small {
+		face = smallFont
+	}
+	size := m.fontSize()
+	items := m.visibleItems()
+	for i, item := range items {
+		if !m.inView(i) {
+			continue
+		}
+		label := item.label()
+		x := screenWidth/2 - len(label)*size/2
+		y := m.itemTop(i) + size/2
+		text.Draw(screen, label, face, x, y, color.White)
+		if m.touch && item.adjust != nil {
+			text.Draw(screen, "<", face, menuTouchArrowWidth/2-size/2, y, color.White)
+			text.Draw(screen, ">", face, screenWidth-menuTouchArrowWidth/2-size/2, y, color.White)
+		}
+		if i == m.cursor {
+			scale := float64(size) / regularFontSize
+			_, h := menuCursorImg.Size()
+			opt := scratchDrawOptions()
+			opt.GeoM.Scale(scale, scale)
+			opt.GeoM.Translate(float64(x-size*3/2), float64(y-size/2)-float64(h)*scale/2)
+			screen.DrawImage(menuCursorImg, opt)
+		}
+	}
+	// Tell there are more items beyond the view
+	if m.scroll > 0 {
+		text.Draw(screen, "^", face, screenWidth/2-size/2, m.itemTop(m.scroll-1)+size/2, color.White)
+	}
+	if m.rows > 0 && m.scroll+m.rows < len(items) {
+		text.Draw(screen, "v", face, screenWidth/2-size/2, m.itemTop(m.scroll+m.rows)+size/2, color.White)
+	}
+}
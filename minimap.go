@@ -0,0 +1,68 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	minimapX      = 220
+	minimapY      = 18
+	minimapWidth  = 200
+	minimapHeight = 24
+	// how far behind and ahead of the birdman the minimap shows
+	minimapBehind = 50
+	minimapRange  = 3 * screenWidth
+)
+
+var (
+	minimapColor         = color.RGBA{0x00, 0x00, 0x00, 0x60}
+	minimapBirdmanColor  = color.RGBA{0xff, 0xff, 0x80, 0xff}
+	minimapBirdColor     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	minimapAirplaneColor = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	minimapBalloonColor  = color.RGBA{0xff, 0xa0, 0x40, 0xff}
+	minimapRingColor     = color.RGBA{0xff, 0xe0, 0x60, 0xff}
+)
+
+// drawMinimap draws a strip at the top of the screen with everything
+// spawned ahead of the birdman as dots, squeezing the world into it.
+func (g *Game) drawMinimap(screen *ebiten.Image) {
+	if !g.settings.Minimap {
+		return
+	}
+	ebitenutil.DrawRect(screen, minimapX, minimapY, minimapWidth, minimapHeight, minimapColor)
+
+	left := g.birdman.x - minimapBehind
+	dot := func(x, y float64, size float64, clr color.Color) {
+		mx := (x - left) / (minimapBehind + minimapRange) * minimapWidth
+		if mx < 0 || mx > minimapWidth {
+			return
+		}
+		if g.mirrored() {
+			mx = minimapWidth - mx
+		}
+		my := y / screenHeight * minimapHeight
+		if my < 0 || my > minimapHeight {
+			return
+		}
+		ebitenutil.DrawRect(screen, minimapX+mx-size/2, minimapY+my-size/2, size, size, clr)
+	}
+
+	for i := range g.rings {
+		if r := &g.rings[i]; !r.passed {
+			dot(r.x, r.y, 3, minimapRingColor)
+		}
+	}
+	for i := range g.balloons {
+		dot(g.balloons[i].x, g.balloons[i].y, 3, minimapBalloonColor)
+	}
+	for i := range g.birds {
+		dot(g.birds[i].x, g.birds[i].y, 2, minimapBirdColor)
+	}
+	for i := range g.airplanes {
+		dot(g.airplanes[i].x, g.airplanes[i].y, 3, minimapAirplaneColor)
+	}
+	dot(g.birdman.x, g.birdman.y, 3, minimapBirdmanColor)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const mirrorUnlockID = "mirror"
+
+// mirrored reports whether the world is drawn flipped, the birdman flying
+// right to left. The runs go on the same as ever underneath, so only the
+// drawing and what it points at are turned around; the texts stay readable.
+func (g *Game) mirrored() bool {
+	return g.settings.Mirror && g.isUnlocked(findUnlockable(mirrorUnlockID))
+}
+
+// direction is the way the birdman flies across the screen, 1 to the right
+// and -1 to the left in the mirror mode. What is drawn or heard at a side is
+// turned by it, while the world underneath keeps going to the right.
+func (g *Game) direction() float64 {
+	if g.mirrored() {
+		return -1
+	}
+	return 1
+}
+
+// mirrorX returns where a position across the canvas is drawn, which is the
+// other side of it in the mirror mode.
+func (g *Game) mirrorX(x float64) float64 {
+	if g.mirrored() {
+		return screenWidth - x
+	}
+	return x
+}
+
+// drawMirrored draws the world onto a layer of its own, then flips it over
+// onto the canvas.
+func (g *Game) drawMirrored(screen *ebiten.Image, draw func(world *ebiten.Image)) {
+	if g.mirrorLayer == nil {
+		g.mirrorLayer = ebiten.NewImage(screenWidth, screenHeight)
+	}
+	g.mirrorLayer.Clear()
+	draw(g.mirrorLayer)
+	opt := scratchDrawOptions()
+	opt.GeoM.Scale(-1, 1)
+	opt.GeoM.Translate(screenWidth, 0)
+	screen.DrawImage(g.mirrorLayer, opt)
+}
@@ -0,0 +1,109 @@
+package main
+
+// Modifier is a silly change to the runs, picked before them for fun. The
+// runs with any of them on aren't recorded.
+type Modifier struct {
+	ID   string
+	Name string
+	// changes the balance of the runs, nil for none
+	config func(c *GameConfig)
+	// the sizes the birdman and the birds are drawn and hit at, 0 for no
+	// change
+	birdmanScale float64
+	birdScale    float64
+}
+
+var modifiers = []Modifier{
+	{ID: "giant_birdman", Name: "GIANT BIRDMAN", birdmanScale: 1.8},
+	{ID: "tiny_birds", Name: "TINY BIRDS", birdScale: 0.5},
+	{ID: "moon_gravity", Name: "MOON GRAVITY", config: func(c *GameConfig) {
+		c.Gravity *= 0.3
+		c.MaxFallSpeed *= 0.5
+		c.DamagedFallSpeed *= 0.5
+	}},
+	{ID: "turbo", Name: "TURBO SPEED", config: func(c *GameConfig) {
+		c.BirdmanSpeed *= 1.8
+		c.MinForwardSpeed *= 1.8
+		c.MaxForwardSpeed *= 1.8
+	}},
+}
+
+// activeModifiers returns the ids of the modifiers on, in the order of the
+// list.
+func (g *Game) activeModifiers() []string {
+	var ids []string
+	for i := range modifiers {
+		if g.modifiers[modifiers[i].ID] {
+			ids = append(ids, modifiers[i].ID)
+		}
+	}
+	return ids
+}
+
+// applyModifiers sets the balance and the sizes of the runs from the base
+// ones with the modifiers on, harder in the new game+.
+func (g *Game) applyModifiers() {
+	g.config = g.baseConfig
+	g.birdScale = 0
+	birdmanScale := 0.0
+	if len(g.activeModifiers()) > 0 || g.loop > 0 {
+		c := *g.baseConfig
+		hardenConfig(&c, g.loop)
+		for i := range modifiers {
+			m := &modifiers[i]
+			if !g.modifiers[m.ID] {
+				continue
+			}
+			if m.config != nil {
+				m.config(&c)
+			}
+			if m.birdmanScale != 0 {
+				birdmanScale = m.birdmanScale
+			}
+			if m.birdScale != 0 {
+				g.birdScale = m.birdScale
+			}
+		}
+		g.config = &c
+	}
+	g.birdman.scale = birdmanScale
+}
+
+func (g *Game) toggleModifier(id string) {
+	if g.modifiers == nil {
+		g.modifiers = map[string]bool{}
+	}
+	g.modifiers[id] = !g.modifiers[id]
+	g.applyModifiers()
+}
+
+func (g *Game) newModifiersMenu() *Menu {
+	var items []MenuItem
+	for i := range modifiers {
+		m := &modifiers[i]
+		items = append(items, MenuItem{
+			label:  func() string { return m.Name + ": " + onOff(g.modifiers[m.ID]) },
+			action: func() { g.toggleModifier(m.ID) },
+		})
+	}
+	items = append(items,
+		MenuItem{
+			label: func() string { return "ALL OFF" },
+			action: func() {
+				g.modifiers = nil
+				g.applyModifiers()
+			},
+		},
+		MenuItem{
+			label:  func() string { return "BACK" },
+			action: func() { g.mode = ModeTitle },
+		},
+	)
+	return &Menu{
+		title: "MODIFIERS",
+		y:     titleMenuY,
+		small: true,
+		sfx:   g.sfx,
+		items: items,
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+const (
+	musicBars         = 4
+	musicBeatsPerBar  = 4
+	musicLayerCount   = 4
+	musicGainFadeRate = 0.00005
+	musicSparseTicks  = 180
+)
+
+// Layer order, from always-on to only-at-high-intensity.
+const (
+	musicLayerBass = iota
+	musicLayerDrums
+	musicLayerArpeggio
+	musicLayerLead
+)
+
+// MusicTrack is a looping progression the layers are synthesized from.
+type MusicTrack struct {
+	BPM int
+	// chord roots and triads (in Hz), one per bar
+	BassNotes [musicBars]float64
+	Chords    [musicBars][3]float64
+	// an eighth note each, 0 for a rest
+	LeadNotes []float64
+}
+
+// musicTracks are the tracks by the id of their unlockable.
+var musicTracks = map[string]MusicTrack{
+	"retro": {
+		BPM:       128,
+		BassNotes: [musicBars]float64{110.00, 87.31, 130.81, 98.00},
+		Chords: [musicBars][3]float64{
+			{440.00, 523.25, 659.25},
+			{349.23, 440.00, 523.25},
+			{523.25, 659.25, 783.99},
+			{392.00, 493.88, 587.33},
+		},
+		LeadNotes: []float64{
+			880.00, 0, 783.99, 659.25, 0, 659.25, 783.99, 880.00,
+			698.46, 0, 659.25, 523.25, 0, 523.25, 587.33, 659.25,
+			783.99, 0, 659.25, 587.33, 0, 523.25, 587.33, 659.25,
+			587.33, 0, 493.88, 392.00, 0, 493.88, 587.33, 0,
+		},
+	},
+	"dusk": {
+		BPM:       96,
+		BassNotes: [musicBars]float64{73.42, 116.54, 87.31, 110.00},
+		Chords: [musicBars][3]float64{
+			{293.66, 349.23, 440.00},
+			{466.16, 587.33, 698.46},
+			{349.23, 440.00, 523.25},
+			{440.00, 554.37, 659.25},
+		},
+		LeadNotes: []float64{
+			587.33, 0, 0, 698.46, 659.25, 0, 587.33, 0,
+			698.46, 0, 0, 587.33, 523.25, 0, 466.16, 0,
+			523.25, 0, 0, 440.00, 523.25, 0, 587.33, 0,
+			554.37, 0, 0, 0, 659.25, 0, 0, 0,
+		},
+	},
+	"stardust": {
+		BPM:       150,
+		BassNotes: [musicBars]float64{130.81, 98.00, 110.00, 87.31},
+		Chords: [musicBars][3]float64{
+			{523.25, 659.25, 783.99},
+			{392.00, 493.88, 587.33},
+			{440.00, 523.25, 659.25},
+			{349.23, 440.00, 523.25},
+		},
+		LeadNotes: []float64{
+			1046.50, 783.99, 659.25, 783.99, 1046.50, 0, 987.77, 0,
+			783.99, 587.33, 493.88, 587.33, 783.99, 0, 880.00, 0,
+			880.00, 659.25, 523.25, 659.25, 880.00, 0, 783.99, 0,
+			698.46, 523.25, 440.00, 523.25, 698.46, 0, 0, 0,
+		},
+	},
+}
+
+const defaultMusicTrack = "retro"
+
+// MusicManager mixes the synthesized music layers into a single stream so
+// that every layer stays sample-aligned however often they are faded in and
+// out.
+type MusicManager struct {
+	sampleRate int
+
+	mu          sync.Mutex
+	layers      [musicLayerCount][]float32
+	gains       [musicLayerCount]float64
+	targetGains [musicLayerCount]float64
+	pos         int
+	sparseTicks int
+	beatFrames  int
+}
+
+func NewMusicManager(sampleRate int) *MusicManager {
+	m := &MusicManager{sampleRate: sampleRate}
+	track := musicTracks[defaultMusicTrack]
+	m.layers = compose(track, sampleRate)
+	m.beatFrames = sampleRate * 60 / track.BPM
+	m.targetGains[musicLayerBass] = 1
+	m.gains[musicLayerBass] = 1
+	return m
+}
+
+// SetTrack switches to another track, keeping the layers faded in as they
+// are.
+func (m *MusicManager) SetTrack(track MusicTrack) {
+	layers := compose(track, m.sampleRate)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.layers = layers
+	m.pos %= len(layers[0])
+	m.beatFrames = m.sampleRate * 60 / track.BPM
+}
+
+// Beat returns the beat of the loop the stream is at and how far into it,
+// from 0 to 1. It goes by what has been read of the stream, which runs a
+// little ahead of what is heard.
+func (m *MusicManager) Beat() (beat int, phase float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pos / m.beatFrames, float64(m.pos%m.beatFrames) / float64(m.beatFrames)
+}
+
+func compose(track MusicTrack, sampleRate int) [musicLayerCount][]float32 {
+	var layers [musicLayerCount][]float32
+	frames := sampleRate * 60 / track.BPM * musicBeatsPerBar * musicBars
+	for i := range layers {
+		layers[i] = make([]float32, frames)
+	}
+
+	beatFrames := sampleRate * 60 / track.BPM
+	barFrames := beatFrames * musicBeatsPerBar
+	eighthFrames := beatFrames / 2
+
+	note := func(layer []float32, start, length int, freq, volume float64, wave func(phase float64) float64) {
+		for i := 0; i < length && start+i < len(layer); i++ {
+			t := float64(i) / float64(sampleRate)
+			env := math.Min(1, float64(i)/200) * (1 - float64(i)/float64(length))
+			layer[start+i] += float32(wave(math.Mod(t*freq, 1)) * env * volume)
+		}
+	}
+	square := func(phase float64) float64 {
+		if phase < 0.5 {
+			return 1
+		}
+		return -1
+	}
+	triangle := func(phase float64) float64 {
+		return 4*math.Abs(phase-0.5) - 1
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for bar := 0; bar < musicBars; bar++ {
+		barStart := bar * barFrames
+
+		for beat := 0; beat < musicBeatsPerBar; beat++ {
+			note(layers[musicLayerBass], barStart+beat*beatFrames, beatFrames, track.BassNotes[bar], 0.35, triangle)
+
+			// kick on beats, hi-hat on off-beats
+			kick := layers[musicLayerDrums][barStart+beat*beatFrames:]
+			for i := 0; i < beatFrames/4 && i < len(kick); i++ {
+				t := float64(i) / float64(sampleRate)
+				kick[i] += float32(math.Sin(2*math.Pi*t*(60+100*math.Exp(-t*30))) * math.Exp(-t*20) * 0.5)
+			}
+			hat := layers[musicLayerDrums][barStart+beat*beatFrames+eighthFrames:]
+			for i := 0; i < eighthFrames/4 && i < len(hat); i++ {
+				hat[i] += float32((r.Float64()*2 - 1) * math.Exp(-float64(i)/300) * 0.15)
+			}
+		}
+
+		for e := 0; e < musicBeatsPerBar*2; e++ {
+			freq := track.Chords[bar][e%3]
+			note(layers[musicLayerArpeggio], barStart+e*eighthFrames, eighthFrames, freq, 0.08, square)
+
+			if freq := track.LeadNotes[(bar*musicBeatsPerBar*2+e)%len(track.LeadNotes)]; freq > 0 {
+				note(layers[musicLayerLead], barStart+e*eighthFrames, eighthFrames, freq, 0.1, triangle)
+			}
+		}
+	}
+	return layers
+}
+
+// SetIntensity fades in the layers whose index is below level + 1.
+func (m *MusicManager) SetIntensity(level int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sparseTicks > 0 {
+		m.sparseTicks--
+		level = 0
+	}
+	for i := range m.targetGains {
+		if i <= level {
+			m.targetGains[i] = 1
+		} else {
+			m.targetGains[i] = 0
+		}
+	}
+}
+
+// DropToSparse mutes every layer but the bass for a few seconds, e.g. after
+// the birdman gets damaged.
+func (m *MusicManager) DropToSparse() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sparseTicks = musicSparseTicks
+}
+
+func (m *MusicManager) Read(b []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	frames := len(m.layers[0])
+	if frames == 0 {
+		return 0, io.EOF
+	}
+
+	n := len(b) / bytesPerFrame
+	for i := 0; i < n; i++ {
+		var v float64
+		for l := range m.layers {
+			if g, t := m.gains[l], m.targetGains[l]; g < t {
+				m.gains[l] = math.Min(t, g+musicGainFadeRate)
+			} else if g > t {
+				m.gains[l] = math.Max(t, g-musicGainFadeRate)
+			}
+			v += float64(m.layers[l][m.pos]) * m.gains[l]
+		}
+		s := uint16(int16(math.Max(-1, math.Min(1, v)) * math.MaxInt16))
+		binary.LittleEndian.PutUint16(b[i*bytesPerFrame:], s)
+		binary.LittleEndian.PutUint16(b[i*bytesPerFrame+2:], s)
+		m.pos = (m.pos + 1) % frames
+	}
+	return n * bytesPerFrame, nil
+}
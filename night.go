@@ -0,0 +1,99 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	nightUnlockID = "night"
+	// in pixels, the light fading out over the outer part of it
+	spotlightRadius = 110
+	spotlightSoft   = 0.4
+	darknessAlpha   = 0.97
+	// seconds between the lightning flashes, and of a flash
+	minLightningInterval = 5
+	maxLightningInterval = 14
+	lightningTime        = 0.3
+)
+
+var spotlightImg *ebiten.Image
+
+// newSpotlightImage draws the light around the birdman as an alpha mask,
+// opaque where it is lit.
+func newSpotlightImage(r int) *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 2*r, 2*r))
+	for y := 0; y < 2*r; y++ {
+		for x := 0; x < 2*r; x++ {
+			dx, dy := float64(x-r)+0.5, float64(y-r)+0.5
+			d := math.Sqrt(dx*dx+dy*dy) / float64(r)
+			a := math.Max(0, math.Min(1, (1-d)/spotlightSoft))
+			img.Set(x, y, color.Alpha{uint8(0xff * a)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// Night keeps the lightning of the night mode. It has a random source of its
+// own so as not to change the runs.
+type Night struct {
+	rand *rand.Rand
+	// until the next flash, and the flash fading out
+	next  float64
+	flash float64
+}
+
+func (n *Night) Reset() {
+	if n.rand == nil {
+		n.rand = rand.New(rand.NewSource(1))
+	}
+	n.next = minLightningInterval
+	n.flash = 0
+}
+
+func (n *Night) Update(dt float64) {
+	n.flash = math.Max(0, n.flash-dt/lightningTime)
+	n.next -= dt
+	if n.next <= 0 {
+		n.next = minLightningInterval + n.rand.Float64()*(maxLightningInterval-minLightningInterval)
+		n.flash = 1
+	}
+}
+
+// isNight reports whether only the light around the birdman is seen.
+func (g *Game) isNight() bool {
+	return g.settings.Night && g.isUnlocked(findUnlockable(nightUnlockID))
+}
+
+func (g *Game) updateNight() {
+	if g.isNight() {
+		g.night.Update(simulationStep)
+	}
+}
+
+// drawDarkness covers the world in the dark but for a circle of light around
+// the birdman, lifted for a moment by the lightning.
+func (g *Game) drawDarkness(screen *ebiten.Image) {
+	if !g.isNight() {
+		return
+	}
+	if g.darknessLayer == nil {
+		g.darknessLayer = ebiten.NewImage(screenWidth, screenHeight)
+	}
+	d := g.darknessLayer
+	alpha := darknessAlpha * (1 - g.night.flash*g.night.flash)
+	d.Fill(color.RGBA{0, 0, 0, uint8(0xff * alpha)})
+
+	x := g.mirrorX(g.birdman.x - g.camera.ViewX())
+	y := g.birdman.y - g.camera.ViewY()
+	opt := scratchDrawOptions()
+	opt.GeoM.Translate(x-spotlightRadius, y-spotlightRadius)
+	opt.CompositeMode = ebiten.CompositeModeDestinationOut
+	d.DrawImage(spotlightImg, opt)
+
+	screen.DrawImage(d, scratchDrawOptions())
+}
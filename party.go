@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	minPartyPlayers = 2
+	maxPartyPlayers = 8
+	partyNameLength = 10
+	standingsTitleY = 80
+	standingsTop    = standingsTitleY + regularFontSize*3
+)
+
+var winnerColor = color.RGBA{0xff, 0xd0, 0x40, 0xff}
+
+// Handicap evens a party out for the less skilled players, counting their
+// distance for more or giving them hearts to start with.
+type Handicap struct {
+	Label      string
+	multiplier float64
+	hearts     int
+}
+
+var handicaps = []Handicap{
+	{Label: "", multiplier: 1},
+	{Label: "X1.25", multiplier: 1.25},
+	{Label: "X1.5", multiplier: 1.5},
+	{Label: "X2", multiplier: 2},
+	{Label: "+1 HEART", multiplier: 1, hearts: 1},
+	{Label: "+2 HEARTS", multiplier: 1, hearts: 2},
+}
+
+// PartyPlayer is one of the players taking turns in the party mode.
+type PartyPlayer struct {
+	Name string
+	// the index in handicaps
+	Handicap int
+	// in meters, of the turn
+	Distance int
+	played   bool
+}
+
+func (pl *PartyPlayer) handicap() *Handicap {
+	return &handicaps[pl.Handicap]
+}
+
+// score is the distance counted with the handicap.
+func (pl *PartyPlayer) score() int {
+	return int(math.Round(float64(pl.Distance) * pl.handicap().multiplier))
+}
+
+// Party takes local players through one run each, with the standings
+// shown between the turns and the winner at the end.
+type Party struct {
+	// the names are kept for all of them, to play again with the same
+	players []PartyPlayer
+	count   int
+	active  bool
+	// the index of the player playing or up next, count once all have
+	// played
+	turn  int
+	entry TextEntry
+	// the index of the player whose name is typed in
+	editing int
+}
+
+func defaultPartyName(i int) string {
+	return fmt.Sprintf("PLAYER %d", i+1)
+}
+
+func (p *Party) setCount(n int) {
+	if n < minPartyPlayers {
+		n = maxPartyPlayers
+	} else if n > maxPartyPlayers {
+		n = minPartyPlayers
+	}
+	p.count = n
+	for i := len(p.players); i < n; i++ {
+		p.players = append(p.players, PartyPlayer{Name: defaultPartyName(i)})
+	}
+}
+
+func (p *Party) over() bool {
+	return p.turn >= p.count
+}
+
+// standings returns the indices of the players, the best scores first,
+// and those yet to play after them in turn order.
+func (p *Party) standings() []int {
+	order := make([]int, p.count)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := &p.players[order[a]], &p.players[order[b]]
+		if pa.played != pb.played {
+			return pa.played
+		}
+		return pa.score() > pb.score()
+	})
+	return order
+}
+
+func (g *Game) newPartyMenu() *Menu {
+	p := &g.party
+	p.setCount(minPartyPlayers)
+	items := []MenuItem{
+		{
+			label:  func() string { return fmt.Sprintf("PLAYERS: %d", p.count) },
+			action: func() { p.setCount(p.count + 1) },
+			adjust: func(delta int) { p.setCount(p.count + delta) },
+		},
+	}
+	// Tapping a player types the name in, and left/right pick the handicap
+	for i := 0; i < maxPartyPlayers; i++ {
+		i := i
+		pl := func() *PartyPlayer { return &p.players[i] }
+		items = append(items, MenuItem{
+			label: func() string {
+				s := fmt.Sprintf("%d. %s", i+1, pl().Name)
+				if h := pl().handicap().Label; h != "" {
+					s += " " + h
+				}
+				return s
+			},
+			action: func() {
+				p.editing = i
+				p.entry.Start(pl().Name, partyNameLength)
+			},
+			adjust: func(delta int) {
+				n := len(handicaps)
+				pl().Handicap = ((pl().Handicap+delta)%n + n) % n
+			},
+			visible: func() bool { return i < p.count },
+		})
+	}
+	items = append(items,
+		MenuItem{
+			label:  func() string { return "START" },
+			action: g.startParty,
+		},
+		MenuItem{
+			label:  func() string { return "BACK" },
+			action: func() { g.mode = ModeTitle },
+		},
+	)
+	return &Menu{
+		title: "PARTY",
+		y:     titleMenuY,
+		small: true,
+		sfx:   g.sfx,
+		items: items,
+	}
+}
+
+func (g *Game) startParty() {
+	p := &g.party
+	for i := range p.players {
+		p.players[i].Distance = 0
+		p.players[i].played = false
+	}
+	p.turn = 0
+	p.active = true
+	g.startPartyTurn()
+}
+
+func (g *Game) startPartyTurn() {
+	g.practice = false
+	g.racing = false
+	g.speedrunTarget = 0
+	g.initialize()
+	g.startGame()
+	if p := &g.party; p.active {
+		if h := p.players[p.turn].handicap(); h.hearts > 0 {
+			g.hearts += h.hearts
+			// Hearts for free aren't fair on the records
+			g.stats.handicapped = true
+		}
+	}
+}
+
+// endPartyTurn takes the distance of the run just over for the player, and
+// shows the standings.
+func (g *Game) endPartyTurn() {
+	p := &g.party
+	pl := &p.players[p.turn]
+	pl.Distance = int(g.birdman.x) / 10
+	pl.played = true
+	p.turn++
+	g.mode = ModeStandings
+	if p.over() {
+		g.fireworks.Reset()
+	}
+}
+
+func (g *Game) updatePartyMenu() {
+	p := &g.party
+	e := &p.entry
+	if !e.active {
+		g.partyMenu.Update(g.input)
+		return
+	}
+	if !e.Update() {
+		return
+	}
+	name := strings.ToUpper(strings.TrimSpace(e.text))
+	if name == "" {
+		name = defaultPartyName(p.editing)
+	}
+	p.players[p.editing].Name = name
+	e.active = false
+}
+
+func (g *Game) drawPartyMenu(screen *ebiten.Image) {
+	p := &g.party
+	if !p.entry.active {
+		g.partyMenu.Draw(screen)
+		return
+	}
+	p.entry.Draw(screen, fmt.Sprintf("PLAYER %d: ", p.editing+1), titleMenuY)
+}
+
+// updateStandings goes on to the next turn, or back to the title once the
+// winner is celebrated.
+func (g *Game) updateStandings() {
+	p := &g.party
+	if p.over() {
+		g.fireworks.Update(1 / float64(ebiten.MaxTPS()))
+	}
+	if !g.input.IsJustTapped() {
+		return
+	}
+	if p.over() {
+		p.active = false
+		g.initialize()
+		return
+	}
+	g.startPartyTurn()
+}
+
+func (g *Game) drawStandings(screen *ebiten.Image) {
+	p := &g.party
+	order := p.standings()
+	title := "STANDINGS"
+	if p.over() {
+		g.fireworks.Draw(screen)
+		title = p.players[order[0]].Name + " WINS!"
+	}
+	text.Draw(screen, title, regularFont, screenWidth/2-len(title)*regularFontSize/2, standingsTitleY, color.White)
+
+	for rank, i := range order {
+		pl := &p.players[i]
+		d := "-"
+		if pl.played {
+			d = fmt.Sprintf("%sm", formatIntComma(pl.score()))
+		}
+		s := fmt.Sprintf("%d. %-*s %8s", rank+1, partyNameLength, pl.Name, d)
+		if h := pl.handicap(); pl.played && h.multiplier != 1 {
+			s += fmt.Sprintf(" (%sm %s)", formatIntComma(pl.Distance), h.Label)
+		}
+		clr := color.Color(color.White)
+		switch {
+		case p.over() && rank == 0:
+			clr = winnerColor
+		case i == p.turn-1:
+			clr = splitAheadColor
+		}
+		text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, standingsTop+rank*smallFontSize*2, clr)
+	}
+
+	s := "TAP TO FINISH"
+	if !p.over() {
+		s = fmt.Sprintf("NEXT: %s, TAP TO START", p.players[p.turn].Name)
+	}
+	text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, gameOverRetryButtonY, color.White)
+}
@@ -0,0 +1,8 @@
+//go:build android || ios
+// +build android ios
+
+package main
+
+// mobilePlatform tells the builds for phones and tablets, which start in
+// touch mode and have no windows to manage.
+const mobilePlatform = true
@@ -0,0 +1,6 @@
+//go:build !android && !ios
+// +build !android,!ios
+
+package main
+
+const mobilePlatform = false
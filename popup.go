@@ -0,0 +1,80 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	popupPoolSize  = 32
+	popupLifetime  = 1.0
+	popupRiseSpeed = 40
+)
+
+var (
+	popupPointsColor    = color.RGBA{0xff, 0xff, 0x80, 0xff}
+	popupNearMissColor  = color.RGBA{0x80, 0xe0, 0xff, 0xff}
+	popupMilestoneColor = color.RGBA{0xff, 0xa0, 0x40, 0xff}
+)
+
+// Popup is a short text rising and fading out at a world position.
+type Popup struct {
+	text  string
+	x, y  float64
+	time  float64
+	clr   color.RGBA
+	alive bool
+}
+
+// Popups is a fixed pool of popups. Slots are handed out in turn, so when
+// every one is taken the oldest popup is replaced and spawning never
+// allocates.
+type Popups struct {
+	pool [popupPoolSize]Popup
+	next int
+}
+
+func (p *Popups) Reset() {
+	*p = Popups{}
+}
+
+func (p *Popups) Spawn(text string, x, y float64, clr color.RGBA) {
+	p.pool[p.next] = Popup{text: text, x: x, y: y, clr: clr, alive: true}
+	p.next = (p.next + 1) % popupPoolSize
+}
+
+func (p *Popups) Update(dt float64) {
+	for i := range p.pool {
+		pp := &p.pool[i]
+		if !pp.alive {
+			continue
+		}
+		pp.time += dt
+		pp.y -= popupRiseSpeed * dt
+		if pp.time >= popupLifetime {
+			pp.alive = false
+		}
+	}
+}
+
+// Draw draws the popups over the world, or over the flipped world if
+// mirrored.
+func (p *Popups) Draw(screen *ebiten.Image, cameraX, cameraY float64, mirrored bool) {
+	for i := range p.pool {
+		pp := &p.pool[i]
+		if !pp.alive {
+			continue
+		}
+		fade := pp.time / popupLifetime
+		clr := pp.clr
+		clr.A = uint8(float64(clr.A) * (1 - fade*fade))
+		x := pp.x - cameraX
+		if mirrored {
+			x = screenWidth - x
+		}
+		x -= float64(len(pp.text) * smallFontSize / 2)
+		text.Draw(screen, pp.text, smallFont, int(x), int(pp.y-cameraY), clr)
+	}
+}
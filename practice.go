@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	// in meters
+	practiceDistanceStep = 250
+	minPracticeDistance  = 1000
+	// the practice can go as far as the best, or this far for beginners
+	minPracticeLimit = 2000
+)
+
+func (g *Game) practiceLimit() int {
+	limit := g.records.BestDistance / practiceDistanceStep * practiceDistanceStep
+	if limit < minPracticeLimit {
+		limit = minPracticeLimit
+	}
+	return limit
+}
+
+func (g *Game) adjustPracticeDistance(delta int) {
+	d := g.practiceDistance + delta*practiceDistanceStep
+	if d < minPracticeDistance {
+		d = g.practiceLimit()
+	} else if d > g.practiceLimit() {
+		d = minPracticeDistance
+	}
+	g.practiceDistance = d
+}
+
+// newPracticeMenu starts practice runs at a chosen distance. The flap power
+// and the hazards follow the distance as in a normal run, but the runs
+// aren't recorded. Retrying keeps practicing until back to the title.
+func (g *Game) newPracticeMenu() *Menu {
+	return &Menu{
+		title: "PRACTICE",
+		y:     titleMenuY,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label:  func() string { return fmt.Sprintf("FROM %sm", formatIntComma(g.practiceDistance)) },
+				action: func() { g.adjustPracticeDistance(1) },
+				adjust: g.adjustPracticeDistance,
+			},
+			{
+				label: func() string { return "START" },
+				action: func() {
+					g.practice = true
+					g.racing = false
+					g.speedrunTarget = 0
+					g.startGame()
+				},
+			},
+			{
+				label:  func() string { return "BACK" },
+				action: func() { g.mode = ModeTitle },
+			},
+		},
+	}
+}
+
+// warpTo puts the birdman in the air at a distance in meters, as if he had
+// flown there.
+func (g *Game) warpTo(distance int) {
+	x := float64(distance * 10)
+	g.birdman.state = StateFlying
+	g.birdman.x = x
+	g.camera.Reset(x-cameraOffsetX, 0)
+	g.nextBirdX = x
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"net/url"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	privacyMenuY      = 220
+	privacyStatusY    = 340
+	maxEndpointLength = 48
+)
+
+var consentTexts = []string{
+	"SEND ANONYMOUS PLAY DATA?",
+	"DISTANCES, CRASHES AND SETTINGS",
+	"HELP TO BALANCE THE GAME.",
+	"YOU CAN CHANGE IT IN THE SETTINGS.",
+}
+
+// PrivacySettings are the player's choice about sending play data to the
+// game logging server.
+type PrivacySettings struct {
+	// whether the player has answered the prompt on the first launch
+	Asked   bool `json:"asked"`
+	Logging bool `json:"logging"`
+	// URL of a self-hosted logging server, empty for the default one
+	Endpoint string `json:"endpoint"`
+}
+
+// validateURL checks a URL typed in by the player, where empty means none.
+func validateURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%s is not an http(s) URL", s)
+	}
+	return nil
+}
+
+// applyPrivacy saves the privacy settings and has the logger follow them.
+func (g *Game) applyPrivacy() {
+	if g.eventLogger != nil {
+		g.eventLogger.Configure(g.settings.Privacy)
+	}
+	g.saveSettings()
+}
+
+func (g *Game) answerConsent(logging bool) {
+	g.settings.Privacy.Asked = true
+	g.settings.Privacy.Logging = logging
+	g.applyPrivacy()
+	g.mode = ModeTitle
+}
+
+// newConsentMenu asks whether to send play data on the first launch, before
+// anything is logged.
+func (g *Game) newConsentMenu() *Menu {
+	return &Menu{
+		y:     infoScreenY - regularFontSize*2,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label:  func() string { return "YES, SEND" },
+				action: func() { g.answerConsent(true) },
+			},
+			{
+				label:  func() string { return "NO, THANKS" },
+				action: func() { g.answerConsent(false) },
+			},
+		},
+	}
+}
+
+// urlLabel shows just the host of a URL setting, which fits the screen.
+func urlLabel(name, s, unset string) string {
+	if s == "" {
+		return name + ": " + unset
+	}
+	if u, err := url.Parse(s); err == nil {
+		s = u.Host
+	}
+	return name + ": " + s
+}
+
+// editURL lets the player type in the URL setting, which is applied once
+// confirmed.
+func (g *Game) editURL(setting *string, maxLen int) {
+	g.urlEntry.Start(*setting, maxLen)
+	g.urlEntryTarget = setting
+}
+
+func (g *Game) newPrivacyMenu() *Menu {
+	return &Menu{
+		title: "PRIVACY",
+		y:     privacyMenuY,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label: func() string { return "SEND PLAY DATA: " + onOff(g.settings.Privacy.Logging) },
+				action: func() {
+					g.settings.Privacy.Logging = !g.settings.Privacy.Logging
+					g.applyPrivacy()
+				},
+				visible: g.loggingAvailable,
+			},
+			{
+				label: func() string { return urlLabel("SERVER", g.settings.Privacy.Endpoint, "DEFAULT") },
+				action: func() {
+					g.editURL(&g.settings.Privacy.Endpoint, maxEndpointLength)
+				},
+				visible: g.loggingAvailable,
+			},
+			{
+				label: func() string { return urlLabel("PB WEBHOOK", g.settings.WebhookURL, "OFF") },
+				action: func() {
+					g.editURL(&g.settings.WebhookURL, maxWebhookURLLength)
+				},
+			},
+			{
+				label: func() string { return "BACK" },
+				action: func() {
+					g.mode = ModeSettings
+				},
+			},
+		},
+	}
+}
+
+func (g *Game) loggingAvailable() bool {
+	return g.eventLogger != nil && g.eventLogger.Available()
+}
+
+func (g *Game) updatePrivacy() {
+	e := &g.urlEntry
+	if !e.active {
+		g.privacyMenu.Update(g.input)
+		return
+	}
+	if !e.Update() {
+		return
+	}
+	if validateURL(e.text) != nil {
+		return
+	}
+	e.active = false
+	*g.urlEntryTarget = e.text
+	g.applyPrivacy()
+}
+
+func (g *Game) drawPrivacy(screen *ebiten.Image) {
+	e := &g.urlEntry
+	if !e.active {
+		g.privacyMenu.Draw(screen)
+		return
+	}
+	e.Draw(screen, "", privacyMenuY)
+	s := "ENTER A URL OR LEAVE IT EMPTY"
+	if err := validateURL(e.text); err != nil {
+		s = "NOT AN HTTP(S) URL"
+	}
+	text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, privacyStatusY, color.White)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	frameProfilerHistory     = 240
+	frameProfilerLogInterval = 10 * time.Second
+	// a frame taking longer than this many times the target is a spike
+	frameSpikeFactor = 2
+)
+
+// upper bounds (in ms) of the frame-time histogram buckets; the last bucket
+// is unbounded
+var frameTimeBuckets = []float64{8, 17, 25, 34, 50, 100}
+
+var (
+	frameGraphColor = color.RGBA{0x40, 0xff, 0x40, 0xc0}
+	frameSpikeColor = color.RGBA{0xff, 0x40, 0x40, 0xff}
+)
+
+// FrameProfiler measures the time between updates, draws it as a graph with
+// spikes marked and periodically logs a histogram of it. A nil profiler does
+// nothing.
+type FrameProfiler struct {
+	last      time.Time
+	lastLog   time.Time
+	history   [frameProfilerHistory]time.Duration
+	pos       int
+	histogram []int
+	spikes    int
+}
+
+func NewFrameProfiler() *FrameProfiler {
+	return &FrameProfiler{histogram: make([]int, len(frameTimeBuckets)+1)}
+}
+
+func (p *FrameProfiler) targetFrameTime() time.Duration {
+	return time.Second / time.Duration(ebiten.MaxTPS())
+}
+
+func (p *FrameProfiler) Tick() {
+	if p == nil {
+		return
+	}
+
+	now := time.Now()
+	if p.last.IsZero() {
+		p.last, p.lastLog = now, now
+		return
+	}
+	d := now.Sub(p.last)
+	p.last = now
+
+	p.history[p.pos] = d
+	p.pos = (p.pos + 1) % len(p.history)
+
+	ms := float64(d) / float64(time.Millisecond)
+	i := 0
+	for i < len(frameTimeBuckets) && ms > frameTimeBuckets[i] {
+		i++
+	}
+	p.histogram[i]++
+	if d > p.targetFrameTime()*frameSpikeFactor {
+		p.spikes++
+	}
+
+	if now.Sub(p.lastLog) >= frameProfilerLogInterval {
+		p.lastLog = now
+		log.Print(p.histogramString())
+		for i := range p.histogram {
+			p.histogram[i] = 0
+		}
+		p.spikes = 0
+	}
+}
+
+func (p *FrameProfiler) histogramString() string {
+	var b strings.Builder
+	b.WriteString("frame times:")
+	for i, n := range p.histogram {
+		if i < len(frameTimeBuckets) {
+			fmt.Fprintf(&b, " <=%.0fms:%d", frameTimeBuckets[i], n)
+		} else {
+			fmt.Fprintf(&b, " >%.0fms:%d", frameTimeBuckets[i-1], n)
+		}
+	}
+	fmt.Fprintf(&b, " spikes:%d", p.spikes)
+	return b.String()
+}
+
+// Draw shows the recent frame times at the bottom of the screen, one pixel
+// column per frame and one pixel row per millisecond.
+func (p *FrameProfiler) Draw(screen *ebiten.Image) {
+	if p == nil {
+		return
+	}
+
+	_, h := screen.Size()
+	target := p.targetFrameTime()
+	for i := range p.history {
+		d := p.history[(p.pos+i)%len(p.history)]
+		if d == 0 {
+			continue
+		}
+		clr := color.Color(frameGraphColor)
+		if d > target*frameSpikeFactor {
+			clr = frameSpikeColor
+		}
+		bar := float64(d) / float64(time.Millisecond)
+		ebitenutil.DrawRect(screen, float64(i), float64(h)-bar, 1, bar, clr)
+	}
+	y := float64(h) - float64(target)/float64(time.Millisecond)
+	ebitenutil.DrawLine(screen, 0, y, frameProfilerHistory, y, color.White)
+}
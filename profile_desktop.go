@@ -0,0 +1,20 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"log"
+	"net/http"
+	_ "net/http/pprof"
+)
+
+// startProfileServer serves net/http/pprof (including trace) at addr.
+func startProfileServer(addr string) {
+	go func() {
+		log.Printf("Serving pprof at http://%s/debug/pprof/", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			log.Printf("Failed to serve pprof: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,14 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"log"
+)
+
+// startProfileServer is not available in browsers; only the frame-time
+// histograms are logged there.
+func startProfileServer(addr string) {
+	log.Print("pprof is not available on this platform")
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	eventQueueFileName = "events.json"
+	// the oldest events are dropped beyond this, so that a player who is
+	// never online doesn't pile them up forever
+	eventQueueCapacity = 1000
+	eventBatchSize     = 20
+	eventFlushInterval = 5 * time.Second
+	eventMinBackoff    = 2 * time.Second
+	eventMaxBackoff    = 2 * time.Minute
+)
+
+type savedEvents struct {
+	Events []map[string]interface{} `json:"events"`
+}
+
+// EventQueue holds the events to log until the server takes them, saving
+// them to the storage meanwhile so that they also survive the game being
+// closed while offline. Events are sent in batches every few seconds, or as
+// soon as a batch is full, and retried with an exponential backoff when the
+// server can't be reached.
+//
+// Pushing an event doesn't save the queue, which would rewrite all of it on
+// the game loop for every event. It is saved in the background along with
+// the sending, and by Save when the game goes to the background.
+type EventQueue struct {
+	mu      sync.Mutex
+	events  []map[string]interface{}
+	dropped int
+	// whether the events changed since they were saved
+	dirty   bool
+	storage Storage
+	send    func(payload map[string]interface{}) error
+	wake    chan struct{}
+	// serializes the saves, which are written out of the lock
+	saveMu sync.Mutex
+}
+
+func NewEventQueue(storage Storage, send func(payload map[string]interface{}) error) *EventQueue {
+	var saved savedEvents
+	if err := loadJSON(storage, eventQueueFileName, &saved); err != nil {
+		log.Printf("Failed to load unsent events: %v", err)
+	}
+	return &EventQueue{
+		events:  saved.Events,
+		storage: storage,
+		send:    send,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (q *EventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+func (q *EventQueue) Push(payload map[string]interface{}) {
+	q.mu.Lock()
+	q.events = append(q.events, payload)
+	if n := len(q.events) - eventQueueCapacity; n > 0 {
+		q.events = q.events[n:]
+		q.dropped += n
+	}
+	full := len(q.events) >= eventBatchSize
+	q.dirty = true
+	q.mu.Unlock()
+
+	if full {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Clear throws away the events not sent yet.
+func (q *EventQueue) Clear() {
+	q.mu.Lock()
+	q.dropped += len(q.events)
+	q.events = nil
+	q.dirty = true
+	q.mu.Unlock()
+	q.Save()
+}
+
+// Save writes the events not sent yet to the storage, if they changed.
+func (q *EventQueue) Save() {
+	q.saveMu.Lock()
+	defer q.saveMu.Unlock()
+
+	q.mu.Lock()
+	if !q.dirty {
+		q.mu.Unlock()
+		return
+	}
+	events := append([]map[string]interface{}(nil), q.events...)
+	q.dirty = false
+	q.mu.Unlock()
+
+	if err := saveJSON(q.storage, eventQueueFileName, savedEvents{Events: events}); err != nil {
+		log.Printf("Failed to save unsent events: %v", err)
+		q.mu.Lock()
+		q.dirty = true
+		q.mu.Unlock()
+	}
+}
+
+// Run sends the queued events for as long as the game runs.
+func (q *EventQueue) Run() {
+	var backoff time.Duration
+	for {
+		if backoff > 0 {
+			time.Sleep(backoff)
+		} else {
+			select {
+			case <-q.wake:
+			case <-time.After(eventFlushInterval):
+			}
+		}
+
+		err := q.flush()
+		q.Save()
+		if err != nil {
+			backoff = nextEventBackoff(backoff)
+			log.Printf("Failed to send events, retrying in %v: %v", backoff, err)
+		} else {
+			backoff = 0
+		}
+	}
+}
+
+func nextEventBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d < eventMinBackoff {
+		return eventMinBackoff
+	}
+	if d > eventMaxBackoff {
+		return eventMaxBackoff
+	}
+	return d
+}
+
+// flush sends batches until the queue is empty or sending fails.
+func (q *EventQueue) flush() error {
+	for {
+		more, err := q.sendBatch()
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// sendBatch sends the oldest events in order, keeping those from the first
+// one which failed on, so that a retry goes on after the last one the server
+// took. It reports whether more events are left.
+func (q *EventQueue) sendBatch() (bool, error) {
+	q.mu.Lock()
+	n := len(q.events)
+	if n > eventBatchSize {
+		n = eventBatchSize
+	}
+	batch := append([]map[string]interface{}(nil), q.events[:n]...)
+	dropped := q.dropped
+	q.mu.Unlock()
+
+	sent := 0
+	var err error
+	for _, e := range batch {
+		if err = q.send(e); err != nil {
+			break
+		}
+		sent++
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Events sent meanwhile dropped for the capacity are already gone
+	sent -= q.dropped - dropped
+	if sent > 0 {
+		q.events = q.events[sent:]
+		q.dirty = true
+	}
+	return len(q.events) > 0, err
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+)
+
+const (
+	// in meters, multiples of the checkpoint distance so that every
+	// checkpoint is a split of the race too
+	raceDistanceStep    = checkpointDistance
+	minRaceDistance     = checkpointDistance
+	maxRaceDistance     = 10000
+	defaultRaceDistance = 1000
+	// the best times kept for every distance
+	raceRankingSize   = 5
+	finishLineSquare  = 16
+	resultsBannerY    = 90
+	resultsRankingTop = resultsBannerY + regularFontSize*10
+	// seconds between the fireworks, and of a burst
+	fireworkInterval  = 0.45
+	fireworkLife      = 1.4
+	fireworkParticles = 36
+	fireworkSpeed     = 140
+	fireworkGravity   = 60
+)
+
+var fireworkColors = []color.RGBA{
+	{0xff, 0x60, 0x60, 0xff},
+	{0xff, 0xd0, 0x40, 0xff},
+	{0x60, 0xe0, 0xff, 0xff},
+	{0xa0, 0xff, 0x80, 0xff},
+	{0xff, 0x90, 0xff, 0xff},
+}
+
+func (g *Game) adjustRaceDistance(delta int) {
+	d := g.raceDistance + delta*raceDistanceStep
+	if d < minRaceDistance {
+		d = maxRaceDistance
+	} else if d > maxRaceDistance {
+		d = minRaceDistance
+	}
+	g.raceDistance = d
+}
+
+// newRaceMenu starts races to a finish line at a chosen distance. They are
+// timed like the speedruns, and ranked by the time by distance. Retrying
+// keeps racing until back to the title.
+func (g *Game) newRaceMenu() *Menu {
+	return &Menu{
+		title: "RACE",
+		y:     titleMenuY,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label:  func() string { return fmt.Sprintf("TO %sm", formatIntComma(g.raceDistance)) },
+				action: func() { g.adjustRaceDistance(1) },
+				adjust: g.adjustRaceDistance,
+			},
+			{
+				label: func() string { return "START" },
+				action: func() {
+					g.practice = false
+					g.racing = true
+					g.speedrunTarget = g.raceDistance
+					g.startGame()
+				},
+			},
+			{
+				label:  func() string { return "BACK" },
+				action: func() { g.mode = ModeTitle },
+			},
+		},
+	}
+}
+
+// updateRaceRanking puts the time of a finished race in the ranking of its
+// distance, if it is good enough, and keeps its rank.
+func (g *Game) updateRaceRanking() {
+	if !g.racing || !g.stats.finished {
+		return
+	}
+	d, t := g.speedrunTarget, g.stats.finishTime
+	times := g.records.RaceTimes[d]
+	rank := len(times)
+	for i, other := range times {
+		if t < other {
+			rank = i
+			break
+		}
+	}
+	if rank >= raceRankingSize {
+		return
+	}
+	times = append(times, 0)
+	copy(times[rank+1:], times[rank:])
+	times[rank] = t
+	if len(times) > raceRankingSize {
+		times = times[:raceRankingSize]
+	}
+	if g.records.RaceTimes == nil {
+		g.records.RaceTimes = map[int][]float64{}
+	}
+	g.records.RaceTimes[d] = times
+	g.stats.raceRank = rank + 1
+}
+
+// drawFinishLine draws a checkered line across the sky at the finish of the
+// race.
+func (g *Game) drawFinishLine(screen *ebiten.Image) {
+	if !g.racing {
+		return
+	}
+	x := float64(g.speedrunTarget*10) - g.camera.ViewX()
+	if x < -finishLineSquare*2 || x > screenWidth {
+		return
+	}
+	for row := 0; row*finishLineSquare < screenHeight; row++ {
+		for col := 0; col < 2; col++ {
+			clr := color.White
+			if (row+col)%2 == 1 {
+				clr = color.Black
+			}
+			ebitenutil.DrawRect(screen, x+float64(col*finishLineSquare), float64(row*finishLineSquare), finishLineSquare, finishLineSquare, clr)
+		}
+	}
+}
+
+type fireworkParticle struct {
+	x, y, vx, vy float64
+	clr          color.RGBA
+	time         float64
+}
+
+// Fireworks burst over the results of a race. They have a random source of
+// their own so as not to change the runs after.
+type Fireworks struct {
+	rand      *rand.Rand
+	particles []fireworkParticle
+	next      float64
+}
+
+func NewFireworks() *Fireworks {
+	return &Fireworks{rand: rand.New(rand.NewSource(1))}
+}
+
+func (f *Fireworks) Reset() {
+	f.particles = f.particles[:0]
+	f.next = 0
+}
+
+func (f *Fireworks) Update(dt float64) {
+	f.next -= dt
+	if f.next <= 0 {
+		f.next = fireworkInterval
+		x, y := 60+f.rand.Float64()*(screenWidth-120), 40+f.rand.Float64()*screenHeight/3
+		clr := fireworkColors[f.rand.Intn(len(fireworkColors))]
+		for i := 0; i < fireworkParticles; i++ {
+			a := 2 * math.Pi * float64(i) / fireworkParticles
+			s := fireworkSpeed * (0.7 + 0.3*f.rand.Float64())
+			f.particles = append(f.particles, fireworkParticle{x: x, y: y, vx: s * math.Cos(a), vy: s * math.Sin(a), clr: clr})
+		}
+	}
+
+	n := 0
+	for i := range f.particles {
+		p := &f.particles[i]
+		p.time += dt
+		p.vy += fireworkGravity * dt
+		p.x += p.vx * dt
+		p.y += p.vy * dt
+		if p.time < fireworkLife {
+			f.particles[n] = *p
+			n++
+		}
+	}
+	f.particles = f.particles[:n]
+}
+
+func (f *Fireworks) Draw(screen *ebiten.Image) {
+	for i := range f.particles {
+		p := &f.particles[i]
+		clr := p.clr
+		a := 1 - p.time/fireworkLife
+		clr.R, clr.G, clr.B, clr.A = uint8(float64(clr.R)*a), uint8(float64(clr.G)*a), uint8(float64(clr.B)*a), uint8(float64(clr.A)*a)
+		ebitenutil.DrawRect(screen, p.x-1, p.y-1, 3, 3, clr)
+	}
+}
+
+func (g *Game) updateResults() {
+	g.fireworks.Update(1 / float64(ebiten.MaxTPS()))
+	if g.isRetryButtonTapped(gameOverRetryButtonY) {
+		g.restart()
+	} else if g.input.IsJustTapped() {
+		g.challenge, g.daily = nil, ""
+		g.initialize()
+	}
+}
+
+// drawResults draws the results screen of a finished race: the time, its
+// rank and the ranking of the distance.
+func (g *Game) drawResults(screen *ebiten.Image) {
+	g.fireworks.Draw(screen)
+	drawCentered := func(s string, face font.Face, size, y int, clr color.Color) {
+		text.Draw(screen, s, face, screenWidth/2-len(s)*size/2, y, clr)
+	}
+	drawCentered("FINISH!", titleFont, titleFontSize, resultsBannerY, color.White)
+	drawCentered(fmt.Sprintf("%sm RACE", formatIntComma(g.speedrunTarget)), regularFont, regularFontSize, resultsBannerY+regularFontSize*3, color.White)
+	drawCentered(formatRunTime(g.stats.finishTime), titleFont, titleFontSize, resultsBannerY+regularFontSize*6, color.White)
+	rankText := "OUT OF THE TOP 5"
+	switch {
+	case !g.stats.recorded():
+		rankText = "NOT RECORDED"
+	case g.stats.raceRank == 1:
+		rankText = "NEW RECORD!"
+	case g.stats.raceRank > 0:
+		rankText = fmt.Sprintf("RANK %d", g.stats.raceRank)
+	}
+	if g.stats.challengeResult != "" {
+		rankText = g.stats.challengeResult
+	}
+	drawCentered(rankText, regularFont, regularFontSize, resultsBannerY+regularFontSize*8, color.White)
+
+	for i, t := range g.records.RaceTimes[g.speedrunTarget] {
+		s := fmt.Sprintf("%d. %s", i+1, formatRunTime(t))
+		clr := color.Color(color.White)
+		if i+1 == g.stats.raceRank && g.stats.recorded() {
+			clr = splitAheadColor
+		}
+		drawCentered(s, smallFont, smallFontSize, resultsRankingTop+i*smallFontSize*2, clr)
+	}
+	drawCentered(retryText, smallFont, smallFontSize, gameOverRetryButtonY, color.White)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	progressBarY      = 8
+	progressBarHeight = 4
+	progressBarMargin = 24
+	// in meters
+	splitDistance = 250
+	splitShowTime = 3
+)
+
+var (
+	progressBarColor     = color.RGBA{0xff, 0xff, 0xff, 0x40}
+	progressBarFillColor = color.RGBA{0xff, 0xe0, 0x60, 0xc0}
+	progressBarBestColor = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	splitAheadColor      = color.RGBA{0x60, 0xff, 0x60, 0xff}
+	splitBehindColor     = color.RGBA{0xff, 0x60, 0x60, 0xff}
+)
+
+// Records are the player's bests, saved apart from the settings.
+type Records struct {
+	// in meters
+	BestDistance int `json:"best_distance"`
+	// seconds into the best run at every split distance
+	BestSplits    []float64 `json:"best_splits"`
+	Runs          int       `json:"runs"`
+	TotalDistance int       `json:"total_distance"`
+	// the code the best distance is kept under on the server
+	SyncCode string `json:"sync_code,omitempty"`
+	// the latest runs, oldest first
+	History    []RunRecord        `json:"history"`
+	Difficulty AdaptiveDifficulty `json:"difficulty"`
+	// earned by the runs and spent in the shop
+	Coins int `json:"coins"`
+	// the levels of the upgrades bought, by id
+	Upgrades map[string]int `json:"upgrades,omitempty"`
+	// the ids of the scenes and the tracks unlocked
+	Unlocked []string `json:"unlocked,omitempty"`
+	// the best time of every sector between the checkpoints over all runs
+	BestSectors []float64 `json:"best_sectors,omitempty"`
+	// the best times in seconds of the speedruns, by their target in meters
+	BestTimes map[int]float64 `json:"best_times,omitempty"`
+	// the best times in seconds of the races, fastest first, by their
+	// distance in meters
+	RaceTimes map[int][]float64 `json:"race_times,omitempty"`
+	// the times the island was reached, in the game and in the new game+
+	// loops after it, which may be played again
+	Loops int `json:"loops,omitempty"`
+	// the best distance of the daily run of the date
+	DailyDate string `json:"daily_date,omitempty"`
+	DailyBest int    `json:"daily_best,omitempty"`
+}
+
+// SplitTimer times the run at every split distance, like a speedrun timer,
+// and compares the latest split with the best run.
+type SplitTimer struct {
+	time   float64
+	splits []float64
+	// seconds behind the best run at the latest split, negative if ahead
+	diff     float64
+	compared bool
+	shownAt  float64
+}
+
+func (s *SplitTimer) Reset() {
+	s.time = 0
+	s.splits = s.splits[:0]
+	s.compared = false
+}
+
+const recordsFileName = "records.json"
+
+// LoadRecords reads the saved records. Missing or broken records are not
+// fatal; they start over instead.
+func LoadRecords(storage Storage) *Records {
+	r := &Records{}
+	if err := loadJSON(storage, recordsFileName, r); err != nil {
+		log.Printf("Failed to load records: %v", err)
+		return &Records{}
+	}
+	return r
+}
+
+func (r *Records) Save(storage Storage) error {
+	return saveJSON(storage, recordsFileName, r)
+}
+
+// updateRecords adds the run just over to the records, keeping its distance
+// and splits if it is a new best.
+func (g *Game) updateRecords() {
+	d := int(g.birdman.x) / 10
+	g.records.Runs++
+	g.records.TotalDistance += d
+	g.records.History = append(g.records.History, RunRecord{
+		Date:        time.Now().UTC(),
+		Distance:    d,
+		Duration:    g.splits.time,
+		StylePoints: g.stylePoints,
+		Cause:       g.stats.cause.String(),
+	})
+	if n := len(g.records.History) - maxRunHistory; n > 0 {
+		g.records.History = append(g.records.History[:0], g.records.History[n:]...)
+	}
+	if d > g.records.BestDistance {
+		g.records.BestDistance = d
+		g.records.BestSplits = append(g.records.BestSplits[:0], g.splits.splits...)
+	}
+	g.updateBestSectors()
+	g.updateBestTime()
+	g.updateRaceRanking()
+	if g.stats.landed && g.loop >= g.records.Loops {
+		g.records.Loops = g.loop + 1
+	}
+	g.adjustDifficulty()
+	g.stats.coins = g.runCoins()
+	g.records.Coins += g.stats.coins
+	g.stats.unlocked = g.updateUnlocks()
+	if err := g.records.Save(g.storage); err != nil {
+		log.Printf("Failed to save records: %v", err)
+	}
+}
+
+// updateSplits advances the run timer and takes a split whenever the
+// birdman passes the next split distance.
+func (g *Game) updateSplits() {
+	s := &g.splits
+	s.time += simulationStep
+	if int(g.birdman.x)/10 < (len(s.splits)+1)*splitDistance {
+		return
+	}
+	i := len(s.splits)
+	s.splits = append(s.splits, s.time)
+	if i < len(g.records.BestSplits) {
+		s.diff = s.time - g.records.BestSplits[i]
+		s.compared = true
+		s.shownAt = s.time
+	}
+}
+
+// splitText returns the comparison of the latest split with the best run
+// while it is shown.
+func (g *Game) splitText() (text string, clr color.Color, ok bool) {
+	s := &g.splits
+	if !s.compared || s.time-s.shownAt >= splitShowTime {
+		return "", nil, false
+	}
+	at := formatIntComma(len(s.splits) * splitDistance)
+	if s.diff <= 0 {
+		return fmt.Sprintf("-%.1fs AHEAD AT %sM", -s.diff, at), splitAheadColor, true
+	}
+	return fmt.Sprintf("+%.1fs BEHIND AT %sM", s.diff, at), splitBehindColor, true
+}
+
+// drawProgressBar draws a thin bar across the top of the screen filling up
+// towards the next milestone, with a marker at the personal best when it
+// lies before that milestone.
+func (g *Game) drawProgressBar(screen *ebiten.Image) {
+	d := int(g.birdman.x) / 10
+	if d < 0 {
+		d = 0
+	}
+	start := d / milestoneDistance * milestoneDistance
+	w := float64(screenWidth - 2*progressBarMargin)
+	ebitenutil.DrawRect(screen, progressBarMargin, progressBarY, w, progressBarHeight, progressBarColor)
+	fill := float64(d-start) / milestoneDistance
+	ebitenutil.DrawRect(screen, progressBarMargin, progressBarY, w*fill, progressBarHeight, progressBarFillColor)
+
+	if best := g.records.BestDistance; best > start && best < start+milestoneDistance {
+		x := progressBarMargin + w*float64(best-start)/milestoneDistance
+		ebitenutil.DrawRect(screen, x-1, progressBarY-3, 2, progressBarHeight+6, progressBarBestColor)
+	}
+}
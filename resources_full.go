@@ -0,0 +1,13 @@
+//go:build !lite
+// +build !lite
+
+package main
+
+import (
+	"embed"
+)
+
+const resourcesRoot = "resources"
+
+//go:embed resources
+var resources embed.FS
@@ -0,0 +1,15 @@
+//go:build lite
+// +build lite
+
+package main
+
+import (
+	"embed"
+)
+
+// The lite build embeds the resources packed by cmd/packassets, see the lite
+// target of the Makefile.
+const resourcesRoot = "resources_lite"
+
+//go:embed resources_lite
+var resources embed.FS
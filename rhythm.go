@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// the fraction of a beat either side of it a flap counts as on it
+	rhythmWindow    = 0.18
+	rhythmFlapBonus = 0.15
+	// the groove gained by a flap on the beat and lost by one off it
+	grooveGain   = 0.2
+	grooveLoss   = 0.4
+	grooveDecay  = 0.1
+	groovePoints = 50
+	// the meters on the HUD
+	meterWidth = 80
+	meterX     = 24 + smallFontSize*7
+)
+
+var (
+	meterBackColor  = color.RGBA{0x40, 0x40, 0x40, 0xc0}
+	grooveFillColor = color.RGBA{0xe0, 0x60, 0xe0, 0xff}
+	grooveBeatColor = color.RGBA{0xff, 0xa0, 0xff, 0xff}
+)
+
+// rhythmOn reports whether the flaps on the beat are stronger. Not in a
+// challenge, which must play the same on every device.
+func (g *Game) rhythmOn() bool {
+	return g.settings.Rhythm && g.challenge == nil
+}
+
+// onBeat reports whether it is the time to flap on the beat of the music.
+func (g *Game) onBeat() bool {
+	if !g.rhythmOn() {
+		return false
+	}
+	_, phase := g.music.Beat()
+	return phase < rhythmWindow || phase > 1-rhythmWindow
+}
+
+// rhythmFlap builds the groove on a flap and returns what to multiply its
+// power by. A full groove is worth style points and starts over.
+func (g *Game) rhythmFlap() float64 {
+	if !g.rhythmOn() {
+		return 1
+	}
+	if !g.onBeat() {
+		g.groove = math.Max(0, g.groove-grooveLoss)
+		return 1
+	}
+	g.groove += grooveGain
+	if g.groove >= 1 {
+		g.groove = 0
+		g.award("IN THE GROOVE!", groovePoints)
+	}
+	return 1 + rhythmFlapBonus
+}
+
+// updateGroove lets the groove fade while the birdman doesn't keep it up.
+func (g *Game) updateGroove() {
+	g.groove = math.Max(0, g.groove-grooveDecay*simulationStep)
+}
+
+// drawMeter draws a labeled bar on the HUD line at y, filled from 0 to 1.
+func drawMeter(screen *ebiten.Image, label string, y int, fill float64, clr color.Color) {
+	text.Draw(screen, label, smallFont, 24, y, color.White)
+	top := float64(y - smallFontSize)
+	ebitenutil.DrawRect(screen, meterX, top, meterWidth, smallFontSize, meterBackColor)
+	ebitenutil.DrawRect(screen, meterX, top, meterWidth*fill, smallFontSize, clr)
+}
+
+// drawGroove draws the groove meter, pulsing with the beat.
+func (g *Game) drawGroove(screen *ebiten.Image, y int) {
+	clr := grooveFillColor
+	if g.onBeat() {
+		clr = grooveBeatColor
+	}
+	drawMeter(screen, "GROOVE", y, g.groove, clr)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	ringRadius      = 45
+	ringWidth       = 14
+	ringPoints      = 50
+	ringMaxStep     = 120
+	ringFadeTime    = 0.3
+	ringSegments    = 24
+	ringGlowPeriod  = 1.0
+	ringSpawnMargin = 60
+)
+
+var ringColor = color.RGBA{0xff, 0xe0, 0x60, 0xff}
+
+// Ring is a bonus hoop to fly through, seen edge-on as the birdman crosses
+// the course.
+type Ring struct {
+	x, y       float64
+	passed     bool
+	passedTime float64
+}
+
+func (g *Game) spawnRing() {
+	// Keep each ring within reach of the previous one, so rings can be
+	// chained
+	y := g.lastRingY + (g.rand.Float64()*2-1)*ringMaxStep
+	if g.lastRingY == 0 {
+		y = screenHeight / 2
+	}
+	y = math.Max(g.config.AltitudeZoneHeight+ringRadius, math.Min(g.config.FishSkimY-ringRadius, y))
+	g.lastRingY = y
+	g.rings = append(g.rings, Ring{x: g.birdman.x + screenWidth + ringSpawnMargin, y: y})
+}
+
+// updateRings spawns rings and rewards the birdman for flying through them
+// since (prevX, prevY). A pass is the path crossing the ring's opening, as
+// the birdman easily moves further than the ring is thick in a step. Each
+// ring in a row without missing one is worth more.
+func (g *Game) updateRings(prevX, prevY float64) {
+	birdman := g.birdman
+	if !g.inSpace() && birdman.x >= g.config.RingStartX && birdman.x >= g.nextRingX {
+		g.nextRingX = birdman.x + g.config.RingInterval
+		g.spawnRing()
+	}
+
+	n := 0
+	for i := range g.rings {
+		r := &g.rings[i]
+		if r.passed {
+			r.passedTime += simulationStep
+		} else if segmentsCross(prevX, prevY, birdman.x, birdman.y, r.x, r.y-ringRadius, r.x, r.y+ringRadius) {
+			r.passed = true
+			g.ringChain++
+			g.stats.items++
+			name := "RING!"
+			if g.ringChain > 1 {
+				name = fmt.Sprintf("RING x%d!", g.ringChain)
+			}
+			g.award(name, ringPoints*g.ringChain)
+			birdman.vx += g.config.RingBoost
+			g.sfx.PlaySE(ringAudioData)
+		} else if r.x < birdman.x-birdmanWidth/2 {
+			// Missed
+			g.ringChain = 0
+			r.passed = true
+			r.passedTime = ringFadeTime
+		}
+
+		if (!r.passed || r.passedTime < ringFadeTime) && r.x+ringRadius > g.camera.ViewX() {
+			g.rings[n] = *r
+			n++
+		}
+	}
+	g.rings = g.rings[:n]
+}
+
+// drawRings draws the rings as glowing ellipses, the passed ones bursting
+// outwards.
+func (g *Game) drawRings(screen *ebiten.Image) {
+	for i := range g.rings {
+		r := &g.rings[i]
+		scale, alpha := 1.0, 0.7+0.3*math.Sin(2*math.Pi*g.tricks.time/ringGlowPeriod)
+		if r.passed {
+			t := r.passedTime / ringFadeTime
+			scale, alpha = 1+t, 1-t
+		}
+		if alpha <= 0 {
+			continue
+		}
+		clr := ringColor
+		clr.A = uint8(0xff * alpha)
+
+		cx, cy := r.x-g.camera.ViewX(), r.y-g.camera.ViewY()
+		rx, ry := ringWidth/2*scale, ringRadius*scale
+		for j := 0; j < ringSegments; j++ {
+			a0 := 2 * math.Pi * float64(j) / ringSegments
+			a1 := 2 * math.Pi * float64(j+1) / ringSegments
+			ebitenutil.DrawLine(screen, cx+rx*math.Cos(a0), cy+ry*math.Sin(a0), cx+rx*math.Cos(a1), cy+ry*math.Sin(a1), clr)
+			ebitenutil.DrawLine(screen, cx+(rx+2)*math.Cos(a0), cy+(ry+2)*math.Sin(a0), cx+(rx+2)*math.Cos(a1), cy+(ry+2)*math.Sin(a1), clr)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	defaultScene  = "seaside"
+	lockedTextTop = titleMenuY + regularFontSize*8
+	// the menu rows and the locked ones listed under them, to fit the screen
+	sceneryMenuRows = 3
+	maxLockedRows   = 4
+)
+
+type UnlockKind int
+
+const (
+	UnlockScene UnlockKind = iota
+	UnlockTrack
+	// a way to play, turned on and off in the scenery
+	UnlockVariant
+)
+
+// Unlockable is a background scene, a music track or a variant to pick
+// before a run, unlocked by a milestone.
+type Unlockable struct {
+	ID   string
+	Kind UnlockKind
+	Name string
+	// how it is unlocked, shown while locked
+	Hint string
+	// reports whether the run just over unlocks it, nil if it is unlocked
+	// from the start
+	unlocks func(g *Game) bool
+}
+
+func bestAtLeast(meters int) func(g *Game) bool {
+	return func(g *Game) bool { return g.records.BestDistance >= meters }
+}
+
+var unlockables = []Unlockable{
+	{ID: defaultScene, Kind: UnlockScene, Name: "SEASIDE"},
+	{ID: "city", Kind: UnlockScene, Name: "CITY SKYLINE", Hint: "BEST 1,000m", unlocks: bestAtLeast(1000)},
+	{ID: "mountains", Kind: UnlockScene, Name: "MOUNTAINS", Hint: "BEST 3,000m", unlocks: bestAtLeast(3000)},
+	{ID: "aurora", Kind: UnlockScene, Name: "NIGHT AURORA", Hint: "REACH SPACE", unlocks: func(g *Game) bool { return g.stats.reachedSpace }},
+	{ID: defaultMusicTrack, Kind: UnlockTrack, Name: "RETRO"},
+	{ID: "dusk", Kind: UnlockTrack, Name: "DUSK", Hint: "BEST 2,000m", unlocks: bestAtLeast(2000)},
+	{ID: "stardust", Kind: UnlockTrack, Name: "STARDUST", Hint: "500 STYLE IN A RUN", unlocks: func(g *Game) bool { return g.stylePoints >= 500 }},
+	{ID: mirrorUnlockID, Kind: UnlockVariant, Name: "MIRROR MODE", Hint: "BEST 5,000m", unlocks: bestAtLeast(5000)},
+	{ID: nightUnlockID, Kind: UnlockVariant, Name: "NIGHT MODE", Hint: "BEST 8,000m", unlocks: bestAtLeast(8000)},
+}
+
+func findUnlockable(id string) *Unlockable {
+	for i := range unlockables {
+		if unlockables[i].ID == id {
+			return &unlockables[i]
+		}
+	}
+	return nil
+}
+
+func (g *Game) isUnlocked(u *Unlockable) bool {
+	if u.unlocks == nil {
+		return true
+	}
+	for _, id := range g.records.Unlocked {
+		if id == u.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// updateUnlocks unlocks what the run just over has earned, and returns the
+// names of them.
+func (g *Game) updateUnlocks() []string {
+	var names []string
+	for i := range unlockables {
+		u := &unlockables[i]
+		if !g.isUnlocked(u) && u.unlocks(g) {
+			g.records.Unlocked = append(g.records.Unlocked, u.ID)
+			names = append(names, u.Name)
+		}
+	}
+	return names
+}
+
+// selected returns the unlockable of the kind picked in the settings, or
+// the first one if that is locked.
+func (g *Game) selected(kind UnlockKind) *Unlockable {
+	id := g.settings.Scene
+	if kind == UnlockTrack {
+		id = g.settings.Track
+	}
+	var first *Unlockable
+	for i := range unlockables {
+		u := &unlockables[i]
+		if u.Kind != kind {
+			continue
+		}
+		if first == nil {
+			first = u
+		}
+		if u.ID == id && g.isUnlocked(u) {
+			return u
+		}
+	}
+	return first
+}
+
+// cycleUnlockable picks the next or the previous unlocked one of the kind.
+func (g *Game) cycleUnlockable(kind UnlockKind, delta int) {
+	var ids []string
+	current := 0
+	for i := range unlockables {
+		u := &unlockables[i]
+		if u.Kind != kind || !g.isUnlocked(u) {
+			continue
+		}
+		if u == g.selected(kind) {
+			current = len(ids)
+		}
+		ids = append(ids, u.ID)
+	}
+	id := ids[((current+delta)%len(ids)+len(ids))%len(ids)]
+	if kind == UnlockScene {
+		g.settings.Scene = id
+	} else {
+		g.settings.Track = id
+	}
+	g.applyScenery()
+	g.saveSettings()
+}
+
+// applyScenery puts up the scene and plays the track selected.
+func (g *Game) applyScenery() {
+	if g.backdrop != nil {
+		if img, ok := sceneImages[g.selected(UnlockScene).ID]; ok {
+			g.backdrop.SetSky(img)
+		}
+	}
+	g.music.SetTrack(musicTracks[g.selected(UnlockTrack).ID])
+}
+
+func (g *Game) newSceneryMenu() *Menu {
+	item := func(name string, kind UnlockKind) MenuItem {
+		return MenuItem{
+			label:  func() string { return name + ": " + g.selected(kind).Name },
+			action: func() { g.cycleUnlockable(kind, 1) },
+			adjust: func(delta int) { g.cycleUnlockable(kind, delta) },
+		}
+	}
+	return &Menu{
+		title: "SCENERY",
+		y:     titleMenuY,
+		sfx:   g.sfx,
+		rows:  sceneryMenuRows,
+		items: []MenuItem{
+			item("SCENE", UnlockScene),
+			item("MUSIC", UnlockTrack),
+			{
+				label: func() string { return "MIRROR MODE: " + onOff(g.settings.Mirror) },
+				action: func() {
+					g.settings.Mirror = !g.settings.Mirror
+					g.saveSettings()
+				},
+				visible: func() bool { return g.isUnlocked(findUnlockable(mirrorUnlockID)) },
+			},
+			{
+				label: func() string { return "NIGHT MODE: " + onOff(g.settings.Night) },
+				action: func() {
+					g.settings.Night = !g.settings.Night
+					g.saveSettings()
+				},
+				visible: func() bool { return g.isUnlocked(findUnlockable(nightUnlockID)) },
+			},
+			{
+				label:  func() string { return "BACK" },
+				action: func() { g.mode = ModeTitle },
+			},
+		},
+	}
+}
+
+// drawScenery draws the menu over the scene picked, with how to unlock the
+// ones still locked.
+func (g *Game) drawScenery(screen *ebiten.Image) {
+	g.sceneryMenu.Draw(screen)
+	y, rows := lockedTextTop, 0
+	for i := range unlockables {
+		u := &unlockables[i]
+		if g.isUnlocked(u) {
+			continue
+		}
+		if rows++; rows > maxLockedRows {
+			continue
+		}
+		s := fmt.Sprintf("LOCKED %s: %s", u.Name, u.Hint)
+		text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, y, color.White)
+		y += smallFontSize * 2
+	}
+	if rows > maxLockedRows {
+		s := fmt.Sprintf("AND %d MORE LOCKED", rows-maxLockedRows)
+		text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, y, color.White)
+	}
+}
+
+// sceneImages are the sky tiles of the scenes by the id of their
+// unlockable.
+var sceneImages map[string]*ebiten.Image
+
+// newSceneImages draws the scenes at the size of the original background,
+// which they stand in for.
+func newSceneImages() map[string]*ebiten.Image {
+	w, h := backgroundImg.Size()
+	return map[string]*ebiten.Image{
+		defaultScene: backgroundImg,
+		"city":       ebiten.NewImageFromImage(newCityImage(w, h)),
+		"mountains":  ebiten.NewImageFromImage(newMountainsImage(w, h)),
+		"aurora":     ebiten.NewImageFromImage(newAuroraImage(w, h)),
+	}
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	l := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+	return color.RGBA{l(a.R, b.R), l(a.G, b.G), l(a.B, b.B), 0xff}
+}
+
+func newGradientImage(w, h int, top, bottom color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		c := lerpColor(top, bottom, float64(y)/float64(h-1))
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// fillSilhouette fills every column under the height the function gives
+// for it, counted from the bottom.
+func fillSilhouette(img *image.RGBA, clr color.RGBA, height func(x int) int) {
+	b := img.Bounds()
+	for x := 0; x < b.Dx(); x++ {
+		for y := b.Dy() - height(x); y < b.Dy(); y++ {
+			img.SetRGBA(x, y, clr)
+		}
+	}
+}
+
+// wave is a sum of sines whose periods divide w, so that the tiles join up.
+func wave(x, w int, amplitudes ...float64) float64 {
+	v := 0.0
+	for i, a := range amplitudes {
+		v += a * math.Sin(2*math.Pi*float64((i+1)*x)/float64(w)+float64(i))
+	}
+	return v
+}
+
+func newCityImage(w, h int) *image.RGBA {
+	img := newGradientImage(w, h, color.RGBA{0x3a, 0x5a, 0xa8, 0xff}, color.RGBA{0xf8, 0xc0, 0x80, 0xff})
+	building := color.RGBA{0x30, 0x30, 0x48, 0xff}
+	window := color.RGBA{0xff, 0xe0, 0x80, 0xff}
+	r := rand.New(rand.NewSource(1))
+	for x := 0; x < w; {
+		bw, bh := 16+r.Intn(24), 40+r.Intn(h/3)
+		for i := x; i < x+bw && i < w; i++ {
+			for y := h - bh; y < h; y++ {
+				c := building
+				if (i-x)%5 == 2 && y%7 == 3 && (i*31+y*17)%3 == 0 {
+					c = window
+				}
+				img.SetRGBA(i, y, c)
+			}
+		}
+		x += bw + r.Intn(3)
+	}
+	return img
+}
+
+func newMountainsImage(w, h int) *image.RGBA {
+	img := newGradientImage(w, h, color.RGBA{0x60, 0xa0, 0xe0, 0xff}, color.RGBA{0xe0, 0xf0, 0xf8, 0xff})
+	snow := color.RGBA{0xf8, 0xf8, 0xff, 0xff}
+	far := func(x int) int { return h/2 + int(wave(x, w, 30, 20, 0, 10)) }
+	fillSilhouette(img, color.RGBA{0x80, 0x98, 0xb8, 0xff}, far)
+	for x := 0; x < w; x++ {
+		// Snow down from the peaks, deeper on the higher ones
+		if depth := (far(x) - h/2 - 16) / 2; depth > 0 {
+			for y := h - far(x); y < h-far(x)+depth; y++ {
+				img.SetRGBA(x, y, snow)
+			}
+		}
+	}
+	fillSilhouette(img, color.RGBA{0x48, 0x60, 0x78, 0xff}, func(x int) int {
+		return h/4 + int(wave(x, w, 0, 24, 12, 0, 6))
+	})
+	return img
+}
+
+func newAuroraImage(w, h int) *image.RGBA {
+	img := newGradientImage(w, h, color.RGBA{0x04, 0x08, 0x20, 0xff}, color.RGBA{0x18, 0x28, 0x50, 0xff})
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 80; i++ {
+		img.SetRGBA(r.Intn(w), r.Intn(h*2/3), color.RGBA{0xff, 0xff, 0xff, 0xff})
+	}
+	light := color.RGBA{0x50, 0xff, 0xa0, 0xff}
+	for x := 0; x < w; x++ {
+		center := float64(h)*0.3 + wave(x, w, 0, 30, 0, 10)
+		strength := 0.5 + 0.5*math.Sin(2*math.Pi*3*float64(x)/float64(w))
+		for y := 0; y < h; y++ {
+			// Sharp at the bottom, fading upwards like curtains
+			d := (float64(y) - center) / 24
+			if d > 0 {
+				d *= 3
+			}
+			if t := math.Exp(-d*d) * strength * 0.7; t > 0.01 {
+				img.SetRGBA(x, y, lerpColor(img.RGBAAt(x, y), light, t))
+			}
+		}
+	}
+	fillSilhouette(img, color.RGBA{0x08, 0x0c, 0x18, 0xff}, func(x int) int {
+		return h/8 + int(wave(x, w, 8, 0, 6))
+	})
+	return img
+}
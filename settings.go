@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+type Settings struct {
+	TiltEnabled bool            `json:"tilt_enabled"`
+	TiltOffset  float64         `json:"tilt_offset"`
+	Minimap     bool            `json:"minimap"`
+	Vibration   bool            `json:"vibration"`
+	CloudSync   bool            `json:"cloud_sync"`
+	Bindings    Bindings        `json:"bindings"`
+	Window      WindowSettings  `json:"window"`
+	Audio       AudioSettings   `json:"audio"`
+	Privacy     PrivacySettings `json:"privacy"`
+	// posted to on a new personal best, empty for none
+	WebhookURL string `json:"webhook_url"`
+	// widen or tighten the gaps between hazards by how the runs go
+	AdaptiveDifficulty bool `json:"adaptive_difficulty"`
+	// the companion flies along once unlocked
+	Companion bool `json:"companion"`
+	// the ids of the scene and the music track picked, see unlockables
+	Scene string `json:"scene"`
+	Track string `json:"track"`
+	// the crowds, their cheers and the commentary
+	Flavor bool `json:"flavor"`
+	// flaps on the beat of the music are stronger and build the groove
+	Rhythm bool `json:"rhythm"`
+	// the world is flipped once unlocked, see mirrored
+	Mirror bool `json:"mirror"`
+	// only the light around the birdman is seen once unlocked, see isNight
+	Night bool `json:"night"`
+	// the intro and the milestone vignettes, see Cutscene
+	Cutscenes bool `json:"cutscenes"`
+	// the coach's hints and jokes, see coachTriggers
+	Hints bool `json:"hints"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{
+		Bindings:  DefaultBindings(),
+		Window:    DefaultWindowSettings(),
+		Audio:     DefaultAudioSettings(),
+		Vibration: true,
+		Companion: true,
+		Flavor:    true,
+		Cutscenes: true,
+		Hints:     true,
+	}
+}
+
+const settingsFileName = "settings.json"
+
+// LoadSettings reads the saved settings over the defaults. Missing or broken
+// settings are not fatal; the defaults are used instead.
+func LoadSettings(storage Storage) *Settings {
+	s := NewSettings()
+	if err := loadJSON(storage, settingsFileName, s); err != nil {
+		log.Printf("Failed to load settings: %v", err)
+		return NewSettings()
+	}
+	return s
+}
+
+func (s *Settings) Save(storage Storage) error {
+	return saveJSON(storage, settingsFileName, s)
+}
+
+// hasWindow tells the platforms with a window to resize.
+func hasWindow() bool {
+	return !mobilePlatform
+}
+
+func onOff(b bool) string {
+	if b {
+		return "ON"
+	}
+	return "OFF"
+}
+
+// newVolumeMenuItem makes a slider adjusted with the left/right keys. Tapping
+// it steps the volume up and wraps around to zero.
+func (g *Game) newVolumeMenuItem(name string, v *float64) MenuItem {
+	return MenuItem{
+		label: func() string { return name + " " + volumeBar(*v) },
+		action: func() {
+			if *v >= 1 {
+				*v = 0
+			} else {
+				adjustVolume(v, 1)
+			}
+			g.audio.applyVolumes()
+			g.saveSettings()
+		},
+		adjust: func(delta int) {
+			adjustVolume(v, delta)
+			g.audio.applyVolumes()
+			g.saveSettings()
+		},
+	}
+}
+
+func (g *Game) newSettingsMenu() *Menu {
+	return &Menu{
+		title: "SETTINGS",
+		y:     110,
+		small: true,
+		rows:  14,
+		items: []MenuItem{
+			{
+				label: func() string { return "CONTROLS" },
+				action: func() {
+					g.mode = ModeControls
+				},
+			},
+			g.newVolumeMenuItem("MASTER", &g.settings.Audio.MasterVolume),
+			g.newVolumeMenuItem("SFX   ", &g.settings.Audio.SFXVolume),
+			g.newVolumeMenuItem("BGM   ", &g.settings.Audio.BGMVolume),
+			{
+				label: func() string { return "MUTE: " + onOff(g.settings.Audio.Muted) },
+				action: func() {
+					g.audio.ToggleMute()
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "BACKGROUND AUDIO: " + onOff(g.settings.Audio.Background) },
+				action: func() {
+					g.settings.Audio.Background = !g.settings.Audio.Background
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "FULLSCREEN: " + onOff(g.settings.Window.Fullscreen) },
+				action: func() {
+					g.toggleFullscreen()
+				},
+				visible: hasWindow,
+			},
+			{
+				label: func() string {
+					if g.settings.Window.RenderScale == 0 {
+						return "RENDER SCALE: AUTO"
+					}
+					return fmt.Sprintf("RENDER SCALE: %dX", g.settings.Window.RenderScale)
+				},
+				action: func() {
+					g.cycleRenderScale()
+				},
+			},
+			{
+				label: func() string {
+					if g.settings.Window.IntegerScaling {
+						return "SCALING: INTEGER"
+					}
+					return "SCALING: LETTERBOX"
+				},
+				action: func() {
+					g.toggleIntegerScaling()
+				},
+			},
+			{
+				label: func() string { return "MINIMAP: " + onOff(g.settings.Minimap) },
+				action: func() {
+					g.settings.Minimap = !g.settings.Minimap
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "COMPANION: " + onOff(g.settings.Companion) },
+				action: func() {
+					g.settings.Companion = !g.settings.Companion
+					g.saveSettings()
+				},
+				visible: g.companionUnlocked,
+			},
+			{
+				label: func() string { return "CROWD & COMMENTARY: " + onOff(g.settings.Flavor) },
+				action: func() {
+					g.settings.Flavor = !g.settings.Flavor
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "COACH HINTS: " + onOff(g.settings.Hints) },
+				action: func() {
+					g.settings.Hints = !g.settings.Hints
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "CUTSCENES: " + onOff(g.settings.Cutscenes) },
+				action: func() {
+					g.settings.Cutscenes = !g.settings.Cutscenes
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "RHYTHM FLAPS: " + onOff(g.settings.Rhythm) },
+				action: func() {
+					g.settings.Rhythm = !g.settings.Rhythm
+					g.saveSettings()
+				},
+			},
+			{
+				label: func() string { return "ADAPTIVE DIFFICULTY: " + onOff(g.settings.AdaptiveDifficulty) },
+				action: func() {
+					g.settings.AdaptiveDifficulty = !g.settings.AdaptiveDifficulty
+					g.saveSettings()
+				},
+			},
+			{
+				// Told rather than hidden where nothing vibrates
+				label: func() string {
+					if !g.hapticsAvailable() {
+						return "VIBRATION: WEB ONLY"
+					}
+					return "VIBRATION: " + onOff(g.settings.Vibration)
+				},
+				action: func() {
+					if !g.hapticsAvailable() {
+						return
+					}
+					g.settings.Vibration = !g.settings.Vibration
+					g.saveSettings()
+					g.vibrate(damageVibration, damageVibrationPower)
+				},
+			},
+			{
+				label: func() string { return "PRIVACY" },
+				action: func() {
+					g.mode = ModePrivacy
+				},
+			},
+			{
+				label: func() string { return "CLOUD SYNC" },
+				action: func() {
+					g.mode = ModeSync
+				},
+				visible: g.syncAvailable,
+			},
+			{
+				label: func() string { return "TILT: " + onOff(g.settings.TiltEnabled) },
+				action: func() {
+					g.settings.TiltEnabled = !g.settings.TiltEnabled
+					g.saveSettings()
+				},
+				visible: deviceTilt.IsAvailable,
+			},
+			{
+				label: func() string { return "CALIBRATE TILT" },
+				action: func() {
+					g.settings.TiltOffset = deviceTilt.Pitch()
+					g.saveSettings()
+				},
+				visible: func() bool { return deviceTilt.IsAvailable() && g.settings.TiltEnabled },
+			},
+			{
+				label: func() string { return "BACK" },
+				action: func() {
+					g.mode = ModeTitle
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// a coin for every this many meters, and this many for every item
+	coinDistance  = 10
+	coinsPerItem  = 5
+	shopMenuY     = titleMenuY + regularFontSize*3
+	heartLossTime = 1.5
+	// per level of the upgrades
+	flapUpgradeRecovery = 0.1
+	diveUpgradeBoost    = 0.2
+)
+
+const (
+	upgradeHeart    = "heart"
+	upgradeFlap     = "flap"
+	upgradeDive     = "dive"
+	upgradeContinue = "continue"
+)
+
+// Upgrade is a permanent upgrade bought with the coins earned by the runs,
+// a level at a time.
+type Upgrade struct {
+	ID   string
+	Name string
+	// the price of every level
+	Costs []int
+}
+
+var upgrades = []Upgrade{
+	{ID: upgradeHeart, Name: "+1 HEART", Costs: []int{200, 500, 1000}},
+	{ID: upgradeFlap, Name: "SLOWER FLAP DECAY", Costs: []int{150, 400, 800}},
+	{ID: upgradeDive, Name: "STRONGER DIVE", Costs: []int{100, 300}},
+	{ID: upgradeContinue, Name: "EXTRA CONTINUE", Costs: []int{600}},
+}
+
+type UpgradeBoughtEvent struct {
+	Upgrade string `json:"upgrade"`
+	Level   int    `json:"level"`
+	Cost    int    `json:"cost"`
+}
+
+func (UpgradeBoughtEvent) Action() string { return "upgrade_bought" }
+
+func (g *Game) upgradeLevel(id string) int {
+	return g.records.Upgrades[id]
+}
+
+// runUpgradeLevel is the level of the upgrade the run is played with, none
+// in a challenge, which must play the same on every device.
+func (g *Game) runUpgradeLevel(id string) int {
+	if g.challenge != nil {
+		return 0
+	}
+	return g.upgradeLevel(id)
+}
+
+// runUpgraded reports whether the run is played with any upgrade.
+func (g *Game) runUpgraded() bool {
+	for _, u := range upgrades {
+		if g.runUpgradeLevel(u.ID) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// runCoins are the coins the run just over earns.
+func (g *Game) runCoins() int {
+	return int(g.birdman.x)/10/coinDistance + g.stats.items*coinsPerItem
+}
+
+// buyUpgrade buys the next level of the upgrade if the coins are enough, and
+// reports whether it did.
+func (g *Game) buyUpgrade(u *Upgrade) bool {
+	level := g.upgradeLevel(u.ID)
+	if level >= len(u.Costs) || g.records.Coins < u.Costs[level] {
+		return false
+	}
+	cost := u.Costs[level]
+	g.records.Coins -= cost
+	if g.records.Upgrades == nil {
+		g.records.Upgrades = map[string]int{}
+	}
+	g.records.Upgrades[u.ID] = level + 1
+	g.logEvent(UpgradeBoughtEvent{Upgrade: u.ID, Level: level + 1, Cost: cost})
+	if err := g.records.Save(g.storage); err != nil {
+		log.Printf("Failed to save records: %v", err)
+	}
+	return true
+}
+
+func (g *Game) upgradeLabel(u *Upgrade) string {
+	level := g.upgradeLevel(u.ID)
+	if level >= len(u.Costs) {
+		return fmt.Sprintf("%s  MAX", u.Name)
+	}
+	return fmt.Sprintf("%s  LV%d  %s", u.Name, level+1, formatIntComma(u.Costs[level]))
+}
+
+// newShopMenu sells the upgrades between the runs.
+func (g *Game) newShopMenu() *Menu {
+	m := &Menu{
+		title: "SHOP",
+		y:     shopMenuY,
+		small: true,
+		sfx:   g.sfx,
+	}
+	for i := range upgrades {
+		u := &upgrades[i]
+		m.items = append(m.items, MenuItem{
+			label: func() string { return g.upgradeLabel(u) },
+			action: func() {
+				if !g.buyUpgrade(u) {
+					g.sfx.PlaySE(warningAudioData)
+				}
+			},
+		})
+	}
+	m.items = append(m.items, MenuItem{
+		label:  func() string { return "BACK" },
+		action: func() { g.mode = ModeTitle },
+	})
+	return m
+}
+
+func (g *Game) drawShop(screen *ebiten.Image) {
+	g.shopMenu.Draw(screen)
+	s := fmt.Sprintf("COINS %s", formatIntComma(g.records.Coins))
+	text.Draw(screen, s, regularFont, screenWidth/2-len(s)*regularFontSize/2, shopMenuY-regularFontSize*3/2, color.White)
+}
+
+// dealUpgrades gives the run the hearts and the continues bought.
+func (g *Game) dealUpgrades() {
+	g.hearts = g.runUpgradeLevel(upgradeHeart)
+	g.continues = g.runUpgradeLevel(upgradeContinue)
+}
+
+// loseHeart takes a heart instead of the damage, if one is left, and keeps
+// the birdman safe for a while after. It reports whether the damage is
+// taken care of.
+func (g *Game) loseHeart() bool {
+	b := g.birdman
+	if b.heartLossTime > 0 {
+		return true
+	}
+	if g.hearts == 0 {
+		return false
+	}
+	g.hearts--
+	b.heartLossTime = heartLossTime
+	g.popups.Spawn("HEART LOST", b.x, b.y-birdmanHeight/2, popupPointsColor)
+	g.sfx.PlaySE(damageAudioData)
+	g.camera.Shake(damageShakeStrength/2, damageShakeDuration)
+	return true
+}
+
+// fallIntoSea ends the run, or puts the birdman back in the air if a
+// continue is left.
+func (g *Game) fallIntoSea() {
+	if g.continues == 0 {
+		g.gameOver()
+		return
+	}
+	g.continues--
+	b := g.birdman
+	b.state = StateFlying
+	b.y = screenHeight / 2
+	b.vx = g.config.BirdmanSpeed
+	b.vy = 0
+	b.damagedTicks = 0
+	b.damagedSkippedTicks = 0
+	b.knockbackVx = 0
+	b.heartLossTime = heartLossTime
+	g.popups.Spawn("CONTINUE", b.x, b.y-birdmanHeight/2, popupPointsColor)
+	g.sfx.PlaySE(menuSelectAudioData)
+}
+
+// flapUpgradeRecovery is the fraction of the lost flap power the upgrade
+// keeps.
+func (g *Game) flapUpgradeRecovery() float64 {
+	return math.Min(1, float64(g.runUpgradeLevel(upgradeFlap))*flapUpgradeRecovery)
+}
+
+func (g *Game) diveBoost() float64 {
+	return 1 + float64(g.runUpgradeLevel(upgradeDive))*diveUpgradeBoost
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	slipstreamBoostDuration = 1.0
+	slipstreamTrailLines    = 5
+)
+
+var slipstreamTrailColor = color.RGBA{0xc0, 0xe8, 0xff, 0xff}
+
+// inSlipstream reports whether the birdman is in the wake trailing behind
+// any bird, which extends from the bird's tail in the direction it came
+// from. Escorting birds fly the other way, ahead of him.
+func (g *Game) inSlipstream() bool {
+	birdman := g.birdman
+	for i := range g.birds {
+		b := &g.birds[i]
+		dx := birdman.x - b.x
+		if b.state == BirdEscorting {
+			dx = -dx
+		}
+		if dx > birdWidth/2 && dx < birdWidth/2+g.config.SlipstreamLength && math.Abs(birdman.y-b.y) < g.config.SlipstreamHeight {
+			return true
+		}
+	}
+	return false
+}
+
+// updateSlipstream boosts the birdman once he has drafted behind birds for
+// long enough.
+func (g *Game) updateSlipstream() {
+	birdman := g.birdman
+	if birdman.boostTime > 0 {
+		birdman.boostTime = math.Max(0, birdman.boostTime-simulationStep)
+	}
+
+	if !g.inSlipstream() {
+		birdman.draftTime = 0
+		return
+	}
+	birdman.draftTime += simulationStep
+	if birdman.draftTime >= g.config.SlipstreamTime {
+		birdman.draftTime = 0
+		birdman.vx += g.config.SlipstreamBoost
+		birdman.boostTime = slipstreamBoostDuration
+		g.sfx.PlaySE(whooshAudioData)
+	}
+}
+
+// drawSlipstreamTrail draws wind lines streaming off the birdman, faint
+// while drafting and strong while boosted.
+func (g *Game) drawSlipstreamTrail(screen *ebiten.Image) {
+	birdman := g.birdman
+	alpha := 0.0
+	if birdman.draftTime > 0 {
+		alpha = 0.3 * birdman.draftTime / g.config.SlipstreamTime
+	}
+	alpha = math.Max(alpha, birdman.boostTime/slipstreamBoostDuration)
+	if alpha <= 0 {
+		return
+	}
+
+	clr := slipstreamTrailColor
+	clr.A = uint8(0xff * alpha)
+	x := birdman.x - g.camera.ViewX() - birdmanWidth/3
+	y := birdman.y - g.camera.ViewY()
+	for i := 0; i < slipstreamTrailLines; i++ {
+		dy := (float64(i) - (slipstreamTrailLines-1)/2.0) * 8
+		length := 30 + 20*math.Abs(math.Sin(birdman.x/15+float64(i)))
+		ebitenutil.DrawLine(screen, x, y+dy, x-length, y+dy, clr)
+	}
+}
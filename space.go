@@ -0,0 +1,236 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	boosterRadius        = 14
+	boosterBobHeight     = 6
+	boosterBobPeriod     = 1.5
+	boosterSpawnMargin   = 60
+	boosterSpawnDepth    = 120
+	spacePoints          = 200
+	spaceStarPoints      = 30
+	spaceStarRadius      = 14
+	spaceStarSpawnMargin = 50
+	spaceEntryY          = screenHeight * 0.75
+	spaceFadeTime        = 0.8
+	spaceSkyStars        = 60
+)
+
+var (
+	spaceSkyColor     = color.RGBA{0x08, 0x08, 0x28, 0xff}
+	spaceSkyStarColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	boosterColor      = color.RGBA{0xff, 0x80, 0x20, 0xff}
+	boosterCoreColor  = color.RGBA{0xff, 0xf0, 0xa0, 0xff}
+	spaceStarColor    = color.RGBA{0xff, 0xf0, 0x80, 0xff}
+)
+
+var (
+	boosterImg, boosterCoreImg, spaceStarImg *ebiten.Image
+)
+
+// Booster is a pickup floating just under the high-altitude zone. With
+// enough of them the birdman can climb through the zone into space.
+type Booster struct {
+	x, y, baseY float64
+	time        float64
+}
+
+// SpaceStar is a bonus collectible of the space segment.
+type SpaceStar struct {
+	x, y float64
+}
+
+// newSparkleImage draws a four-pointed star, the astroid |x|^½ + |y|^½ < r^½.
+func newSparkleImage(r int, clr color.Color) *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 2*r, 2*r))
+	for y := 0; y < 2*r; y++ {
+		for x := 0; x < 2*r; x++ {
+			dx, dy := math.Abs(float64(x-r)+0.5), math.Abs(float64(y-r)+0.5)
+			if math.Sqrt(dx)+math.Sqrt(dy) < math.Sqrt(float64(r)) {
+				img.Set(x, y, clr)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+func (g *Game) inSpace() bool {
+	return g.spaceTime > 0
+}
+
+// spaceCharged reports whether the birdman has collected enough boosters to
+// break through the ceiling.
+func (g *Game) spaceCharged() bool {
+	return !g.inSpace() && g.boosterCount >= g.config.SpaceBoosters
+}
+
+func (g *Game) spawnBooster() {
+	y := g.config.AltitudeZoneHeight + boosterRadius + g.rand.Float64()*boosterSpawnDepth
+	g.boosters = append(g.boosters, Booster{x: g.birdman.x + screenWidth + boosterSpawnMargin, y: y, baseY: y})
+}
+
+// updateSpace spawns the boosters and takes the birdman to space once he
+// reaches the ceiling with enough of them. The space segment lasts until the
+// time is up or he sinks out of the bottom of it.
+func (g *Game) updateSpace() {
+	birdman := g.birdman
+	if !g.inSpace() {
+		if birdman.x >= g.config.BoosterStartX && birdman.x >= g.nextBoosterX {
+			g.nextBoosterX = birdman.x + g.config.BoosterInterval
+			g.spawnBooster()
+		}
+
+		n := 0
+		for i := range g.boosters {
+			b := &g.boosters[i]
+			b.time += simulationStep
+			b.y = b.baseY + boosterBobHeight*math.Sin(2*math.Pi*b.time/boosterBobPeriod)
+			if Collides(birdman.hitbox(), birdman.x, birdman.y, pickupHitbox, b.x, b.y) {
+				if g.boosterCount < g.config.SpaceBoosters {
+					g.boosterCount++
+				}
+				g.stats.items++
+				g.popups.Spawn("BOOST!", b.x, b.y-boosterRadius, boosterCoreColor)
+				g.sfx.PlaySE(ringAudioData)
+				continue
+			}
+			if b.x+boosterRadius > g.camera.ViewX() {
+				g.boosters[n] = *b
+				n++
+			}
+		}
+		g.boosters = g.boosters[:n]
+
+		if g.spaceCharged() && birdman.y < 0 {
+			g.enterSpace()
+		}
+		return
+	}
+
+	g.spaceTime -= simulationStep
+	g.spaceElapsed += simulationStep
+	if birdman.x >= g.nextSpaceStarX {
+		g.nextSpaceStarX = birdman.x + g.config.SpaceStarInterval
+		y := spaceStarSpawnMargin + g.rand.Float64()*(screenHeight-2*spaceStarSpawnMargin)
+		g.spaceStars = append(g.spaceStars, SpaceStar{x: birdman.x + screenWidth, y: y})
+	}
+
+	n := 0
+	for i := range g.spaceStars {
+		s := &g.spaceStars[i]
+		if Collides(birdman.hitbox(), birdman.x, birdman.y, pickupHitbox, s.x, s.y) {
+			g.stats.items++
+			g.award("STAR!", spaceStarPoints)
+			g.sfx.PlaySE(ringAudioData)
+			continue
+		}
+		if s.x+spaceStarRadius > g.camera.ViewX() {
+			g.spaceStars[n] = *s
+			n++
+		}
+	}
+	g.spaceStars = g.spaceStars[:n]
+
+	if g.spaceTime <= 0 || birdman.y > screenHeight {
+		g.exitSpace()
+	}
+}
+
+// enterSpace clears everything left below and lets the birdman rise into
+// space from the bottom of the screen.
+func (g *Game) enterSpace() {
+	g.spaceTime = g.config.SpaceDuration
+	g.spaceElapsed = 0
+	g.stats.reachedSpace = true
+	g.boosterCount = 0
+	g.boosters = g.boosters[:0]
+	g.nextSpaceStarX = g.birdman.x
+
+	for i := range g.birds {
+		g.birds[i] = Bird{}
+	}
+	g.birds = g.birds[:0]
+	g.flocks = g.flocks[:0]
+	g.airplanes = g.airplanes[:0]
+	g.fish = g.fish[:0]
+	g.balloons = g.balloons[:0]
+	g.rings = g.rings[:0]
+	g.ringChain = 0
+
+	g.birdman.y = spaceEntryY
+	g.birdman.pressureTime = 0
+	g.award("SPACE!", spacePoints)
+	g.sfx.PlaySE(whooshAudioData)
+}
+
+// exitSpace drops the birdman back into the sky just below the
+// high-altitude zone, with the birds starting over from there.
+func (g *Game) exitSpace() {
+	g.spaceTime = 0
+	g.spaceStars = g.spaceStars[:0]
+	g.birdman.y = g.config.AltitudeZoneHeight + boosterRadius
+	g.birdman.vy = 0
+	g.nextBirdX = g.birdman.x + g.birdSpawnInterval()
+	g.sfx.PlaySE(whooshAudioData)
+}
+
+// drawSpace darkens the sky into space, fading in and out at both ends of
+// the segment, with a star field scrolling slowly behind.
+func (g *Game) drawSpace(screen *ebiten.Image) {
+	if !g.inSpace() {
+		return
+	}
+	alpha := math.Min(1, math.Min(g.spaceElapsed, g.spaceTime)/spaceFadeTime)
+
+	clr := spaceSkyColor
+	clr.A = uint8(float64(clr.A) * alpha)
+	ebitenutil.DrawRect(screen, 0, -cameraMaxRise, screenWidth, screenHeight+2*cameraMaxRise, clr)
+
+	// The star field is cosmetic, so it is laid out by the golden ratio
+	// rather than the game's random source
+	const phi = 0.6180339887
+	for i := 0; i < spaceSkyStars; i++ {
+		fx := math.Mod(float64(i)*phi, 1) * screenWidth
+		fy := math.Mod(float64(i)*phi*phi*7, 1) * screenHeight
+		x := math.Mod(fx-g.camera.ViewX()*0.1, screenWidth)
+		if x < 0 {
+			x += screenWidth
+		}
+		twinkle := 0.6 + 0.4*math.Sin(g.spaceElapsed*3+float64(i))
+		c := spaceSkyStarColor
+		c.A = uint8(0xff * alpha * twinkle)
+		size := float64(1 + i%2)
+		ebitenutil.DrawRect(screen, x, fy, size, size, c)
+	}
+}
+
+// drawSpacePickups draws the boosters and the stars of the space segment.
+func (g *Game) drawSpacePickups(screen *ebiten.Image) {
+	for i := range g.boosters {
+		b := &g.boosters[i]
+		x, y := b.x-g.camera.ViewX(), b.y-g.camera.ViewY()
+		opt := scratchDrawOptions()
+		opt.GeoM.Translate(x-boosterRadius, y-boosterRadius)
+		screen.DrawImage(boosterImg, opt)
+		opt = scratchDrawOptions()
+		opt.GeoM.Translate(x-boosterRadius/2, y-boosterRadius/2)
+		screen.DrawImage(boosterCoreImg, opt)
+	}
+
+	for i := range g.spaceStars {
+		s := &g.spaceStars[i]
+		opt := scratchDrawOptions()
+		opt.GeoM.Translate(-spaceStarRadius, -spaceStarRadius)
+		opt.GeoM.Rotate(g.spaceElapsed * 2)
+		opt.GeoM.Translate(s.x-g.camera.ViewX(), s.y-g.camera.ViewY())
+		screen.DrawImage(spaceStarImg, opt)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const speedrunTimerY = 24 + smallFontSize*4 + regularFontSize/2
+
+// speedrunTargets are the distances in meters speedruns race to, each a
+// multiple of the checkpoint distance so that every checkpoint is a split.
+var speedrunTargets = []int{1000, 5000}
+
+// Autosplitter follows the speedruns from outside the game, e.g. a timer
+// like LiveSplit.
+type Autosplitter interface {
+	Start()
+	// Split is called at every checkpoint and at the finish, with the time
+	// of the run
+	Split(gameTime float64)
+	Reset()
+}
+
+// formatRunTime formats seconds as minutes, seconds and milliseconds.
+func formatRunTime(t float64) string {
+	ms := int(math.Round(t * 1000))
+	return fmt.Sprintf("%d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
+
+func (g *Game) newSpeedrunMenu() *Menu {
+	m := &Menu{
+		title: "SPEEDRUN",
+		y:     titleMenuY,
+		sfx:   g.sfx,
+	}
+	for _, target := range speedrunTargets {
+		target := target
+		m.items = append(m.items, MenuItem{
+			label: func() string {
+				best := "--"
+				if t, ok := g.records.BestTimes[target]; ok {
+					best = formatRunTime(t)
+				}
+				return fmt.Sprintf("%sm  BEST %s", formatIntComma(target), best)
+			},
+			action: func() {
+				g.practice = false
+				g.racing = false
+				g.speedrunTarget = target
+				g.startGame()
+			},
+		})
+	}
+	m.items = append(m.items, MenuItem{
+		label:  func() string { return "BACK" },
+		action: func() { g.mode = ModeTitle },
+	})
+	return m
+}
+
+func (g *Game) autosplit(f func(a Autosplitter)) {
+	if g.autosplitter != nil && g.speedrunTarget > 0 {
+		f(g.autosplitter)
+	}
+}
+
+// crossingTime returns when in the last step the birdman passed x, for times
+// finer than the steps.
+func (g *Game) crossingTime(x float64) float64 {
+	t := g.splits.time
+	if vx := g.birdman.vx; vx > 0 {
+		t -= (g.birdman.x - x) / vx
+	}
+	return math.Max(g.splits.time-simulationStep, math.Min(g.splits.time, t))
+}
+
+// updateSpeedrun splits at every checkpoint of a speedrun and finishes the
+// run at its target.
+func (g *Game) updateSpeedrun() {
+	if g.speedrunTarget == 0 || g.mode != ModeGame {
+		return
+	}
+	next := (g.speedrunSplits + 1) * checkpointDistance
+	if int(g.birdman.x)/10 < next {
+		return
+	}
+	t := g.crossingTime(float64(next * 10))
+	g.speedrunSplits++
+	g.autosplit(func(a Autosplitter) { a.Split(t) })
+	if next >= g.speedrunTarget {
+		g.stats.finished = true
+		g.stats.finishTime = t
+		g.gameOver()
+	}
+}
+
+// updateBestTime keeps the time of a finished speedrun if it beats the best
+// one to its target.
+func (g *Game) updateBestTime() {
+	if !g.stats.finished {
+		return
+	}
+	if best, ok := g.records.BestTimes[g.speedrunTarget]; ok && best <= g.stats.finishTime {
+		return
+	}
+	if g.records.BestTimes == nil {
+		g.records.BestTimes = map[int]float64{}
+	}
+	g.records.BestTimes[g.speedrunTarget] = g.stats.finishTime
+}
+
+// drawSpeedrunTimer draws the timer of a speedrun in the top right corner.
+func (g *Game) drawSpeedrunTimer(screen *ebiten.Image) {
+	if g.speedrunTarget == 0 {
+		return
+	}
+	s := formatRunTime(g.splits.time)
+	text.Draw(screen, s, regularFont, screenWidth-24-len(s)*regularFontSize, speedrunTimerY, color.White)
+	target := fmt.Sprintf("TO %sm", formatIntComma(g.speedrunTarget))
+	text.Draw(screen, target, smallFont, screenWidth-24-len(target)*smallFontSize, speedrunTimerY+smallFontSize*2, color.White)
+}
+
+// speedrunResult returns the lines of the game over screen telling how the
+// speedrun went, in place of the record.
+func (g *Game) speedrunResult() []string {
+	if !g.stats.finished {
+		return []string{"DID NOT FINISH", fmt.Sprintf("%sm TO GO", formatIntComma(g.speedrunTarget-int(g.birdman.x)/10))}
+	}
+	lines := []string{"YOUR TIME IS", formatRunTime(g.stats.finishTime)}
+	switch best := g.stats.previousBestTime; {
+	case !g.stats.recorded():
+	case best == 0 || g.stats.finishTime < best:
+		lines = append(lines, "NEW BEST TIME!")
+	default:
+		lines = append(lines, fmt.Sprintf("BEST %s", formatRunTime(best)))
+	}
+	return lines
+}
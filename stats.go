@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeathCause is what ended a run: the damage the birdman was still reeling
+// from when he fell into the sea, if any.
+type DeathCause int
+
+const (
+	CauseDrowned DeathCause = iota
+	CauseBird
+	CauseAirplane
+	CauseFish
+	CauseBasket
+	CauseCeiling
+	// reached the target of a speedrun
+	CauseFinished
+	// reached the island at the end
+	CauseLanded
+)
+
+func (c DeathCause) String() string {
+	switch c {
+	case CauseBird:
+		return "HIT A BIRD"
+	case CauseAirplane:
+		return "HIT AN AIRPLANE"
+	case CauseFish:
+		return "CAUGHT BY A FISH"
+	case CauseBasket:
+		return "HIT A BASKET"
+	case CauseCeiling:
+		return "FLEW TOO HIGH"
+	case CauseFinished:
+		return "FINISHED!"
+	case CauseLanded:
+		return "LANDED!"
+	default:
+		return "DROWNED"
+	}
+}
+
+// RunStats collects what happened during a run for the game over screen.
+type RunStats struct {
+	lastDamage DeathCause
+	cause      DeathCause
+	damage     int
+	flaps      int
+	nearMisses int
+	items      int
+	birdsFed   int
+	damages    []DamagePosition
+	// in meters, before this run
+	previousBest int
+	// cheats were on, or the run was a practice, so it isn't recorded
+	cheated  bool
+	practice bool
+	// the ids of the modifiers on, which keep it from being recorded too,
+	// as does a handicap of the party mode giving hearts
+	modifiers   []string
+	handicapped bool
+	// how the run compares with the challenge played
+	challengeResult string
+	// of the adaptive difficulty
+	gapScale float64
+	// the run was played with upgrades of the shop, or with the stronger
+	// flaps on the beat
+	upgraded bool
+	rhythm   bool
+	// earned by the run
+	coins int
+	// the names of what the run unlocked
+	unlocked     []string
+	reachedSpace bool
+	// the run reached the target of the speedrun, in this many seconds
+	finished   bool
+	finishTime float64
+	// in seconds to the same target before this run, 0 for none
+	previousBestTime float64
+	// in the ranking of the race's distance, 0 if out of it
+	raceRank int
+	// the run reached the island at the ending distance
+	landed bool
+}
+
+func (s *RunStats) Reset() {
+	*s = RunStats{}
+}
+
+func (s *RunStats) recorded() bool {
+	return !s.cheated && !s.practice && len(s.modifiers) == 0 && !s.handicapped
+}
+
+// breakdown returns the lines of the game over screen under the record of
+// the run.
+func (s *RunStats) breakdown(record int) []string {
+	best := fmt.Sprintf("BEST %sm (%sm TO GO)", formatIntComma(s.previousBest), formatIntComma(s.previousBest-record))
+	if s.cheated {
+		best = "CHEATS ON: NOT RECORDED"
+	} else if s.practice {
+		best = "PRACTICE: NOT RECORDED"
+	} else if len(s.modifiers) > 0 {
+		best = "MODIFIERS ON: NOT RECORDED"
+	} else if record > s.previousBest {
+		best = "NEW BEST!"
+		if s.previousBest > 0 {
+			best = fmt.Sprintf("NEW BEST! (WAS %sm)", formatIntComma(s.previousBest))
+		}
+	}
+	lines := []string{
+		best,
+		fmt.Sprintf("DAMAGE %d  FLAPS %d", s.damage, s.flaps),
+		fmt.Sprintf("NEAR MISSES %d  ITEMS %d", s.nearMisses, s.items),
+	}
+	var earned []string
+	if s.coins > 0 {
+		earned = append(earned, fmt.Sprintf("COINS +%s", formatIntComma(s.coins)))
+	}
+	switch len(s.unlocked) {
+	case 0:
+	case 1:
+		earned = append(earned, "UNLOCKED "+s.unlocked[0])
+	default:
+		earned = append(earned, fmt.Sprintf("UNLOCKED %d NEW", len(s.unlocked)))
+	}
+	if len(earned) > 0 {
+		lines = append(lines, strings.Join(earned, "  "))
+	}
+	return lines
+}
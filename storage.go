@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+)
+
+// Storage keeps the small save files of the game, such as the settings and
+// the records, by name. Each build target has its own backend, see
+// newPlatformStorage.
+type Storage interface {
+	// Load returns an error wrapping fs.ErrNotExist if nothing is saved
+	// under the name.
+	Load(name string) ([]byte, error)
+	Save(name string, data []byte) error
+}
+
+// saveVersion is the schema version of the save files. Whenever the format
+// of one changes, bump it and append a migration from the previous version
+// to saveMigrations.
+const saveVersion = 1
+
+// saveMigration upgrades a decoded save file by one version in place.
+type saveMigration func(m map[string]interface{})
+
+// saveMigrations holds, for every save file, the migrations from version i
+// to i+1 at index i. Version 0 is a save file from before versioning.
+var saveMigrations = map[string][]saveMigration{
+	settingsFileName: {
+		// Unversioned saves only gain the version field
+		func(m map[string]interface{}) {},
+	},
+	recordsFileName: {
+		func(m map[string]interface{}) {},
+	},
+}
+
+// loadJSON reads the named file of the storage into v, migrating it from
+// the version it was saved in. Nothing saved yet is not an error and leaves
+// v as it is, and neither is a nil storage, which is what tests and headless
+// runs use. A file which can't be read back is copied to a backup before
+// the error is returned, so that saving over it doesn't lose it for good.
+func loadJSON(s Storage, name string, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	data, err := s.Load(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := decodeSave(name, data, v); err != nil {
+		backup := name + ".bak"
+		if err := s.Save(backup, data); err != nil {
+			log.Printf("Failed to back up %s: %v", name, err)
+		} else {
+			log.Printf("Backed up %s to %s", name, backup)
+		}
+		return err
+	}
+	return nil
+}
+
+func decodeSave(name string, data []byte, v interface{}) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	version := 0
+	if f, ok := m["version"].(float64); ok {
+		version = int(f)
+	}
+	if version > saveVersion {
+		return fmt.Errorf("%s is saved in version %d, newer than %d", name, version, saveVersion)
+	}
+	migrations := saveMigrations[name]
+	for ; version < saveVersion; version++ {
+		if version < len(migrations) {
+			migrations[version](m)
+		}
+	}
+	delete(m, "version")
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveJSON writes v to the named file of the storage, tagged with the
+// current version.
+func saveJSON(s Storage, name string, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	m["version"] = saveVersion
+	data, err = json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.Save(name, data)
+}
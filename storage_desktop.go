@@ -0,0 +1,42 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// fileStorage keeps every save file as a file in a directory.
+type fileStorage struct {
+	dir string
+}
+
+// newPlatformStorage stores the save files in the user's config directory.
+func newPlatformStorage() (Storage, error) {
+	if runtime.GOOS == "android" {
+		// Android apps have no home. gomobile sets TMPDIR to the app's cache
+		// directory, which the system may clear, so use its files directory
+		// next to it.
+		return &fileStorage{dir: filepath.Join(filepath.Dir(os.TempDir()), "files")}, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &fileStorage{dir: filepath.Join(dir, gameName)}, nil
+}
+
+func (s *fileStorage) Load(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(s.dir, name))
+}
+
+func (s *fileStorage) Save(name string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.dir, name), data, 0644)
+}
@@ -0,0 +1,57 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall/js"
+)
+
+// localStorage keeps every save file as an item of the browser's
+// localStorage, under a key prefixed with the game name.
+type localStorage struct {
+	storage js.Value
+}
+
+// newPlatformStorage stores the save files in the localStorage of the page.
+// It is missing e.g. outside browsers or with storage disabled by privacy
+// settings.
+func newPlatformStorage() (s Storage, err error) {
+	// Merely touching localStorage throws when it is disabled
+	defer func() {
+		if r := recover(); r != nil {
+			s, err = nil, fmt.Errorf("localStorage is not accessible: %v", r)
+		}
+	}()
+	v := js.Global().Get("localStorage")
+	if v.IsUndefined() || v.IsNull() {
+		return nil, errors.New("localStorage is not available")
+	}
+	return &localStorage{storage: v}, nil
+}
+
+func (s *localStorage) key(name string) string {
+	return gameName + "/" + name
+}
+
+func (s *localStorage) Load(name string) ([]byte, error) {
+	v := s.storage.Call("getItem", s.key(name))
+	if v.IsNull() {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return []byte(v.String()), nil
+}
+
+// Save fails rather than panics when the quota is exceeded.
+func (s *localStorage) Save(name string, data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to save %s: %v", name, r)
+		}
+	}()
+	s.storage.Call("setItem", s.key(name), string(data))
+	return nil
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/tsujio/game-logging-server/client"
+)
+
+type memoryStorage map[string][]byte
+
+func (s memoryStorage) Load(name string) ([]byte, error) {
+	data, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return data, nil
+}
+
+func (s memoryStorage) Save(name string, data []byte) error {
+	s[name] = data
+	return nil
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	storage := memoryStorage{}
+
+	// Nothing saved yet
+	if s := LoadSettings(storage); s.Window != DefaultWindowSettings() {
+		t.Errorf("window settings %+v, want the defaults", s.Window)
+	}
+
+	s := NewSettings()
+	s.Minimap = true
+	if err := s.Save(storage); err != nil {
+		t.Fatal(err)
+	}
+	r := &Records{BestDistance: 123, Runs: 4}
+	if err := r.Save(storage); err != nil {
+		t.Fatal(err)
+	}
+	if s := LoadSettings(storage); !s.Minimap {
+		t.Error("minimap setting not restored")
+	}
+	if r := LoadRecords(storage); r.BestDistance != 123 || r.Runs != 4 {
+		t.Errorf("records %+v not restored", r)
+	}
+
+	// Broken saves fall back to the defaults
+	storage[recordsFileName] = []byte("{")
+	if r := LoadRecords(storage); r.BestDistance != 0 {
+		t.Errorf("records %+v from a broken save", r)
+	}
+}
+
+func TestSaveMigration(t *testing.T) {
+	// Saved before versioning
+	storage := memoryStorage{recordsFileName: []byte(`{"best_distance": 321}`)}
+	if r := LoadRecords(storage); r.BestDistance != 321 {
+		t.Errorf("records %+v not migrated", r)
+	}
+
+	r := &Records{BestDistance: 321}
+	if err := r.Save(storage); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf(`"version": %d`, saveVersion); !strings.Contains(string(storage[recordsFileName]), want) {
+		t.Errorf("saved %s without %s", storage[recordsFileName], want)
+	}
+
+	// Saves which can't be read back are kept as backups
+	for _, data := range []string{`{"best_distance": "far"}`, fmt.Sprintf(`{"version": %d}`, saveVersion+1)} {
+		storage := memoryStorage{recordsFileName: []byte(data)}
+		if r := LoadRecords(storage); r.BestDistance != 0 {
+			t.Errorf("records %+v from %s", r, data)
+		}
+		if string(storage[recordsFileName+".bak"]) != data {
+			t.Errorf("backup %q, want %q", storage[recordsFileName+".bak"], data)
+		}
+	}
+}
+
+type testSyncer struct {
+	scores map[string]int
+	// the score lists of the leaderboard by name
+	lists map[string][]logging.GameScore
+}
+
+func (s *testSyncer) FetchScores(list string) ([]logging.GameScore, error) {
+	return s.lists[list], nil
+}
+
+func (s *testSyncer) UploadRaceTime(code string, distance int, t float64) error {
+	return nil
+}
+
+func (s *testSyncer) UploadBest(code string, distance int) error {
+	s.scores[code] = distance
+	return nil
+}
+
+func (s *testSyncer) FetchBest(code string) (int, error) {
+	best, ok := s.scores[code]
+	if !ok {
+		return 0, errSyncNotFound
+	}
+	return best, nil
+}
+
+// waitSync polls for the result of the request in flight like the game loop.
+func waitSync(t *testing.T, g *testGame) {
+	t.Helper()
+	for i := 0; g.sync.busy; i++ {
+		if i > 1000 {
+			t.Fatal("sync request never finished")
+		}
+		time.Sleep(time.Millisecond)
+		g.updateSync()
+	}
+}
+
+func TestCloudSync(t *testing.T) {
+	syncer := &testSyncer{scores: map[string]int{}}
+
+	// Nothing is uploaded until the player turns the sync on
+	g := newTestGame(t)
+	g.syncer = syncer
+	g.storage = memoryStorage{}
+	g.fly(5000)
+	g.gameOver()
+	waitSync(t, g)
+	if len(syncer.scores) != 0 {
+		t.Fatalf("uploaded %v with the sync off", syncer.scores)
+	}
+
+	g.settings.Privacy.Logging = true
+	g.settings.CloudSync = true
+	g.initialize()
+	g.startGame()
+	g.fly(8000)
+	g.gameOver()
+	waitSync(t, g)
+	code := g.records.SyncCode
+	if _, ok := normalizeSyncCode(code); !ok {
+		t.Fatalf("sync code %q is not a valid code", code)
+	}
+	if syncer.scores[code] != 800 {
+		t.Fatalf("uploaded %v, want 800 under %s", syncer.scores, code)
+	}
+
+	// Another device restores the best from the code typed in
+	other := newTestGame(t)
+	other.settings.Privacy.Logging = true
+	other.syncer = syncer
+	other.storage = memoryStorage{}
+	typed, ok := normalizeSyncCode(strings.ToLower(formatSyncCode(code)))
+	if !ok {
+		t.Fatalf("typed code %q not accepted", formatSyncCode(code))
+	}
+	other.restoreBest(typed)
+	waitSync(t, other)
+	if other.records.BestDistance != 800 || other.records.SyncCode != code {
+		t.Errorf("records %+v after restoring, want the best of %s", other.records, code)
+	}
+	if r := LoadRecords(other.storage); r.BestDistance != 800 {
+		t.Errorf("restored records not saved: %+v", r)
+	}
+
+	other.restoreBest("ZZZZZZZZ")
+	waitSync(t, other)
+	if other.sync.status != "NO RECORD FOR THE CODE" || other.records.BestDistance != 800 {
+		t.Errorf("unknown code gave %q and %+v", other.sync.status, other.records)
+	}
+
+	if _, ok := normalizeSyncCode("ABCD-EFG0"); ok {
+		t.Error("code with a 0 accepted")
+	}
+
+	// Only a hash of the code is published
+	id := syncPlayerID(code)
+	if strings.Contains(id, code) || id != syncPlayerID(code) || id == syncPlayerID("ZZZZZZZZ") {
+		t.Errorf("player id %q of %s is not a hash of the code", id, code)
+	}
+}
+
+// waitLeaderboard polls for the list in flight like the game loop.
+func waitLeaderboard(t *testing.T, g *testGame) {
+	t.Helper()
+	for i := 0; g.leaderboard.busy; i++ {
+		if i > 1000 {
+			t.Fatal("leaderboard request never finished")
+		}
+		time.Sleep(time.Millisecond)
+		g.receiveLeaderboard()
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	g := newTestGame(t)
+	g.leaderboardMenu = g.newLeaderboardMenu()
+	g.records.SyncCode = "ABCDEFGH"
+	g.syncer = &testSyncer{lists: map[string][]logging.GameScore{
+		syncScoreList: {
+			{PlayerID: "sync-a", Score: 900},
+			{PlayerID: syncPlayerID("ABCDEFGH"), Score: 1200},
+			{PlayerID: "sync-a", Score: 1500},
+		},
+		raceScoreList(1000): {
+			{PlayerID: "sync-b", Score: -75250},
+		},
+	}}
+
+	g.openLeaderboard()
+	waitLeaderboard(t, g)
+	if want := []string{"THE LEADERBOARD IS OFF", "WITHOUT THE EVENT LOGGING"}; !reflect.DeepEqual(g.leaderboard.lines, want) {
+		t.Errorf("lines %q without the logging", g.leaderboard.lines)
+	}
+
+	// The best of every player, with the player's own marked
+	g.settings.Privacy.Logging = true
+	g.openLeaderboard()
+	waitLeaderboard(t, g)
+	want := []string{"1.     1,500m    ", "2.     1,200m YOU"}
+	if !reflect.DeepEqual(g.leaderboard.lines, want) {
+		t.Errorf("best distances %q, want %q", g.leaderboard.lines, want)
+	}
+
+	// Switching the list while one is in flight fetches the latest
+	g.adjustLeaderboard(1)
+	g.adjustLeaderboard(1)
+	waitLeaderboard(t, g)
+	want = []string{"1.   1:15.250    "}
+	if g.leaderboard.race != 1000 || !reflect.DeepEqual(g.leaderboard.lines, want) {
+		t.Errorf("race times %q to %dm, want %q", g.leaderboard.lines, g.leaderboard.race, want)
+	}
+	g.adjustLeaderboard(1)
+	waitLeaderboard(t, g)
+	if want := []string{"NO ENTRIES YET"}; !reflect.DeepEqual(g.leaderboard.lines, want) {
+		t.Errorf("empty race %q", g.leaderboard.lines)
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	storage := memoryStorage{}
+	online := false
+	var sent []string
+	// the connection drops after this many more events
+	dropAfter := -1
+	send := func(payload map[string]interface{}) error {
+		if !online || dropAfter == 0 {
+			return errors.New("offline")
+		}
+		dropAfter--
+		sent = append(sent, payload["action"].(string))
+		return nil
+	}
+
+	q := NewEventQueue(storage, send)
+	for i := 0; i < eventBatchSize+5; i++ {
+		q.Push(map[string]interface{}{"action": fmt.Sprint(i)})
+	}
+	if _, ok := storage[eventQueueFileName]; ok {
+		t.Error("events saved on every push")
+	}
+	if err := q.flush(); err == nil {
+		t.Fatal("flush succeeded while offline")
+	}
+	// as the game goes to the background
+	q.Save()
+
+	// The game is closed and opened again, then the connection comes back
+	q = NewEventQueue(storage, send)
+	if q.Len() != eventBatchSize+5 {
+		t.Fatalf("%d events restored, want %d", q.Len(), eventBatchSize+5)
+	}
+	online = true
+	if more, err := q.sendBatch(); err != nil || !more || len(sent) != eventBatchSize {
+		t.Fatalf("first batch sent %d events (more %v, err %v), want %d", len(sent), more, err, eventBatchSize)
+	}
+	// The retry of a batch cut off goes on after the last event the server
+	// took, sending none twice
+	dropAfter = 2
+	if err := q.flush(); err == nil || q.Len() != 3 {
+		t.Fatalf("flush cut off left %d events (err %v), want 3", q.Len(), err)
+	}
+	dropAfter = -1
+	if err := q.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != eventBatchSize+5 {
+		t.Errorf("%d events sent, want %d", len(sent), eventBatchSize+5)
+	}
+	q.Save()
+	for i, a := range sent {
+		if a != fmt.Sprint(i) {
+			t.Fatalf("events sent in the order %v", sent)
+		}
+	}
+	if q := NewEventQueue(storage, send); q.Len() != 0 {
+		t.Errorf("%d sent events still saved", q.Len())
+	}
+
+	d := time.Duration(0)
+	for i := 0; i < 20; i++ {
+		d = nextEventBackoff(d)
+	}
+	if d != eventMaxBackoff {
+		t.Errorf("backoff grew to %v, want at most %v", d, eventMaxBackoff)
+	}
+}
+
+func TestPrivacyConsent(t *testing.T) {
+	g := newTestGame(t)
+	g.storage = memoryStorage{}
+	g.syncer = &testSyncer{scores: map[string]int{}}
+	g.mode = ModeConsent
+
+	g.answerConsent(false)
+	s := LoadSettings(g.storage)
+	if g.mode != ModeTitle || !s.Privacy.Asked || s.Privacy.Logging {
+		t.Errorf("mode %v, privacy %+v after opting out", g.mode, s.Privacy)
+	}
+	if g.syncAvailable() {
+		t.Error("cloud sync offered without logging")
+	}
+
+	for _, c := range []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"", true},
+		{"https://logs.example.com", true},
+		{"http://localhost:8080/", true},
+		{"logs.example.com", false},
+		{"ftp://logs.example.com", false},
+	} {
+		if err := validateURL(c.endpoint); (err == nil) != c.valid {
+			t.Errorf("validateURL(%q) = %v", c.endpoint, err)
+		}
+	}
+}
+
+func TestNewBestWebhook(t *testing.T) {
+	var posted []string
+	g := newTestGame(t)
+	g.webhook = func(url, message string) {
+		posted = append(posted, url+" "+message)
+	}
+
+	// No webhook set up
+	g.fly(2000)
+	g.gameOver()
+	if len(posted) != 0 {
+		t.Fatalf("posted %v without a webhook", posted)
+	}
+
+	g.settings.WebhookURL = "https://discord.example.com/api/webhooks/1/x"
+	g.initialize()
+	g.startGame()
+	g.fly(12345)
+	g.gameOver()
+	if len(posted) != 1 || !strings.HasPrefix(posted[0], g.settings.WebhookURL+" ") ||
+		!strings.Contains(posted[0], "1,234m") || !strings.Contains(posted[0], "challenge "+formatChallengeCode(g.lastChallenge.Encode())) {
+		t.Fatalf("posted %v, want the new best", posted)
+	}
+
+	// Not a new best
+	g.initialize()
+	g.startGame()
+	g.fly(5000)
+	g.gameOver()
+	if len(posted) != 1 {
+		t.Errorf("posted %v for a run short of the best", posted)
+	}
+}
+
+func TestExportRecords(t *testing.T) {
+	g := newTestGame(t)
+	for _, x := range []float64{3000, 12000, 500} {
+		g.initialize()
+		g.startGame()
+		g.fly(x)
+		g.damageBirdman(CauseBird)
+		g.gameOver()
+	}
+	if len(g.records.History) != 3 {
+		t.Fatalf("history %+v, want the 3 runs", g.records.History)
+	}
+
+	var csvOut strings.Builder
+	if err := exportRecords(&csvOut, g.records, exportFormat("runs.csv")); err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(rows) != 4 || rows[0] != strings.Join(runRecordCSVHeader, ",") {
+		t.Fatalf("CSV:\n%s", csvOut.String())
+	}
+	if f := strings.Split(rows[2], ","); f[1] != "1200" || f[4] != CauseBird.String() {
+		t.Errorf("second run exported as %q", rows[2])
+	}
+
+	var jsonOut strings.Builder
+	if err := exportRecords(&jsonOut, g.records, exportFormat("runs.JSON")); err != nil {
+		t.Fatal(err)
+	}
+	var exported struct {
+		BestDistance int         `json:"best_distance"`
+		Runs         int         `json:"runs"`
+		History      []RunRecord `json:"history"`
+	}
+	if err := json.Unmarshal([]byte(jsonOut.String()), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.BestDistance != 1200 || exported.Runs != 3 || len(exported.History) != 3 || exported.History[2].Distance != 50 {
+		t.Errorf("JSON export %+v", exported)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strings"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	streamRecentRuns = 5
+	// pixels per second
+	streamTickerSpeed  = 60
+	streamTickerHeight = smallFontSize * 2
+	streamScoreY       = screenHeight - streamTickerHeight - 16
+)
+
+var streamTickerColor = color.RGBA{0x00, 0x00, 0x00, 0xa0}
+
+// StreamStats are the stats of the current run served to stream overlays
+// (e.g. a browser source of OBS) as JSON.
+type StreamStats struct {
+	Mode string `json:"mode"`
+	// in meters
+	Distance    int   `json:"distance"`
+	Best        int   `json:"best"`
+	SpeedKmh    int   `json:"speed_kmh"`
+	StylePoints int   `json:"style_points"`
+	Damage      int   `json:"damage"`
+	Runs        int   `json:"runs"`
+	Recent      []int `json:"recent"`
+	// the player whose turn it is in the party mode
+	Player string `json:"player,omitempty"`
+}
+
+// StreamOverlay draws a layout meant for streaming the game, with a big
+// distance and a ticker of the best and the latest runs, and keeps the stats
+// for the stats server.
+type StreamOverlay struct {
+	mu    sync.Mutex
+	stats StreamStats
+
+	tickerX float64
+}
+
+func NewStreamOverlay() *StreamOverlay {
+	return &StreamOverlay{tickerX: screenWidth}
+}
+
+// StatsJSON returns the latest stats. It may be called from any goroutine.
+func (o *StreamOverlay) StatsJSON() ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return json.Marshal(o.stats)
+}
+
+func (g *Game) modeName() string {
+	switch g.mode {
+	case ModeGame:
+		if g.paused {
+			return "paused"
+		}
+		return "playing"
+	case ModeGameOver:
+		return "game_over"
+	case ModeResults:
+		return "results"
+	case ModeStandings:
+		return "standings"
+	case ModeEnding:
+		return "ending"
+	default:
+		return "menu"
+	}
+}
+
+func (g *Game) updateStream() {
+	o := g.stream
+	if o == nil {
+		return
+	}
+
+	recent := []int{}
+	h := g.records.History
+	for i := len(h) - 1; i >= 0 && len(recent) < streamRecentRuns; i-- {
+		recent = append(recent, h[i].Distance)
+	}
+	o.mu.Lock()
+	o.stats = StreamStats{
+		Mode:        g.modeName(),
+		Distance:    int(g.birdman.x) / 10,
+		Best:        g.records.BestDistance,
+		SpeedKmh:    int(g.birdman.vx / 10 * 3.6),
+		StylePoints: g.stylePoints,
+		Damage:      g.stats.damage,
+		Runs:        g.records.Runs,
+		Recent:      recent,
+	}
+	if p := &g.party; p.active && !p.over() {
+		o.stats.Player = p.players[p.turn].Name
+	}
+	o.mu.Unlock()
+
+	o.tickerX -= streamTickerSpeed / float64(ebiten.MaxTPS())
+	if w := float64(len(g.streamTickerText()) * smallFontSize); o.tickerX < -w {
+		o.tickerX = screenWidth
+	}
+}
+
+func (g *Game) streamTickerText() string {
+	parts := []string{fmt.Sprintf("BEST %sm", formatIntComma(g.records.BestDistance))}
+	h := g.records.History
+	for i := len(h) - 1; i >= 0 && len(h)-i <= streamRecentRuns; i-- {
+		parts = append(parts, fmt.Sprintf("%sm %s", formatIntComma(h[i].Distance), h[i].Cause))
+	}
+	return strings.Join(parts, "  /  ")
+}
+
+func (g *Game) drawStream(screen *ebiten.Image) {
+	o := g.stream
+	if o == nil {
+		return
+	}
+	switch g.mode {
+	case ModeGame:
+		s := fmt.Sprintf("%sm", formatIntComma(int(g.birdman.x)/10))
+		text.Draw(screen, s, titleFont, screenWidth/2-len(s)*titleFontSize/2, streamScoreY, color.White)
+	case ModeGameOver:
+		// The retry button is where the ticker goes
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, screenHeight-streamTickerHeight, screenWidth, streamTickerHeight, streamTickerColor)
+	text.Draw(screen, g.streamTickerText(), smallFont, int(o.tickerX), screenHeight-streamTickerHeight/2+smallFontSize/2, color.White)
+}
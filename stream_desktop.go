@@ -0,0 +1,32 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"log"
+	"net/http"
+)
+
+// startStreamServer serves the stats of the stream overlay at addr, on its
+// own mux so that pprof isn't exposed along with them.
+func startStreamServer(addr string, o *StreamOverlay) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		b, err := o.StatsJSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Overlays are pages of their own origin
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+	go func() {
+		log.Printf("Serving stream stats at http://%s/stats", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Failed to serve stream stats: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,14 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"log"
+)
+
+// startStreamServer is not available in browsers; only the overlay is drawn
+// there.
+func startStreamServer(addr string, o *StreamOverlay) {
+	log.Print("The stream stats server is not available on this platform")
+}
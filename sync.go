@@ -0,0 +1,419 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	logging "github.com/tsujio/game-logging-server/client"
+)
+
+const (
+	syncCodeLength = 8
+	// no 0/O or 1/I, which are easy to mistake for each other when typing
+	// the code in on another device
+	syncCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// keeps the synced scores apart from the player ids of the event logs
+	syncPlayerIDPrefix = "sync-"
+	// the code is short, so its hash is made slow to guess the codes from
+	syncHashRounds = 1 << 12
+	syncMenuY      = 220
+	syncStatusY    = 340
+)
+
+var (
+	errSyncUnavailable = errors.New("cloud sync is not available")
+	errSyncNotFound    = errors.New("no record found for the code")
+)
+
+// ScoreSyncer keeps the best distance of a player on a server under a code
+// the player can carry to another device.
+type ScoreSyncer interface {
+	UploadBest(code string, distance int) error
+	FetchBest(code string) (int, error)
+	// UploadRaceTime registers the time in seconds on the leaderboard of the
+	// race's distance
+	UploadRaceTime(code string, distance int, t float64) error
+	// FetchScores reads a score list of the server for the leaderboard
+	FetchScores(list string) ([]logging.GameScore, error)
+}
+
+// newSyncCode returns a random code to keep the records under. It doesn't
+// come from the game's random source, which may be seeded the same on all
+// devices.
+func newSyncCode() (string, error) {
+	b := make([]byte, syncCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(syncCodeChars))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = syncCodeChars[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// normalizeSyncCode makes a typed in code comparable with the generated
+// ones, or returns false if it could not be one.
+func normalizeSyncCode(s string) (string, bool) {
+	s = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+	if len(s) != syncCodeLength {
+		return "", false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(syncCodeChars, c) {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// The best distances kept for the sync are on a score list of their own, out
+// of the leaderboard of the game
+var syncScoreList = gameName + "-sync"
+
+// syncPlayerID is what the records are published under for the code. The
+// score lists can be read by anyone, so only a one-way hash of the code,
+// which is the secret to restore the records with, goes there.
+func syncPlayerID(code string) string {
+	sum := sha256.Sum256([]byte(gameName + ":" + code))
+	for i := 1; i < syncHashRounds; i++ {
+		sum = sha256.Sum256(sum[:])
+	}
+	return syncPlayerIDPrefix + hex.EncodeToString(sum[:16])
+}
+
+// formatSyncCode splits the code in half for reading it out.
+func formatSyncCode(code string) string {
+	if len(code) != syncCodeLength {
+		return code
+	}
+	return code[:syncCodeLength/2] + "-" + code[syncCodeLength/2:]
+}
+
+// UploadBest registers the distance on the sync score list of the logging
+// server.
+func (l *EventLogger) UploadBest(code string, distance int) error {
+	enabled, endpoint := l.target()
+	if !enabled {
+		return errSyncUnavailable
+	}
+	playerID := syncPlayerID(code)
+	if endpoint == "" {
+		return logging.RegisterScore(syncScoreList, playerID, distance)
+	}
+	return l.post(endpoint+"/score", map[string]interface{}{
+		"game_name": syncScoreList,
+		"player_id": playerID,
+		"score":     distance,
+	})
+}
+
+// UploadRaceTime registers the time on a score list of its own for every race
+// distance. The score lists rank the higher scores first, so the time goes in
+// negated, in milliseconds.
+func (l *EventLogger) UploadRaceTime(code string, distance int, t float64) error {
+	enabled, endpoint := l.target()
+	if !enabled {
+		return errSyncUnavailable
+	}
+	playerID := syncPlayerID(code)
+	category := raceScoreList(distance)
+	score := -int(math.Round(t * 1000))
+	if endpoint == "" {
+		return logging.RegisterScore(category, playerID, score)
+	}
+	return l.post(endpoint+"/score", map[string]interface{}{
+		"game_name": category,
+		"player_id": playerID,
+		"score":     score,
+	})
+}
+
+// raceScoreList is the score list of the times of the race distance.
+func raceScoreList(distance int) string {
+	return fmt.Sprintf("%s-race-%dm", gameName, distance)
+}
+
+// FetchScores reads the score list from the logging server.
+func (l *EventLogger) FetchScores(list string) ([]logging.GameScore, error) {
+	enabled, endpoint := l.target()
+	if !enabled {
+		return nil, errSyncUnavailable
+	}
+	if endpoint == "" {
+		return logging.GetScoreList(list)
+	}
+	return getScoreList(endpoint, list)
+}
+
+// FetchBest looks the hash of the code up in the sync score list of the
+// logging server. The server has no other way to read back what was sent to
+// it, so only the best distance can be restored.
+func (l *EventLogger) FetchBest(code string) (int, error) {
+	scores, err := l.FetchScores(syncScoreList)
+	if err != nil {
+		return 0, err
+	}
+
+	best := -1
+	playerID := syncPlayerID(code)
+	for _, s := range scores {
+		if s.PlayerID == playerID && s.Score > best {
+			best = s.Score
+		}
+	}
+	if best < 0 {
+		return 0, errSyncNotFound
+	}
+	return best, nil
+}
+
+func getScoreList(endpoint, name string) ([]logging.GameScore, error) {
+	resp, err := http.Get(endpoint + "/score?game_name=" + url.QueryEscape(name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error response from game logging server: %s", resp.Status)
+	}
+
+	var data struct {
+		Scores []logging.GameScore `json:"scores"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data.Scores, nil
+}
+
+type syncResult struct {
+	// the code restored from, empty for uploads
+	restored string
+	best     int
+	// the distance and the time of the race uploaded, 0 for the best
+	// distance
+	race     int
+	raceTime float64
+	err      error
+}
+
+// CloudSync is the state of the sync screen. Requests to the server run in
+// the background and report back through results.
+type CloudSync struct {
+	entry   TextEntry
+	busy    bool
+	status  string
+	results chan syncResult
+}
+
+func (g *Game) syncAvailable() bool {
+	return g.syncer != nil && g.settings.Privacy.Logging
+}
+
+// syncCode returns the code the records are kept under, making one up the
+// first time.
+func (g *Game) syncCode() string {
+	if g.records.SyncCode == "" {
+		code, err := newSyncCode()
+		if err != nil {
+			log.Printf("Failed to make a sync code: %v", err)
+			return ""
+		}
+		g.records.SyncCode = code
+		if err := g.records.Save(g.storage); err != nil {
+			log.Printf("Failed to save records: %v", err)
+		}
+	}
+	return g.records.SyncCode
+}
+
+func (g *Game) runSync(f func() syncResult) {
+	if g.sync.results == nil {
+		g.sync.results = make(chan syncResult, 1)
+	}
+	g.sync.busy = true
+	go func() {
+		g.sync.results <- f()
+	}()
+}
+
+// uploadBest sends the best distance to the server, if the player turned
+// the sync on. The rest of the records go along in the event log, where they
+// are kept but can't be read back.
+func (g *Game) uploadBest() {
+	if !g.syncAvailable() || !g.settings.CloudSync || g.sync.busy {
+		return
+	}
+	code, best := g.syncCode(), g.records.BestDistance
+	if code == "" {
+		return
+	}
+	g.logEvent(SyncRecordsEvent{
+		SyncID:        syncPlayerID(code),
+		Best:          best,
+		Runs:          g.records.Runs,
+		TotalDistance: g.records.TotalDistance,
+	})
+	g.sync.status = "UPLOADING..."
+	g.runSync(func() syncResult {
+		return syncResult{best: best, err: g.syncer.UploadBest(code, best)}
+	})
+}
+
+// uploadRaceTime sends the time of the race just finished to the
+// leaderboard of its distance, if the player turned the sync on.
+func (g *Game) uploadRaceTime() {
+	if !g.syncAvailable() || !g.settings.CloudSync || g.sync.busy {
+		return
+	}
+	code := g.syncCode()
+	if code == "" {
+		return
+	}
+	d, t := g.speedrunTarget, g.stats.finishTime
+	g.sync.status = "UPLOADING..."
+	g.runSync(func() syncResult {
+		return syncResult{race: d, raceTime: t, err: g.syncer.UploadRaceTime(code, d, t)}
+	})
+}
+
+// restoreBest takes over the records kept under a code from another device.
+func (g *Game) restoreBest(code string) {
+	g.sync.status = "RESTORING..."
+	g.runSync(func() syncResult {
+		best, err := g.syncer.FetchBest(code)
+		return syncResult{restored: code, best: best, err: err}
+	})
+}
+
+// updateSync takes the result of a finished request, if any.
+func (g *Game) updateSync() {
+	if !g.sync.busy {
+		return
+	}
+	var r syncResult
+	select {
+	case r = <-g.sync.results:
+	default:
+		return
+	}
+	g.sync.busy = false
+
+	switch {
+	case errors.Is(r.err, errSyncNotFound):
+		g.sync.status = "NO RECORD FOR THE CODE"
+		return
+	case r.err != nil:
+		log.Printf("Failed to sync records: %v", r.err)
+		g.sync.status = "SYNC FAILED"
+		return
+	case r.race > 0:
+		g.sync.status = fmt.Sprintf("UPLOADED %s TO %sm", formatRunTime(r.raceTime), formatIntComma(r.race))
+		return
+	case r.restored == "":
+		g.sync.status = fmt.Sprintf("UPLOADED %sm", formatIntComma(r.best))
+		return
+	}
+
+	g.records.SyncCode = r.restored
+	if r.best > g.records.BestDistance {
+		g.records.BestDistance = r.best
+		// The splits of the best run are not on the server
+		g.records.BestSplits = nil
+	}
+	if err := g.records.Save(g.storage); err != nil {
+		log.Printf("Failed to save records: %v", err)
+	}
+	g.sync.status = fmt.Sprintf("RESTORED %sm", formatIntComma(r.best))
+	g.logEvent(RestoreRecordsEvent{Best: r.best})
+}
+
+func (g *Game) newSyncMenu() *Menu {
+	return &Menu{
+		title: "CLOUD SYNC",
+		y:     syncMenuY,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label: func() string { return "SYNC: " + onOff(g.settings.CloudSync) },
+				action: func() {
+					g.settings.CloudSync = !g.settings.CloudSync
+					g.saveSettings()
+					g.uploadBest()
+				},
+			},
+			{
+				label: func() string { return "MY CODE: " + formatSyncCode(g.syncCode()) },
+				action: func() {
+					g.uploadBest()
+				},
+				visible: func() bool { return g.settings.CloudSync },
+			},
+			{
+				label: func() string { return "RESTORE FROM A CODE" },
+				action: func() {
+					if !g.sync.busy {
+						g.sync.entry.Start("", syncCodeLength+1)
+						g.sync.status = "TYPE THE CODE AND PRESS ENTER"
+					}
+				},
+			},
+			{
+				label: func() string { return "BACK" },
+				action: func() {
+					g.mode = ModeSettings
+				},
+			},
+		},
+	}
+}
+
+func (g *Game) updateSyncScreen() {
+	e := &g.sync.entry
+	if !e.active {
+		g.syncMenu.Update(g.input)
+		return
+	}
+
+	if !e.Update() {
+		if !e.active {
+			g.sync.status = ""
+		}
+		return
+	}
+	code, ok := normalizeSyncCode(e.text)
+	if !ok {
+		g.sync.status = "THE CODE IS 8 LETTERS"
+		return
+	}
+	e.active = false
+	g.restoreBest(code)
+}
+
+func (g *Game) drawSyncScreen(screen *ebiten.Image) {
+	if g.sync.status != "" {
+		s := g.sync.status
+		text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, syncStatusY, color.White)
+	}
+	if g.sync.entry.active {
+		g.sync.entry.Draw(screen, "CODE: ", syncMenuY)
+		return
+	}
+	g.syncMenu.Draw(screen)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"runtime"
+	"time"
+)
+
+// gameVersion is set when building a release, see the Makefile.
+var gameVersion = "dev"
+
+// SessionInfo is added to every event, to tell apart the platforms and
+// releases on the server.
+type SessionInfo struct {
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+	// in device pixels
+	ScreenWidth  int `json:"screen_width"`
+	ScreenHeight int `json:"screen_height"`
+	// seconds since the game was launched
+	Duration float64 `json:"duration"`
+}
+
+func platformName() string {
+	if runtime.GOOS == "js" {
+		return "wasm"
+	}
+	return runtime.GOOS
+}
+
+// TelemetryEvent is an event logged to the game logging server. Its fields
+// make the payload along with the action, the ids of the player and the play
+// and the session info, which logEvent adds.
+type TelemetryEvent interface {
+	Action() string
+}
+
+type InitializeEvent struct {
+	Count int `json:"count"`
+}
+
+type StartGameEvent struct{}
+
+type RestartEvent struct {
+	// "pause" or "game_over"
+	From string `json:"from"`
+	X    int    `json:"x"`
+}
+
+type IdleEvent struct{}
+
+type GameOverEvent struct {
+	X            int    `json:"x"`
+	DamagedCount int    `json:"damaged_count"`
+	StylePoints  int    `json:"style_points"`
+	Cause        string `json:"cause"`
+}
+
+// DamagePosition is where in the world the birdman was hit, in pixels, to
+// plot the hits on a heatmap of the course.
+type DamagePosition struct {
+	Cause string `json:"cause"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+}
+
+// RunSettings are the settings a run was played with which may affect how
+// it went.
+type RunSettings struct {
+	Tilt        bool `json:"tilt"`
+	Minimap     bool `json:"minimap"`
+	Muted       bool `json:"muted"`
+	Fullscreen  bool `json:"fullscreen"`
+	RenderScale int  `json:"render_scale"`
+	Rhythm      bool `json:"rhythm"`
+	Mirror      bool `json:"mirror"`
+	Night       bool `json:"night"`
+}
+
+// RunSummaryEvent sums a run up at its end.
+type RunSummaryEvent struct {
+	// in meters
+	Distance int `json:"distance"`
+	// in seconds
+	Duration    float64          `json:"duration"`
+	Cause       string           `json:"cause"`
+	Flaps       int              `json:"flaps"`
+	NearMisses  int              `json:"near_misses"`
+	Items       int              `json:"items"`
+	BirdsFed    int              `json:"birds_fed"`
+	StylePoints int              `json:"style_points"`
+	NewBest     bool             `json:"new_best"`
+	Damages     []DamagePosition `json:"damages"`
+	Settings    RunSettings      `json:"settings"`
+	// the run wasn't recorded
+	Cheated   bool     `json:"cheated"`
+	Practice  bool     `json:"practice"`
+	Modifiers []string `json:"modifiers,omitempty"`
+	// the gap scale of the adaptive difficulty the run was played with
+	GapScale float64 `json:"gap_scale"`
+	// the new game+ loop, 0 for the game itself
+	NewGamePlus int `json:"new_game_plus,omitempty"`
+}
+
+type SyncRecordsEvent struct {
+	// the hash of the code, which is kept out of the logs
+	SyncID        string `json:"sync_id"`
+	Best          int    `json:"best"`
+	Runs          int    `json:"runs"`
+	TotalDistance int    `json:"total_distance"`
+}
+
+type RestoreRecordsEvent struct {
+	Best int `json:"best"`
+}
+
+type CheatsUnlockedEvent struct{}
+
+func (InitializeEvent) Action() string     { return "initialize" }
+func (StartGameEvent) Action() string      { return "start_game" }
+func (RestartEvent) Action() string        { return "restart" }
+func (IdleEvent) Action() string           { return "idle" }
+func (GameOverEvent) Action() string       { return "game_over" }
+func (RunSummaryEvent) Action() string     { return "run_summary" }
+func (SyncRecordsEvent) Action() string    { return "sync_records" }
+func (RestoreRecordsEvent) Action() string { return "restore_records" }
+func (CheatsUnlockedEvent) Action() string { return "cheats_unlocked" }
+
+func (g *Game) sessionInfo() SessionInfo {
+	s := SessionInfo{
+		Version:  gameVersion,
+		Platform: platformName(),
+		Duration: time.Since(g.sessionStart).Round(time.Second).Seconds(),
+	}
+	if g.viewport != nil {
+		s.ScreenWidth, s.ScreenHeight = g.viewport.Size()
+	}
+	return s
+}
+
+// eventPayload flattens the event into the payload sent to the server.
+func (g *Game) eventPayload(e TelemetryEvent) (map[string]interface{}, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return nil, err
+	}
+	payload["player_id"] = g.playerID
+	payload["play_id"] = g.playID
+	payload["action"] = e.Action()
+	payload["session"] = g.sessionInfo()
+	return payload, nil
+}
+
+func (g *Game) logEvent(e TelemetryEvent) {
+	payload, err := g.eventPayload(e)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", e.Action(), err)
+		return
+	}
+	g.logger.LogAsync(payload)
+}
+
+func (g *Game) runSettings() RunSettings {
+	s := g.settings
+	return RunSettings{
+		Tilt:        s.TiltEnabled && deviceTilt.IsAvailable(),
+		Minimap:     s.Minimap,
+		Muted:       s.Audio.Muted,
+		Fullscreen:  s.Window.Fullscreen,
+		RenderScale: s.Window.RenderScale,
+		Rhythm:      s.Rhythm,
+		Mirror:      g.mirrored(),
+		Night:       g.isNight(),
+	}
+}
+
+func (g *Game) logRunSummary() {
+	damages := g.stats.damages
+	if damages == nil {
+		damages = []DamagePosition{}
+	}
+	g.logEvent(RunSummaryEvent{
+		Distance:    int(g.birdman.x) / 10,
+		Duration:    g.splits.time,
+		Cause:       g.stats.cause.String(),
+		Flaps:       g.stats.flaps,
+		NearMisses:  g.stats.nearMisses,
+		Items:       g.stats.items,
+		BirdsFed:    g.stats.birdsFed,
+		StylePoints: g.stylePoints,
+		NewBest:     g.records.BestDistance > g.stats.previousBest,
+		Damages:     damages,
+		Settings:    g.runSettings(),
+		Cheated:     g.stats.cheated,
+		Practice:    g.stats.practice,
+		Modifiers:   g.stats.modifiers,
+		GapScale:    g.stats.gapScale,
+		NewGamePlus: g.loop,
+	})
+}
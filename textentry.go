@@ -0,0 +1,65 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// TextEntry is a one line text field typed in with the keyboard, for the
+// few places the game asks for text.
+type TextEntry struct {
+	active bool
+	text   string
+	maxLen int
+}
+
+func (e *TextEntry) Start(text string, maxLen int) {
+	*e = TextEntry{active: true, text: text, maxLen: maxLen}
+}
+
+// Update edits the text while the entry is active. It reports whether Enter
+// was pressed, which leaves it to the caller to validate the text and stop
+// the entry. Escape stops it right away.
+func (e *TextEntry) Update() (confirmed bool) {
+	if !e.active {
+		return false
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		e.active = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		return true
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		if n := len(e.text); n > 0 {
+			e.text = e.text[:n-1]
+		}
+	default:
+		// Only ASCII, which the font has and len() measures
+		for _, c := range ebiten.InputChars() {
+			if len(e.text) < e.maxLen && c >= 0x20 && c < 0x7f {
+				e.text += string(c)
+			}
+		}
+	}
+	return false
+}
+
+// Draw draws the label and the text with a cursor centered at y in the
+// small font. Text too long for the screen is scrolled to its end.
+func (e *TextEntry) Draw(screen *ebiten.Image, label string, y int) {
+	t := e.text
+	if n := screenWidth/smallFontSize - len(label) - 2; len(t) > n {
+		t = t[len(t)-n:]
+	}
+	s := label + t + "_"
+	text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, y, color.White)
+}
+
+// typing reports whether the keys go into a text entry or the capture of a
+// binding rather than to the hotkeys.
+func (g *Game) typing() bool {
+	return g.urlEntry.active || g.challengeEntry.active || g.party.entry.active || g.sync.entry.active || g.rebinding.active
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+const tiltMaxAngle = math.Pi / 4
+
+// Tilt holds the latest device pitch reported by the orientation sensor of
+// the browser or the accelerometer of the mobile apps. Builds without one
+// never receive readings, so the sensor stays unavailable there.
+type Tilt struct {
+	mu        sync.Mutex
+	available bool
+	pitch     float64
+}
+
+var deviceTilt = &Tilt{}
+
+// setDeviceTilt is called by the sensor with the device pitch in radians.
+func setDeviceTilt(pitch float64) {
+	deviceTilt.mu.Lock()
+	defer deviceTilt.mu.Unlock()
+	deviceTilt.available = true
+	deviceTilt.pitch = pitch
+}
+
+func (t *Tilt) IsAvailable() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.available
+}
+
+func (t *Tilt) Pitch() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pitch
+}
+
+// Value returns the tilt relative to the calibrated neutral pitch, in range [-1, 1].
+func (t *Tilt) Value(offset float64) float64 {
+	v := (t.Pitch() - offset) / tiltMaxAngle
+	return math.Max(-1, math.Min(1, v))
+}
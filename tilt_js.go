@@ -0,0 +1,41 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"math"
+
+	"syscall/js"
+)
+
+// startTiltSensor feeds the device orientation of phones and tablets into
+// the tilt. The pitch is the front-to-back tilt in portrait and the
+// side-to-side one in landscape. Desktop browsers fire no such events, or
+// only with nulls, so the tilt stays unavailable there.
+func startTiltSensor() {
+	window := js.Global()
+	if !window.Get("DeviceOrientationEvent").Truthy() {
+		return
+	}
+	window.Call("addEventListener", "deviceorientation", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		beta, gamma := e.Get("beta"), e.Get("gamma")
+		if beta.Type() != js.TypeNumber || gamma.Type() != js.TypeNumber {
+			return nil
+		}
+		degrees := beta.Float()
+		angle := 0
+		if o := window.Get("screen").Get("orientation"); o.Truthy() {
+			angle = o.Get("angle").Int()
+		}
+		switch angle {
+		case 90:
+			degrees = -gamma.Float()
+		case 270, -90:
+			degrees = gamma.Float()
+		}
+		setDeviceTilt(degrees * math.Pi / 180)
+		return nil
+	}))
+}
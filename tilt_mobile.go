@@ -0,0 +1,50 @@
+//go:build android || ios
+// +build android ios
+
+package main
+
+import (
+	"log"
+	"math"
+	"runtime"
+	"time"
+
+	"golang.org/x/mobile/exp/sensor"
+)
+
+const tiltSensorDelay = time.Second / 30
+
+// accelerometerSender takes the readings of the accelerometer, which the
+// sensor package sends events of.
+type accelerometerSender struct{}
+
+// Send turns the gravity on the device into its pitch. Of the axes on the
+// screen, the one gravity pulls along the most is the one going up it: the
+// long one in portrait and the short one in landscape, either way round.
+// The pitch is the tilt of that axis towards the back of the device, like
+// the one of the browsers' device orientation.
+func (accelerometerSender) Send(event interface{}) {
+	e, ok := event.(sensor.Event)
+	if !ok || e.Sensor != sensor.Accelerometer || len(e.Data) < 3 {
+		return
+	}
+	x, y, z := e.Data[0], e.Data[1], e.Data[2]
+	// iOS reports the acceleration the other way round, in g
+	if runtime.GOOS == "ios" {
+		x, y, z = -x, -y, -z
+	}
+	up := y
+	if math.Abs(x) > math.Abs(y) {
+		up = math.Abs(x)
+	}
+	setDeviceTilt(math.Atan2(up, z))
+}
+
+// startTiltSensor feeds the accelerometer of the phones and tablets into
+// the tilt.
+func startTiltSensor() {
+	sensor.Notify(accelerometerSender{})
+	if err := sensor.Enable(sensor.Accelerometer, tiltSensorDelay); err != nil {
+		log.Printf("Failed to enable the accelerometer: %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !js && !android && !ios
+// +build !js,!android,!ios
+
+package main
+
+// startTiltSensor does nothing: desktops have no motion sensor to tilt with.
+func startTiltSensor() {}
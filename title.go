@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	titleMenuY    = 160
+	infoScreenTop = 150
+	infoScreenY   = 400
+)
+
+var creditTexts = []string{"CREATOR: NAOKI TSUJIO", "PHOTO: OITA-SHI (FIND/47)", "FONT: Press Start 2P by CodeMan38", "SOUND: MaouDamashii"}
+
+func (g *Game) newTitleMenu() *Menu {
+	return &Menu{
+		title: "BIRDMAN CHALLENGE",
+		y:     titleMenuY,
+		sfx:   g.sfx,
+		rows:  7,
+		items: []MenuItem{
+			{
+				label: func() string { return "START" },
+				action: func() {
+					g.practice = false
+					g.racing = false
+					g.speedrunTarget = 0
+					g.startIntro()
+				},
+			},
+			{
+				label:  func() string { return "DAILY" },
+				action: g.startDaily,
+			},
+			{
+				label: func() string { return "NEW GAME+: " + newGamePlusLabel(g.loop) },
+				action: func() {
+					g.loop = (g.loop + 1) % (g.records.Loops + 1)
+				},
+				adjust: func(delta int) {
+					n := g.records.Loops + 1
+					g.loop = ((g.loop+delta)%n + n) % n
+				},
+				visible: func() bool { return g.records.Loops > 0 },
+			},
+			{
+				label:  func() string { return "PRACTICE" },
+				action: func() { g.mode = ModePractice },
+			},
+			{
+				label:  func() string { return "SPEEDRUN" },
+				action: func() { g.mode = ModeSpeedrun },
+			},
+			{
+				label:  func() string { return "RACE" },
+				action: func() { g.mode = ModeRace },
+			},
+			{
+				label:  func() string { return "CHALLENGE" },
+				action: func() { g.mode = ModeChallenge },
+			},
+			{
+				label:  func() string { return "PARTY" },
+				action: func() { g.mode = ModeParty },
+			},
+			{
+				label:  func() string { return "MODIFIERS" },
+				action: func() { g.mode = ModeModifiers },
+			},
+			{
+				label:  func() string { return "SHOP" },
+				action: func() { g.mode = ModeShop },
+			},
+			{
+				label:  func() string { return "SCENERY" },
+				action: func() { g.mode = ModeScenery },
+			},
+			{
+				label:  func() string { return "SETTINGS" },
+				action: func() { g.mode = ModeSettings },
+			},
+			{
+				label:  func() string { return "LEADERBOARD" },
+				action: g.openLeaderboard,
+			},
+			{
+				label:  func() string { return "STATS" },
+				action: func() { g.mode = ModeStats },
+			},
+			{
+				label:  func() string { return "CREDITS" },
+				action: func() { g.mode = ModeCredits },
+			},
+		},
+	}
+}
+
+// newBackMenu is the menu of the screens which only show something and go
+// back to the title.
+func (g *Game) newBackMenu() *Menu {
+	return &Menu{
+		y:     infoScreenY,
+		small: true,
+		sfx:   g.sfx,
+		items: []MenuItem{
+			{
+				label:  func() string { return "BACK" },
+				action: func() { g.mode = ModeTitle },
+			},
+		},
+	}
+}
+
+func (g *Game) newStatsMenu() *Menu {
+	m := g.newBackMenu()
+	m.items = append([]MenuItem{
+		{
+			label:  func() string { return "EXPORT CSV" },
+			action: func() { g.exportFromMenu("csv") },
+		},
+		{
+			label:  func() string { return "EXPORT JSON" },
+			action: func() { g.exportFromMenu("json") },
+		},
+	}, m.items...)
+	m.y -= m.itemHeight() * 2
+	return m
+}
+
+func (g *Game) statsTexts() []string {
+	r := g.records
+	average := 0
+	if r.Runs > 0 {
+		average = r.TotalDistance / r.Runs
+	}
+	texts := []string{
+		fmt.Sprintf("BEST     %sm", formatIntComma(r.BestDistance)),
+		fmt.Sprintf("RUNS     %s", formatIntComma(r.Runs)),
+		fmt.Sprintf("TOTAL    %sm", formatIntComma(r.TotalDistance)),
+		fmt.Sprintf("AVERAGE  %sm", formatIntComma(average)),
+	}
+	if g.exportStatus != "" {
+		texts = append(texts, "", g.exportStatus)
+	}
+	return texts
+}
+
+// drawInfoScreen draws a titled list of lines above the back menu.
+func drawInfoScreen(screen *ebiten.Image, title string, lines []string, menu *Menu) {
+	text.Draw(screen, title, titleFont, screenWidth/2-len(title)*titleFontSize/2, infoScreenTop-regularFontSize*2, color.White)
+	for i, s := range lines {
+		text.Draw(screen, s, smallFont, screenWidth/2-len(s)*smallFontSize/2, infoScreenTop+i*smallFontSize*3, color.White)
+	}
+	menu.Draw(screen)
+}
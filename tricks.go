@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	rollDuration      = 0.6
+	loopPoints        = 100
+	threadPoints      = 150
+	threadMaxGap      = 220
+	threadWindow      = 0.4
+	nearMissPoints    = 10
+	nearMissGap       = 70
+	trickBannerLength = 1.2
+	// in meters
+	milestoneDistance = 1000
+)
+
+// TrickDetector recognizes tricks from what happens around the birdman.
+type TrickDetector struct {
+	time          float64
+	lastPassTime  float64
+	lastPassY     float64
+	hasLastPass   bool
+	banner        string
+	bannerTime    float64
+	bannerPoints  int
+	bannerCombo   int
+	bannerEndTime float64
+	milestone     int
+}
+
+func (t *TrickDetector) Reset() {
+	*t = TrickDetector{}
+}
+
+// award adds style points and announces the trick. Tricks landing while the
+// previous announcement is still up are shown together as a combo.
+func (g *Game) award(name string, points int) {
+	t := &g.tricks
+	if t.time >= t.bannerEndTime {
+		t.bannerCombo = 0
+		t.bannerPoints = 0
+	}
+	t.bannerCombo++
+	t.bannerPoints += points
+	t.banner = name
+	t.bannerTime = t.time
+	t.bannerEndTime = t.time + trickBannerLength
+	g.stylePoints += points
+	g.popups.Spawn(fmt.Sprintf("+%d", points), g.birdman.x, g.birdman.y-birdmanHeight/2, popupPointsColor)
+}
+
+// startRoll begins a loop of the birdman unless he is already rolling.
+func (b *Birdman) startRoll() {
+	if b.rolling {
+		return
+	}
+	b.rolling = true
+	b.angle = 0
+}
+
+func (b *Birdman) cancelRoll() {
+	b.rolling = false
+	b.angle = 0
+}
+
+// updateTricks advances the current roll, looks for birds the birdman has
+// just threaded between or narrowly missed, and announces the milestones.
+func (g *Game) updateTricks() {
+	birdman := g.birdman
+	t := &g.tricks
+	t.time += simulationStep
+
+	if birdman.rolling {
+		birdman.angle += 2 * math.Pi / rollDuration * simulationStep
+		if birdman.angle >= 2*math.Pi {
+			birdman.cancelRoll()
+			g.award("LOOP!", loopPoints)
+		}
+	}
+
+	for i := range g.birds {
+		b := &g.birds[i]
+		if b.passed || b.x > birdman.x {
+			continue
+		}
+		b.passed = true
+
+		// A bird on the other side of the birdman was passed just before
+		if t.hasLastPass && t.time-t.lastPassTime < threadWindow &&
+			(t.lastPassY-birdman.y)*(b.y-birdman.y) < 0 && math.Abs(t.lastPassY-b.y) < threadMaxGap {
+			t.hasLastPass = false
+			g.award("THREAD THE NEEDLE!", threadPoints)
+			continue
+		}
+		t.hasLastPass = true
+		t.lastPassTime = t.time
+		t.lastPassY = b.y
+
+		if !b.struck && math.Abs(b.y-birdman.y) < nearMissGap {
+			g.stylePoints += nearMissPoints
+			g.stats.nearMisses++
+			g.popups.Spawn("NEAR MISS!", b.x, b.y-birdHeight/2, popupNearMissColor)
+		}
+	}
+
+	if m := int(birdman.x) / 10; m >= t.milestone+milestoneDistance {
+		t.milestone = m / milestoneDistance * milestoneDistance
+		g.popups.Spawn("MILESTONE!", birdman.x, birdman.y-birdmanHeight, popupMilestoneColor)
+		g.popups.Spawn(fmt.Sprintf("%sM", formatIntComma(t.milestone)), birdman.x, birdman.y-birdmanHeight+smallFontSize*2, popupMilestoneColor)
+		g.sfx.PlaySE(ringAudioData)
+	}
+}
+
+// trickBanner returns the announcement to show, if any, and how far it has
+// faded out from 0 to 1.
+func (g *Game) trickBanner() (text string, points, combo int, fade float64, ok bool) {
+	t := &g.tricks
+	if t.banner == "" || t.time >= t.bannerEndTime {
+		return "", 0, 0, 0, false
+	}
+	return t.banner, t.bannerPoints, t.bannerCombo, (t.time - t.bannerTime) / trickBannerLength, true
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	damageVibration      = 150 * time.Millisecond
+	damageVibrationPower = 0.6
+	seaVibration         = 400 * time.Millisecond
+	seaVibrationPower    = 1.0
+)
+
+// Haptics vibrates the gamepads or the device, with strength in range
+// [0, 1]. Only the browser has an API for it, so only the web build vibrates.
+type Haptics interface {
+	IsAvailable() bool
+	Vibrate(duration time.Duration, strength float64)
+}
+
+// vibrate plays a vibration unless turned off in the settings.
+func (g *Game) vibrate(duration time.Duration, strength float64) {
+	if g.haptics == nil || !g.settings.Vibration {
+		return
+	}
+	g.haptics.Vibrate(duration, strength)
+}
+
+func (g *Game) hapticsAvailable() bool {
+	return g.haptics != nil && g.haptics.IsAvailable()
+}
@@ -0,0 +1,10 @@
+//go:build !js
+// +build !js
+
+package main
+
+// newPlatformHaptics returns nil: ebiten has no API for gamepad rumble or the
+// vibrator of the apps yet, so nothing vibrates here.
+func newPlatformHaptics() Haptics {
+	return nil
+}
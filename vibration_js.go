@@ -0,0 +1,50 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"time"
+
+	"syscall/js"
+)
+
+// browserHaptics vibrates the gamepads having a vibration actuator through
+// the Gamepad API, and the phone through the Vibration API.
+type browserHaptics struct{}
+
+func newPlatformHaptics() Haptics {
+	return browserHaptics{}
+}
+
+func (browserHaptics) IsAvailable() bool {
+	navigator := js.Global().Get("navigator")
+	return navigator.Truthy() && (navigator.Get("vibrate").Truthy() || navigator.Get("getGamepads").Truthy())
+}
+
+func (browserHaptics) Vibrate(duration time.Duration, strength float64) {
+	navigator := js.Global().Get("navigator")
+	if !navigator.Truthy() {
+		return
+	}
+	ms := int(duration / time.Millisecond)
+
+	if navigator.Get("getGamepads").Truthy() {
+		pads := navigator.Call("getGamepads")
+		for i := 0; i < pads.Length(); i++ {
+			pad := pads.Index(i)
+			if !pad.Truthy() || !pad.Get("vibrationActuator").Truthy() {
+				continue
+			}
+			pad.Get("vibrationActuator").Call("playEffect", "dual-rumble", map[string]interface{}{
+				"duration":        ms,
+				"strongMagnitude": strength,
+				"weakMagnitude":   strength,
+			})
+		}
+	}
+	// Phones can't vibrate weaker, so weak vibrations are shortened instead
+	if navigator.Get("vibrate").Truthy() {
+		navigator.Call("vibrate", int(float64(ms)*strength))
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// of the canvas size, for the render scale setting
+	maxRenderScale = 3
+	// of the canvas size, for the upscaled canvas
+	maxCanvasUpscale = 4
+)
+
+// Viewport maps the fixed-size canvas the game is drawn on onto the actual
+// screen, either letterboxed at any scale or pixel-perfect at integer scales.
+//
+// Letterboxed, the canvas is first scaled up by whole pixels to the multiple
+// of its size just above the screen, and only that is filtered down to the
+// screen. The pixel art and the pixel font stay sharp then, where filtering
+// the canvas itself up to a high-DPI screen blurs every edge. The render
+// scale fixes that multiple instead, 0 picking it by the screen.
+type Viewport struct {
+	integerScaling    bool
+	renderScale       int
+	width, height     int
+	scale             float64
+	offsetX, offsetY  float64
+	canvas            *ebiten.Image
+	canvasDrawOptions *ebiten.DrawImageOptions
+	// the canvas at upscale times its size, nil while not needed
+	upscaled *ebiten.Image
+	upscale  int
+	// of the canvas, 1 for its own colors and 0 for gray
+	saturation float64
+}
+
+func NewViewport(integerScaling bool) *Viewport {
+	return &Viewport{
+		integerScaling:    integerScaling,
+		scale:             1,
+		saturation:        1,
+		canvas:            ebiten.NewImage(screenWidth, screenHeight),
+		canvasDrawOptions: &ebiten.DrawImageOptions{},
+	}
+}
+
+func (v *Viewport) Layout(width, height int) {
+	if width <= 0 || height <= 0 {
+		width, height = screenWidth, screenHeight
+	}
+	v.width, v.height = width, height
+
+	scale := math.Min(float64(width)/screenWidth, float64(height)/screenHeight)
+	if v.integerScaling && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	v.scale = scale
+	v.upscale = 1
+	if v.renderScale > 0 {
+		v.upscale = v.renderScale
+	} else if !v.integerScaling || scale != math.Floor(scale) {
+		v.upscale = int(math.Min(math.Ceil(scale), maxCanvasUpscale))
+	}
+	v.offsetX = (float64(width) - screenWidth*scale) / 2
+	v.offsetY = (float64(height) - screenHeight*scale) / 2
+}
+
+func (v *Viewport) SetIntegerScaling(integerScaling bool) {
+	v.integerScaling = integerScaling
+	v.Layout(v.width, v.height)
+}
+
+func (v *Viewport) SetRenderScale(renderScale int) {
+	if renderScale < 0 || renderScale > maxRenderScale {
+		renderScale = 0
+	}
+	v.renderScale = renderScale
+	v.Layout(v.width, v.height)
+}
+
+func (v *Viewport) Size() (int, int) {
+	return v.width, v.height
+}
+
+// ToCanvas converts a position on the screen into canvas coordinates.
+func (v *Viewport) ToCanvas(x, y int) (int, int) {
+	return int((float64(x) - v.offsetX) / v.scale), int((float64(y) - v.offsetY) / v.scale)
+}
+
+// upscaledCanvas returns the canvas scaled up by whole pixels, or the canvas
+// itself if it isn't scaled up.
+func (v *Viewport) upscaledCanvas() *ebiten.Image {
+	if v.upscale <= 1 {
+		return v.canvas
+	}
+	if v.upscaled != nil {
+		if w, _ := v.upscaled.Size(); w != screenWidth*v.upscale {
+			v.upscaled.Dispose()
+			v.upscaled = nil
+		}
+	}
+	if v.upscaled == nil {
+		v.upscaled = ebiten.NewImage(screenWidth*v.upscale, screenHeight*v.upscale)
+	}
+	v.upscaled.Clear()
+	opt := v.canvasDrawOptions
+	opt.GeoM.Reset()
+	opt.GeoM.Scale(float64(v.upscale), float64(v.upscale))
+	opt.ColorM.Reset()
+	opt.Filter = ebiten.FilterNearest
+	v.upscaled.DrawImage(v.canvas, opt)
+	return v.upscaled
+}
+
+func (v *Viewport) Draw(screen *ebiten.Image) {
+	img := v.upscaledCanvas()
+	opt := v.canvasDrawOptions
+	opt.GeoM.Reset()
+	s := v.scale / float64(v.upscale)
+	opt.GeoM.Scale(s, s)
+	opt.GeoM.Translate(v.offsetX, v.offsetY)
+	opt.ColorM.Reset()
+	if v.saturation < 1 {
+		opt.ColorM.ChangeHSV(0, v.saturation, 1)
+	}
+	if s == math.Floor(s) {
+		opt.Filter = ebiten.FilterNearest
+	} else {
+		opt.Filter = ebiten.FilterLinear
+	}
+	screen.DrawImage(img, opt)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	// seconds before a hazard enters the view that it is warned of
+	warningLeadTime    = 1.5
+	warningArrowSize   = 12
+	warningMargin      = 6
+	warningFlashPeriod = 0.3
+)
+
+var (
+	warningColor    = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	warningArrowImg *ebiten.Image
+)
+
+// newArrowImage draws a triangle pointing right, 2s wide and high.
+func newArrowImage(s int, clr color.Color) *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 2*s, 2*s))
+	for y := 0; y < 2*s; y++ {
+		for x := 0; x < 2*s; x++ {
+			if math.Abs(float64(y-s)+0.5) < float64(2*s-x)/2 {
+				img.Set(x, y, clr)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// isIncoming tells a hazard at x coming at speed that enters the view from
+// the right within warningLeadTime.
+func (g *Game) isIncoming(x, halfWidth, speed float64) bool {
+	dx := x - halfWidth - (g.camera.ViewX() + screenWidth)
+	return dx > 0 && dx/(speed+g.birdman.vx) <= warningLeadTime
+}
+
+func (g *Game) incomingThreats() int {
+	n := 0
+	for i := range g.birds {
+		if b := &g.birds[i]; b.isHazard() && g.isIncoming(b.x, birdWidth/2, g.birdSpeed()) {
+			n++
+		}
+	}
+	for i := range g.airplanes {
+		if a := &g.airplanes[i]; g.isIncoming(a.x, airplaneWidth/2, g.config.AirplaneSpeed) {
+			n++
+		}
+	}
+	return n
+}
+
+// drawThreatWarnings flashes an arrow at the right edge of the screen, at
+// the height of every hazard about to enter the view from there.
+func (g *Game) drawThreatWarnings(screen *ebiten.Image) {
+	if math.Mod(g.tricks.time, warningFlashPeriod) >= warningFlashPeriod/2 {
+		return
+	}
+	warn := func(x, y, halfWidth, speed float64) {
+		if !g.isIncoming(x, halfWidth, speed) {
+			return
+		}
+		y = math.Max(warningArrowSize, math.Min(screenHeight-warningArrowSize, y-g.camera.ViewY()))
+		x = screenWidth - warningMargin - 2*warningArrowSize
+		opt := scratchDrawOptions()
+		opt.GeoM.Translate(x, y-warningArrowSize)
+		textX := int(x) - regularFontSize
+		if g.mirrored() {
+			// At the left edge, pointing the other way
+			opt.GeoM.Scale(-1, 1)
+			opt.GeoM.Translate(screenWidth, 0)
+			textX = screenWidth - textX - regularFontSize
+		}
+		screen.DrawImage(warningArrowImg, opt)
+		text.Draw(screen, "!", regularFont, textX, int(y)+regularFontSize/2, warningColor)
+	}
+
+	for i := range g.birds {
+		if b := &g.birds[i]; b.isHazard() {
+			warn(b.x, b.y, birdWidth/2, g.birdSpeed())
+		}
+	}
+	for i := range g.airplanes {
+		a := &g.airplanes[i]
+		warn(a.x, a.y, airplaneWidth/2, g.config.AirplaneSpeed)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Webhook URLs, such as Discord's, are long
+const maxWebhookURLLength = 200
+
+// newBestMessage is what is posted to the webhook on a new personal best,
+// with what plays the run again.
+func newBestMessage(distance int, date time.Time, replay string, run int) string {
+	return fmt.Sprintf("New Birdman personal best: %sm on %s (%s, run %d)", formatIntComma(distance), date.Format("2006-01-02"), replay, run)
+}
+
+// postWebhook posts the message to a chat webhook. Discord reads "content"
+// and Slack "text", and both ignore the other.
+func postWebhook(url, message string) error {
+	b, err := json.Marshal(map[string]string{
+		"content": message,
+		"text":    message,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Error response from the webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+func sendWebhook(url, message string) {
+	go func() {
+		if err := postWebhook(url, message); err != nil {
+			log.Printf("Failed to post to the webhook: %v", err)
+		}
+	}()
+}
+
+// notifyNewBest tells the webhook the player set up about a new best, with
+// the challenge code of the run, or its seed if it can't be made one.
+func (g *Game) notifyNewBest() {
+	url := g.settings.WebhookURL
+	if url == "" || g.webhook == nil {
+		return
+	}
+	replay := fmt.Sprintf("seed %d", g.runSeed)
+	if c := g.runChallenge(); c != nil {
+		replay = "challenge " + formatChallengeCode(c.Encode())
+	}
+	g.webhook(url, newBestMessage(g.records.BestDistance, time.Now(), replay, g.records.Runs))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// the window can't be made smaller than this, where the text is still
+	// readable
+	minWindowWidth  = screenWidth / 2
+	minWindowHeight = screenHeight / 2
+)
+
+// the sizes of the window icon; the OS picks the one fitting the best
+var windowIconSizes = []int{16, 32, 48, 64}
+
+// windowTitles are the titles of the window by language.
+var windowTitles = map[string]string{
+	"en": "Birdman",
+	"ja": "鳥人間",
+	"de": "Vogelmensch",
+	"es": "Hombre pájaro",
+	"fr": "Homme-oiseau",
+}
+
+type WindowSettings struct {
+	Fullscreen     bool `json:"fullscreen"`
+	Width          int  `json:"width"`
+	Height         int  `json:"height"`
+	X              int  `json:"x"`
+	Y              int  `json:"y"`
+	Positioned     bool `json:"positioned"`
+	RenderScale    int  `json:"render_scale"`
+	IntegerScaling bool `json:"integer_scaling"`
+}
+
+func DefaultWindowSettings() WindowSettings {
+	return WindowSettings{
+		Width:  screenWidth,
+		Height: screenHeight,
+	}
+}
+
+// windowTitle returns the title in the language of a tag like "ja-JP" or
+// "ja_JP.UTF-8", in English for the languages without one.
+func windowTitle(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_."); i >= 0 {
+		lang = lang[:i]
+	}
+	if t, ok := windowTitles[lang]; ok {
+		return t
+	}
+	return windowTitles["en"]
+}
+
+func loadWindowIcons(m *AssetManager) ([]image.Image, error) {
+	var icons []image.Image
+	for _, size := range windowIconSizes {
+		name := fmt.Sprintf("icon%d.png", size)
+		data, err := m.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image %s: %w", name, err)
+		}
+		icons = append(icons, img)
+	}
+	return icons, nil
+}
+
+func applyWindowSettings(w *WindowSettings) {
+	ebiten.SetWindowResizable(true)
+	ebiten.SetWindowSizeLimits(minWindowWidth, minWindowHeight, -1, -1)
+	if w.Width > 0 && w.Height > 0 {
+		ebiten.SetWindowSize(w.Width, w.Height)
+	} else {
+		ebiten.SetWindowSize(screenWidth, screenHeight)
+	}
+	if w.Positioned {
+		ebiten.SetWindowPosition(w.X, w.Y)
+	}
+	ebiten.SetFullscreen(w.Fullscreen)
+}
+
+// cycleRenderScale switches the resolution the canvas is rendered at before
+// it is scaled to the screen, from the automatic one through 1x to 3x.
+func (g *Game) cycleRenderScale() {
+	w := &g.settings.Window
+	w.RenderScale = (w.RenderScale + 1) % (maxRenderScale + 1)
+	g.viewport.SetRenderScale(w.RenderScale)
+	g.saveSettings()
+}
+
+func (g *Game) toggleIntegerScaling() {
+	w := &g.settings.Window
+	w.IntegerScaling = !w.IntegerScaling
+	g.viewport.SetIntegerScaling(w.IntegerScaling)
+	g.saveSettings()
+}
+
+func isFullscreenToggleJustPressed() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		return true
+	}
+	return inpututil.IsKeyJustPressed(ebiten.KeyEnter) &&
+		(ebiten.IsKeyPressed(ebiten.KeyAltLeft) || ebiten.IsKeyPressed(ebiten.KeyAltRight))
+}
+
+func (g *Game) toggleFullscreen() {
+	w := &g.settings.Window
+	w.Fullscreen = !w.Fullscreen
+	ebiten.SetFullscreen(w.Fullscreen)
+	g.saveSettings()
+}
+
+// updateWindow handles the fullscreen hotkeys and remembers the window
+// geometry so it can be restored on the next launch.
+func (g *Game) updateWindow() {
+	if isFullscreenToggleJustPressed() {
+		g.toggleFullscreen()
+	}
+
+	w := &g.settings.Window
+	if ebiten.IsFullscreen() || ebiten.IsWindowMinimized() {
+		return
+	}
+	w.Width, w.Height = ebiten.WindowSize()
+	w.X, w.Y = ebiten.WindowPosition()
+	w.Positioned = true
+}